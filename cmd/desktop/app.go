@@ -3,6 +3,8 @@ package main
 import (
 	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -58,6 +60,8 @@ func (a *App) startup(ctx context.Context) {
 		a.cfg = cfg
 		a.client = api.NewClient(cfg.ServerURL, cfg.Token)
 		a.client.ClientKey = cfg.EnsureClientKey(a.profile)
+		a.client.SetConnPool(cfg.MaxIdleConnsPerHost, cfg.IdleConnTimeout())
+		applyDebug(a.client)
 	}
 
 	// Load existing logs from CLI watcher log file
@@ -69,6 +73,14 @@ func (a *App) startup(ctx context.Context) {
 	}
 }
 
+// applyDebug logs the client's API requests to stderr when the app is
+// started with IZEROP_DEBUG set.
+func applyDebug(client *api.Client) {
+	if enabled, bodies := api.DebugFromEnv(); enabled {
+		client.EnableDebug(os.Stderr, bodies)
+	}
+}
+
 // ---- Log capture ----
 
 func (a *App) addLog(level, msg string) {
@@ -89,32 +101,27 @@ func (a *App) addLog(level, msg string) {
 	}
 }
 
-// logWriter adapts addLog to an io.Writer for use with log.Logger
+// logWriter feeds the watcher's JSON log lines into the activity log,
+// using each line's level instead of guessing it from the text.
 type logWriter struct {
-	app   *App
-	level string
+	app *App
 }
 
 func (lw *logWriter) Write(p []byte) (n int, err error) {
-	msg := strings.TrimSpace(string(p))
-	if msg == "" {
+	var entry watcher.LogLine
+	if json.Unmarshal(p, &entry) != nil || entry.Msg == "" {
 		return len(p), nil
 	}
-	// Detect level from message content
-	level := lw.level
-	if strings.Contains(msg, "ERROR") || strings.Contains(msg, "error") || strings.Contains(msg, "failed") {
-		level = "error"
-	} else if strings.Contains(msg, "↓") || strings.Contains(msg, "Downloaded") || strings.Contains(msg, "↑") || strings.Contains(msg, "Uploaded") {
-		level = "success"
-	} else if strings.Contains(msg, "conflict") || strings.Contains(msg, "Conflict") {
-		level = "warn"
-	}
-	lw.app.addLog(level, msg)
+	lw.app.addLog(entry.Level, entry.Msg)
 	return len(p), nil
 }
 
-func (a *App) newLogger() *log.Logger {
-	return log.New(&logWriter{app: a, level: "info"}, "", 0)
+// newLogger returns a watcher logger and JSON log writing to the activity
+// log. The watcher's structured lines include one per changed file, so the
+// activity view shows individual files rather than only per-run totals.
+func (a *App) newLogger() (*log.Logger, *watcher.JSONLogWriter) {
+	jsonLog := watcher.NewJSONLogWriter(&logWriter{app: a}, a.profile)
+	return log.New(jsonLog, "", 0), jsonLog
 }
 
 // ---- Types ----
@@ -172,7 +179,8 @@ func (a *App) Login(serverURL, token string) LoginResult {
 
 	client := api.NewClient(serverURL, token)
 	client.ClientKey = a.cfg.EnsureClientKey(a.profile)
-	_, err := client.GetSyncStatus()
+	applyDebug(client)
+	_, err := client.GetSyncStatus(context.Background())
 	if err != nil {
 		return LoginResult{Success: false, Error: fmt.Sprintf("Connection failed: %v", err)}
 	}
@@ -200,7 +208,7 @@ func (a *App) GetStatus() StatusInfo {
 		return StatusInfo{Connected: false, Error: "Not logged in"}
 	}
 
-	status, err := a.client.GetSyncStatus()
+	status, err := a.client.GetSyncStatus(context.Background())
 	if err != nil {
 		return StatusInfo{
 			Connected: false,
@@ -306,14 +314,49 @@ func (a *App) RunSync() ActionResult {
 		return ActionResult{Success: false, Error: "No sync directory configured. Set one in Sync settings."}
 	}
 
+	strategy, err := pkgsync.ParseConflictStrategy(a.cfg.ConflictStrategy)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+	symlinks, err := pkgsync.ParseSymlinkPolicy(a.cfg.SymlinkPolicy)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+	backend, err := pkgsync.ParseStateBackend(a.cfg.StateBackend)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+
+	// The watcher may be mid-run in this process, or 'izerop sync' in a terminal
+	lock, err := pkgsync.AcquireLock(a.profile, 0)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+	defer lock.Release()
+
 	a.addLog("info", "Starting sync...")
 
 	pkgsync.MigrateState(a.profile, a.cfg.SyncDir)
-	state, _ := pkgsync.LoadState(a.profile)
-	engine := pkgsync.NewEngine(a.client, a.cfg.SyncDir, state)
+	store, err := pkgsync.OpenStore(a.profile, backend)
+	if err != nil {
+		a.addLog("error", err.Error())
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+	defer store.Close()
+	engine := pkgsync.NewEngine(a.client, a.cfg.SyncDir, store.State())
+	engine.SetRoot(a.cfg.SyncRoot)
+	engine.Store = store
+	engine.Ignore = pkgsync.LoadIgnoreRules(a.cfg.SyncDir, a.cfg.DefaultIgnore...)
+	engine.FilterOSJunk = a.cfg.OSJunkFiltered()
+	engine.ConflictStrategy = strategy
+	engine.Symlinks = symlinks
+	engine.Types = pkgsync.LoadFileTypes(a.cfg.SyncDir, a.cfg.TextExtensions, a.cfg.BinaryExtensions)
+	engine.MaxTextSize = a.cfg.TextSizeLimit()
+	ctx := context.Background()
+	engine.PreserveMode = a.cfg.PreserveMode && a.client.Require(ctx, api.FeatureFileModes) == nil
 
 	// Pull
-	pullResult, newCursor, err := engine.PullSync(state.Cursor)
+	pullResult, newCursor, err := engine.PullSync(ctx, store.Cursor())
 	if err != nil {
 		a.addLog("error", fmt.Sprintf("Pull failed: %v", err))
 		return ActionResult{Success: false, Error: err.Error()}
@@ -324,10 +367,11 @@ func (a *App) RunSync() ActionResult {
 	if pullResult.Conflicts > 0 {
 		a.addLog("warn", fmt.Sprintf("Conflicts: %d", pullResult.Conflicts))
 	}
-	state.Cursor = newCursor
+	conflicts := pullResult.ConflictDetails
+	store.SetCursor(newCursor)
 
 	// Push
-	pushResult, err := engine.PushSync()
+	pushResult, err := engine.PushSync(ctx)
 	if err != nil {
 		a.addLog("error", fmt.Sprintf("Push failed: %v", err))
 		return ActionResult{Success: false, Error: err.Error()}
@@ -335,9 +379,20 @@ func (a *App) RunSync() ActionResult {
 	if pushResult.Uploaded > 0 {
 		a.addLog("success", fmt.Sprintf("↑ Uploaded: %d", pushResult.Uploaded))
 	}
+	if pushResult.Conflicts > 0 {
+		a.addLog("warn", fmt.Sprintf("Conflicts: %d", pushResult.Conflicts))
+	}
+	conflicts = append(conflicts, pushResult.ConflictDetails...)
+	for _, c := range conflicts {
+		if c.ConflictFile != "" {
+			a.addLog("warn", fmt.Sprintf("⚠ Conflict: %s (local saved as %s)", c.Path, c.ConflictFile))
+		} else {
+			a.addLog("warn", fmt.Sprintf("⚠ Conflict: %s", c.Path))
+		}
+	}
 
 	// Save state
-	pkgsync.SaveState(a.profile, state)
+	store.Flush()
 
 	total := pullResult.Downloaded + pullResult.Uploaded + pushResult.Uploaded + pullResult.Deleted
 	if total == 0 {
@@ -347,7 +402,8 @@ func (a *App) RunSync() ActionResult {
 	}
 
 	if a.ctx != nil {
-		runtime.EventsEmit(a.ctx, "sync-complete", nil)
+		// Pass the conflicts along so the UI can point at each copy
+		runtime.EventsEmit(a.ctx, "sync-complete", conflicts)
 	}
 
 	return ActionResult{Success: true}
@@ -374,14 +430,57 @@ func (a *App) StartWatch() ActionResult {
 	if settleMs <= 0 {
 		settleMs = config.DefaultSettleTimeMs
 	}
+	pollS := a.cfg.PollIntervalS
+	if pollS <= 0 {
+		pollS = config.DefaultPollIntervalS
+	}
+	var quiet *watcher.QuietHours
+	if a.cfg.QuietHours != "" {
+		q, err := watcher.ParseQuietHours(a.cfg.QuietHours)
+		if err != nil {
+			return ActionResult{Success: false, Error: err.Error()}
+		}
+		quiet = q
+	}
+	strategy, err := pkgsync.ParseConflictStrategy(a.cfg.ConflictStrategy)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+	symlinks, err := pkgsync.ParseSymlinkPolicy(a.cfg.SymlinkPolicy)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+	backend, err := pkgsync.ParseStateBackend(a.cfg.StateBackend)
+	if err != nil {
+		return ActionResult{Success: false, Error: err.Error()}
+	}
 
+	logger, jsonLog := a.newLogger()
 	w, err := watcher.New(watcher.Config{
 		SyncDir:      a.cfg.SyncDir,
 		ServerURL:    a.cfg.ServerURL,
 		Client:       a.client,
-		PollInterval: 30 * time.Second,
+		PollInterval: time.Duration(pollS) * time.Second,
 		SettleTime:   time.Duration(settleMs) * time.Millisecond,
-		Logger:       a.newLogger(),
+		Logger:       logger,
+
+		SyncRoot:          a.cfg.SyncRoot,
+		TextExtensions:    a.cfg.TextExtensions,
+		BinaryExtensions:  a.cfg.BinaryExtensions,
+		NoTextAPI:         a.cfg.NoTextAPI,
+		MaxTextSize:       a.cfg.TextSizeLimit(),
+		NoDelete:          a.cfg.NoDelete,
+		PreserveMode:      a.cfg.PreserveMode,
+		QuietHours:        quiet,
+		ExcludeVCS:        a.cfg.ExcludeVCS,
+		DefaultIgnore:     a.cfg.DefaultIgnore,
+		MaxUploadAttempts: a.cfg.MaxUploadAttempts,
+		MaxFileSize:       int64(a.cfg.MaxFileSize),
+		KeepOSJunk:        !a.cfg.OSJunkFiltered(),
+		ConflictStrategy:  strategy,
+		Symlinks:          symlinks,
+		StateBackend:      backend,
+		JSONLog:           jsonLog,
 	})
 	if err != nil {
 		return ActionResult{Success: false, Error: fmt.Sprintf("Could not start watcher: %v", err)}
@@ -422,6 +521,7 @@ func (a *App) StopWatch() ActionResult {
 		return ActionResult{Success: true}
 	}
 
+	// Cancels a sync in progress too, so stopping doesn't wait for transfers
 	w.Stop()
 	a.addLog("info", "Stopping watcher...")
 	return ActionResult{Success: true}
@@ -540,18 +640,20 @@ func (a *App) loadExistingLogs() {
 		if line == "" {
 			continue
 		}
-		level := "info"
-		if strings.Contains(line, "error") || strings.Contains(line, "ERROR") || strings.Contains(line, "failed") {
-			level = "error"
-		} else if strings.Contains(line, "⬆") || strings.Contains(line, "⬇") || strings.Contains(line, "uploaded") || strings.Contains(line, "downloaded") {
-			level = "success"
-		} else if strings.Contains(line, "conflict") {
-			level = "warn"
+		// Watchers started with --log-format json carry their own level
+		var entry watcher.LogLine
+		if json.Unmarshal([]byte(line), &entry) == nil && entry.Msg != "" {
+			a.logs = append(a.logs, LogEntry{
+				Time:    entry.TS,
+				Message: entry.Msg,
+				Level:   entry.Level,
+			})
+			continue
 		}
 		a.logs = append(a.logs, LogEntry{
 			Time:    "",
 			Message: line,
-			Level:   level,
+			Level:   watcher.LogLevel(line),
 		})
 	}
 	a.logMu.Unlock()
@@ -613,9 +715,18 @@ func (a *App) DoUpdate() ActionResult {
 		return ActionResult{Success: false, Error: "No compatible binary for this platform"}
 	}
 
+	checksum, err := updater.ExpectedChecksum(release, asset)
+	if err != nil {
+		a.addLog("error", fmt.Sprintf("Could not verify release: %v", err))
+		return ActionResult{Success: false, Error: err.Error()}
+	}
+
 	a.addLog("info", fmt.Sprintf("Downloading %s (%s)...", release.TagName, asset.Name))
 
-	if err := updater.DownloadAndReplace(asset); err != nil {
+	if err := updater.DownloadAndReplace(asset, checksum); err != nil {
+		if errors.Is(err, updater.ErrChecksumMismatch) {
+			a.addLog("error", "Downloaded binary failed verification; current version left in place")
+		}
 		a.addLog("error", fmt.Sprintf("Update failed: %v", err))
 		return ActionResult{Success: false, Error: err.Error()}
 	}
@@ -702,6 +813,8 @@ func (a *App) SwitchProfile(name string) ActionResult {
 	if pcfg.Token != "" {
 		a.client = api.NewClient(pcfg.ServerURL, pcfg.Token)
 		a.client.ClientKey = pcfg.EnsureClientKey(name)
+		a.client.SetConnPool(pcfg.MaxIdleConnsPerHost, pcfg.IdleConnTimeout())
+		applyDebug(a.client)
 	} else {
 		a.client = nil
 	}
@@ -757,6 +870,21 @@ func (a *App) GetActiveProfileName() string {
 	return a.profile
 }
 
+// ValidateIgnoreRules checks .izeropignore content before it is saved and
+// returns the lines that won't work: malformed globs, and re-include lines
+// that match nothing in the sync directory.
+func (a *App) ValidateIgnoreRules(rules string) []pkgsync.IgnoreProblem {
+	syncDir := ""
+	if a.cfg != nil {
+		syncDir = a.cfg.SyncDir
+	}
+	problems := pkgsync.ValidateIgnoreRules(rules, syncDir)
+	if problems == nil {
+		problems = []pkgsync.IgnoreProblem{}
+	}
+	return problems
+}
+
 func (a *App) SaveIgnoreRules(rules string) ActionResult {
 	if a.cfg == nil || a.cfg.SyncDir == "" {
 		return ActionResult{Success: false, Error: "No sync directory configured"}
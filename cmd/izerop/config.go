@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+	"github.com/patricksimpson/izerop-cli/pkg/watcher"
+)
+
+func cmdConfig() {
+	// Usage: izerop config list | get <key> | set <key> <value>
+	if len(os.Args) < 3 {
+		printCommandHelp("config")
+		return
+	}
+
+	// Work on config.json as stored, so environment overrides and
+	// defaults aren't written back into it
+	cfg, err := config.ReadProfile(activeProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read config for profile %q: %v\n", activeProfile, err)
+		os.Exit(1)
+	}
+
+	switch os.Args[2] {
+	case "list":
+		for _, key := range config.Keys() {
+			value, _ := cfg.Get(key)
+			if key == "token" && value != "" {
+				value = "(set)"
+			}
+			fmt.Printf("%-24s %s\n", key, value)
+		}
+	case "get":
+		if len(os.Args) != 4 {
+			fmt.Fprintf(os.Stderr, "Usage: izerop config get <key>\n")
+			os.Exit(1)
+		}
+		value, err := cfg.Get(os.Args[3])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(value)
+	case "set":
+		if len(os.Args) != 5 {
+			fmt.Fprintf(os.Stderr, "Usage: izerop config set <key> <value>  (\"\" resets to the default)\n")
+			os.Exit(1)
+		}
+		key := os.Args[3]
+		value, err := checkConfigValue(key, os.Args[4])
+		if err == nil {
+			err = cfg.Set(key, value)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if err := config.SaveProfile(activeProfile, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not save config: %v\n", err)
+			os.Exit(1)
+		}
+		stored, _ := cfg.Get(key)
+		if stored == "" {
+			fmt.Printf("✅ %s reset to the default for profile %q\n", key, activeProfile)
+		} else {
+			fmt.Printf("✅ %s = %s for profile %q\n", key, stored, activeProfile)
+		}
+		if running, _ := getWatcherStatusForProfile(activeProfile); running {
+			fmt.Println("   The watcher is running; apply the change with 'izerop watch restart'.")
+		}
+	case "help", "--help", "-h":
+		printCommandHelp("config")
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown config command: %s\n", os.Args[2])
+		fmt.Fprintf(os.Stderr, "Usage: izerop config list | get <key> | set <key> <value>\n")
+		os.Exit(1)
+	}
+}
+
+// checkConfigValue validates a value for the settings that need more than
+// a type check and returns it normalized. Config.Set parses the rest.
+func checkConfigValue(key, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	switch key {
+	case "server_url":
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("invalid server_url %q: want a URL like https://izerop.com", value)
+		}
+	case "sync_dir":
+		abs, err := filepath.Abs(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid sync_dir: %w", err)
+		}
+		if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+			return "", fmt.Errorf("invalid sync_dir: %s is not a directory", abs)
+		}
+		return abs, nil
+	case "poll_interval_s":
+		if n, err := strconv.Atoi(value); err == nil && n < config.MinPollIntervalS {
+			return "", fmt.Errorf("invalid poll_interval_s %d: the minimum is %d", n, config.MinPollIntervalS)
+		}
+	case "sync_root":
+		root, err := parseRoot(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid sync_root %q: want a remote directory like work or work/notes", value)
+		}
+		return root, nil
+	case "conflict_strategy":
+		if _, err := sync.ParseConflictStrategy(value); err != nil {
+			return "", err
+		}
+	case "symlink_policy":
+		if _, err := sync.ParseSymlinkPolicy(value); err != nil {
+			return "", err
+		}
+	case "state_backend":
+		if _, err := sync.ParseStateBackend(value); err != nil {
+			return "", err
+		}
+	case "quiet_hours":
+		if _, err := watcher.ParseQuietHours(value); err != nil {
+			return "", err
+		}
+	case "time_format":
+		layout := timeFormatLayout(value)
+		if time.Now().Format(layout) == layout {
+			return "", fmt.Errorf("invalid time_format %q: write it as the reference time, like 2006-01-02 15:04", value)
+		}
+	case "log_format":
+		if value != "text" && value != "json" {
+			return "", fmt.Errorf("invalid log_format %q (expected text or json)", value)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+// detachedProcessFlag is DETACHED_PROCESS, which syscall doesn't export.
+const detachedProcessFlag = 0x00000008
+
+// detachedProcAttr starts the daemon without a console and in its own
+// process group so it survives the parent console closing.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcessFlag,
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+)
+
+// doctorTimeout bounds the server check so an unreachable host fails fast.
+const doctorTimeout = 10 * time.Second
+
+// doctor prints a checklist of results and counts critical failures.
+type doctor struct {
+	failed int
+}
+
+func (d *doctor) pass(name, detail string) {
+	fmt.Printf("  ✅ %-10s %s\n", name, detail)
+}
+
+// warn reports a problem that doesn't stop izerop from working.
+func (d *doctor) warn(name, detail, hint string) {
+	fmt.Printf("  ⚠  %-10s %s\n", name, detail)
+	d.hint(hint)
+}
+
+// fail reports a problem that breaks syncing; doctor exits non-zero.
+func (d *doctor) fail(name, detail, hint string) {
+	d.failed++
+	fmt.Printf("  ❌ %-10s %s\n", name, detail)
+	d.hint(hint)
+}
+
+func (d *doctor) hint(hint string) {
+	if hint != "" {
+		fmt.Printf("     %-10s → %s\n", "", hint)
+	}
+}
+
+// cmdDoctor checks the active profile's config, server, token, sync
+// directory, watcher PID file, and state file, and exits non-zero if
+// anything critical failed. loadErr is the error from loading the config.
+func cmdDoctor(cfg *config.Config, loadErr error) {
+	// Usage: izerop doctor
+	d := &doctor{}
+	fmt.Printf("🩺 Checking profile %q\n\n", activeProfile)
+
+	if cfg == nil {
+		if loadErr == nil {
+			loadErr = errors.New("no config")
+		}
+		d.fail("Config", loadErr.Error(), "run 'izerop login' (or 'izerop profile add') to configure this profile")
+	} else {
+		path, _ := config.ProfileConfigPath(activeProfile)
+		d.pass("Config", "loaded "+path)
+		d.checkServer(cfg)
+		d.checkSyncDir(cfg)
+	}
+	d.checkWatcher()
+	d.checkState(cfg)
+
+	fmt.Println()
+	if d.failed > 0 {
+		fmt.Printf("❌ %d critical problem(s) found\n", d.failed)
+		os.Exit(1)
+	}
+	fmt.Println("✅ All critical checks passed")
+}
+
+// checkServer checks the server URL, that the server answers, and that
+// the token authenticates (via GetSyncStatus), then lists the server's
+// optional features.
+func (d *doctor) checkServer(cfg *config.Config) {
+	u, err := url.Parse(cfg.ServerURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		d.fail("Server", fmt.Sprintf("invalid server URL %q", cfg.ServerURL), "set server_url to an http(s) URL, or pass --server")
+		d.warn("Token", "not checked (no server)", "")
+		return
+	}
+
+	client := newClient(cfg)
+	client.HTTPClient.Timeout = doctorTimeout
+	_, err = client.GetSyncStatus(runCtx)
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		d.fail("Server", fmt.Sprintf("%s is unreachable (%v)", cfg.ServerURL, urlErr.Err), "check the server URL and your network connection")
+		d.warn("Token", "not checked (server unreachable)", "")
+		return
+	}
+	d.pass("Server", "reachable at "+cfg.ServerURL)
+
+	switch {
+	case cfg.Token == "":
+		d.fail("Token", "not logged in", "run 'izerop login'")
+	case errors.Is(err, api.ErrUnauthorized):
+		d.fail("Token", "rejected by the server (invalid or expired)", "run 'izerop login'")
+	case err != nil:
+		d.fail("Token", fmt.Sprintf("could not verify (%v)", err), "try again later; if it persists, check the server")
+	default:
+		d.pass("Token", "valid")
+		d.checkFeatures(client)
+	}
+}
+
+// checkFeatures lists the optional features the server reports.
+func (d *doctor) checkFeatures(client *api.Client) {
+	caps, err := client.Capabilities(runCtx)
+	switch {
+	case err != nil:
+		d.warn("Features", fmt.Sprintf("could not check (%v)", err), "")
+	case !caps.Known:
+		d.pass("Features", "not reported by the server (optional commands are tried as needed)")
+	case len(caps.Features) == 0:
+		d.pass("Features", "no optional features")
+	default:
+		names := make([]string, len(caps.Features))
+		for i, f := range caps.Features {
+			names[i] = string(f)
+		}
+		d.pass("Features", strings.Join(names, ", "))
+	}
+}
+
+// checkSyncDir checks that the sync directory exists and is writable.
+func (d *doctor) checkSyncDir(cfg *config.Config) {
+	if cfg.SyncDir == "" {
+		d.warn("Sync dir", "not configured (sync and watch need a directory argument)", "set sync_dir in the profile's config.json")
+		return
+	}
+	info, err := os.Stat(cfg.SyncDir)
+	if err != nil {
+		d.fail("Sync dir", fmt.Sprintf("%s: %v", cfg.SyncDir, err), "create it with 'mkdir -p "+cfg.SyncDir+"', or fix sync_dir")
+		return
+	}
+	if !info.IsDir() {
+		d.fail("Sync dir", cfg.SyncDir+" is not a directory", "point sync_dir at a directory")
+		return
+	}
+	f, err := os.CreateTemp(cfg.SyncDir, ".izerop-doctor-*")
+	if err != nil {
+		d.fail("Sync dir", fmt.Sprintf("%s is not writable (%v)", cfg.SyncDir, err), "fix the directory's permissions")
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	d.pass("Sync dir", cfg.SyncDir+" is writable")
+}
+
+// checkWatcher checks that the watcher's PID file, if any, names a
+// running process. Unlike watcherStatusAt it never removes the file.
+func (d *doctor) checkWatcher() {
+	pidPath := profilePIDPath(activeProfile)
+	data, err := os.ReadFile(pidPath)
+	if os.IsNotExist(err) {
+		d.pass("Watcher", "not running")
+		return
+	}
+	if err != nil {
+		d.warn("Watcher", fmt.Sprintf("cannot read %s (%v)", pidPath, err), "remove the file")
+		return
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		d.warn("Watcher", "PID file "+pidPath+" is garbled", "remove the file, then restart with 'izerop watch start'")
+		return
+	}
+	if proc, err := os.FindProcess(pid); err == nil && proc.Signal(syscall.Signal(0)) == nil {
+		d.pass("Watcher", fmt.Sprintf("running (PID %d)", pid))
+		return
+	}
+	d.warn("Watcher", fmt.Sprintf("stale PID file: PID %d is not running", pid), "run 'izerop watch status' to clear it, then 'izerop watch start'")
+}
+
+// checkState checks that the sync state file, if any, is valid JSON. A
+// corrupt file with a valid backup is only a warning: the next sync
+// recovers from the backup. With state_backend sqlite it checks the state
+// database instead, once one exists.
+func (d *doctor) checkState(cfg *config.Config) {
+	if cfg != nil && cfg.StateBackend == string(sync.BackendSQLite) {
+		if path, err := sync.StateDBPath(activeProfile); err == nil {
+			if _, err := os.Stat(path); err == nil {
+				d.checkStateDB(path)
+				return
+			}
+		}
+	}
+	path, err := sync.StatePath(activeProfile)
+	if err != nil {
+		d.fail("State", err.Error(), "")
+		return
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		d.pass("State", "no sync state yet")
+		return
+	}
+	if err == nil && json.Valid(data) {
+		d.pass("State", path+" is valid")
+		return
+	}
+	if bak, bakErr := os.ReadFile(path + ".bak"); bakErr == nil && json.Valid(bak) {
+		d.warn("State", path+" is corrupt; the backup is valid", "the next sync recovers from the backup")
+		return
+	}
+	d.fail("State", path+" is corrupt and has no valid backup", "run 'izerop state reset' (the next sync re-checks every file)")
+}
+
+// checkStateDB checks that the SQLite state database opens and reads.
+func (d *doctor) checkStateDB(path string) {
+	store, err := sync.OpenSQLiteStore(activeProfile)
+	if err != nil {
+		d.fail("State", err.Error(), "run 'izerop state reset' (the next sync re-checks every file)")
+		return
+	}
+	store.Close()
+	d.pass("State", path+" is valid")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+)
+
+// cmdExport downloads every file on the server into destDir, mirroring the
+// remote directory tree. Unlike sync and reconcile it is stateless and
+// one-way: it never reads or writes the sync state, uploads, or deletes.
+func cmdExport(cfg *config.Config) {
+	// Usage: izerop export <dest-dir> [--overwrite] [--concurrency <n>] [--progress|--no-progress]
+	destDir := ""
+	overwrite := false
+	jobs := sync.DefaultWorkers
+
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--overwrite":
+			overwrite = true
+		case "--concurrency", "-j":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "%s requires a number\n", os.Args[i])
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Invalid %s value: %s (want a number of at least 1)\n", os.Args[i], os.Args[i+1])
+				os.Exit(1)
+			}
+			jobs = n
+			i++
+		default:
+			if !strings.HasPrefix(os.Args[i], "-") {
+				destDir = os.Args[i]
+			}
+		}
+	}
+
+	if destDir == "" {
+		fmt.Fprintf(os.Stderr, "Usage: izerop export <dest-dir> [--overwrite] [--concurrency <n>]\n")
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	progress := trackTransfers(client, 0)
+	if jobs > 1 && progress != nil {
+		client.OnTransfer = nil
+		progress = nil
+	}
+
+	manifest, err := client.GetManifest(runCtx, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not fetch manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	start := time.Now()
+	t := &treeDownloader{client: client, progress: progress}
+	skipped := 0
+
+	// Directories first, so empty remote folders are exported too
+	for _, d := range manifest.Directories {
+		if strings.Trim(d.Path, "/") == "" {
+			continue
+		}
+		localDir, ok := exportPath(destDir, d.Path)
+		if !ok {
+			t.errs = append(t.errs, fmt.Sprintf("skipping unsafe path %q", d.Path))
+			continue
+		}
+		if err := os.MkdirAll(localDir, 0755); err != nil {
+			t.errs = append(t.errs, fmt.Sprintf("mkdir %s: %v", localDir, err))
+			continue
+		}
+		t.dirs++
+	}
+
+	files := manifest.Files
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	for _, f := range files {
+		dest, ok := exportPath(destDir, f.Path)
+		if !ok {
+			t.errs = append(t.errs, fmt.Sprintf("skipping unsafe path %q", f.Path))
+			continue
+		}
+		if !overwrite {
+			if _, err := os.Lstat(dest); err == nil {
+				skipped++
+				continue
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			t.errs = append(t.errs, fmt.Sprintf("mkdir %s: %v", filepath.Dir(dest), err))
+			continue
+		}
+		t.pending = append(t.pending, treeDownload{
+			file: api.FileEntry{ID: f.ID, Name: filepath.Base(dest), Path: f.Path, Size: f.Size, ContentHash: f.ContentHash},
+			dest: dest,
+			rel:  strings.TrimPrefix(f.Path, "/"),
+		})
+	}
+
+	fmt.Printf("📦 Exporting %d file(s) into %s...\n", len(t.pending), destDir)
+	t.run(jobs)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	for _, e := range t.errs {
+		fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
+	}
+	fmt.Printf("✅ Exported %d file(s) (%s), %d dir(s)", t.files, formatSize(t.bytes), t.dirs)
+	if skipped > 0 {
+		fmt.Printf(", %d skipped (already exist; use --overwrite to replace)", skipped)
+	}
+	if len(t.errs) > 0 {
+		fmt.Printf(", %d error(s)", len(t.errs))
+	}
+	fmt.Printf(" in %s\n", elapsed)
+	if len(t.errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// exportPath maps a remote path like "/root/notes/todo" to its place under
+// destDir. It reports false if any path element isn't a safe local name,
+// so a hostile path can't escape destDir.
+func exportPath(destDir, remotePath string) (string, bool) {
+	rel := strings.Trim(remotePath, "/")
+	if rel == "" {
+		return "", false
+	}
+	parts := strings.Split(rel, "/")
+	for _, p := range parts {
+		if !safeName(p) || strings.ContainsRune(p, 0) {
+			return "", false
+		}
+	}
+	return filepath.Join(append([]string{destDir}, parts...)...), true
+}
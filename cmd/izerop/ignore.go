@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+)
+
+func cmdIgnore(cfg *config.Config) {
+	// Usage: izerop ignore test-run [dir] [options]
+	if len(os.Args) < 3 {
+		printCommandHelp("ignore")
+		return
+	}
+
+	switch os.Args[2] {
+	case "test-run":
+		cmdIgnoreTestRun(cfg)
+	case "help", "--help", "-h":
+		printCommandHelp("ignore")
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown ignore subcommand: %s\n", os.Args[2])
+		fmt.Fprintf(os.Stderr, "Usage: izerop ignore test-run [dir] [options]\n")
+		os.Exit(1)
+	}
+}
+
+// cmdIgnoreTestRun lists the files sync would consider in a directory and
+// the paths its ignore rules leave out, with the rule that excluded each.
+// It only reads the local filesystem, so it needs no server or sync state.
+func cmdIgnoreTestRun(cfg *config.Config) {
+	// Usage: izerop ignore test-run [dir] [--exclude-vcs] [--exclude <glob>] [--include <glob>] [--excluded]
+	syncDir := cfg.SyncDir
+	excludeVCS := cfg.ExcludeVCS
+	excludedOnly := false
+	var runRules []string
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--exclude-vcs":
+			excludeVCS = true
+		case "--exclude", "--include":
+			runRules = appendRunRule(runRules, os.Args[i], os.Args[i+1:])
+			i++
+		case "--excluded":
+			excludedOnly = true
+		default:
+			if strings.HasPrefix(os.Args[i], "--") {
+				fmt.Fprintf(os.Stderr, "Unknown option: %s\n", os.Args[i])
+				os.Exit(1)
+			}
+			syncDir = os.Args[i]
+		}
+	}
+	if syncDir == "" {
+		syncDir = "."
+	}
+	info, err := os.Stat(syncDir)
+	if err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Not a directory: %s\n", syncDir)
+		os.Exit(1)
+	}
+
+	engine := sync.NewEngine(nil, syncDir, &sync.State{})
+	engine.Ignore = sync.LoadIgnoreRules(syncDir, cfg.DefaultIgnore...)
+	engine.FilterOSJunk = cfg.OSJunkFiltered()
+	engine.Symlinks = symlinkPolicy(cfg)
+	if excludeVCS {
+		engine.ExcludeVCS()
+	}
+	engine.Ignore.AddPatterns(runRules)
+
+	checks, err := engine.CheckIgnores()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not walk %s: %v\n", syncDir, err)
+		os.Exit(1)
+	}
+
+	var included, excluded []sync.IgnoreCheck
+	var includedSize int64
+	for _, c := range checks {
+		if c.Ignored {
+			excluded = append(excluded, c)
+		} else {
+			included = append(included, c)
+			includedSize += c.Size
+		}
+	}
+
+	if !excludedOnly {
+		fmt.Printf("✅ Included (%d file(s), %s):\n", len(included), formatSize(includedSize))
+		for _, c := range included {
+			fmt.Printf("  %s\n", c.Path)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("🙈 Excluded (%d):\n", len(excluded))
+	for _, c := range excluded {
+		p := c.Path
+		if c.IsDir {
+			p += "/"
+		}
+		fmt.Printf("  %-40s %s\n", p, c.Rule)
+	}
+	if engine.Ignore.IncludeMode() {
+		fmt.Println("\n.izeropignore is in include mode: files are excluded unless a pattern lists them.")
+	}
+}
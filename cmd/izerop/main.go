@@ -1,16 +1,24 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	gosync "sync"
 	"syscall"
 	"time"
 
@@ -29,14 +37,18 @@ var version = "dev"
 // Defaults to the user's configured active profile (set via `izerop profile use <name>`).
 var activeProfile string
 
+// project is the .izerop/config found by walking up from the working directory, if any.
+var project *config.ProjectConfig
+
 func main() {
 	// Save original args before any modification
 	originalArgs = make([]string, len(os.Args))
 	copy(originalArgs, os.Args)
 
+	debugHTTP, debugBodies = api.DebugFromEnv()
+
 	// Extract --server and --profile flags before command parsing
 	args := os.Args[1:]
-	var serverOverride string
 	var filtered []string
 	for i := 0; i < len(args); i++ {
 		if args[i] == "--server" && i+1 < len(args) {
@@ -49,13 +61,33 @@ func main() {
 			i++
 		} else if len(args[i]) > 10 && args[i][:10] == "--profile=" {
 			activeProfile = args[i][10:]
+		} else if args[i] == "--utc" {
+			timeUTC = true
+		} else if args[i] == "--local" || args[i] == "--local-time" {
+			timeUTC = false
+		} else if args[i] == "--debug" {
+			debugHTTP = true
+		} else if strings.HasPrefix(args[i], "--debug=") {
+			debugHTTP, debugBodies = api.ParseDebug(strings.TrimPrefix(args[i], "--debug="))
 		} else {
 			filtered = append(filtered, args[i])
 		}
 	}
 	os.Args = append([]string{os.Args[0]}, filtered...)
 
-	// If no --profile flag was given, use the configured default profile
+	// A .izerop/config in the current directory or a parent pins project settings
+	if cwd, err := os.Getwd(); err == nil {
+		pc, err := config.FindProjectConfig(cwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+		}
+		project = pc
+	}
+
+	// If no --profile flag was given, use the project's profile or the configured default
+	if activeProfile == "" && project != nil {
+		activeProfile = project.Profile
+	}
 	if activeProfile == "" {
 		activeProfile = config.GetActiveProfile()
 	}
@@ -65,24 +97,43 @@ func main() {
 		os.Exit(1)
 	}
 
+	// These commands run without a working profile
+	configOptional := os.Args[1] == "login" || os.Args[1] == "version" || os.Args[1] == "help" || os.Args[1] == "profile" || os.Args[1] == "rename-profile" || os.Args[1] == "doctor"
+
 	cfg, err := config.LoadProfile(activeProfile)
-	if err != nil && os.Args[1] != "login" && os.Args[1] != "version" && os.Args[1] != "help" && os.Args[1] != "profile" {
+	if err != nil && !configOptional {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		fmt.Fprintf(os.Stderr, "Run 'izerop login' to configure.\n")
 		os.Exit(1)
 	}
 
+	// Project settings win over the user profile, except that a project
+	// can't send the profile's token to a server of its choosing
+	if project != nil && cfg != nil {
+		if err := project.Apply(cfg); err != nil && serverOverride == "" && !configOptional {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Your token is only sent to the profile's server. To use the project's, pass --server %s\n", project.ServerURL)
+			fmt.Fprintf(os.Stderr, "or give it a profile of its own ('izerop --profile <name> login').\n")
+			os.Exit(1)
+		}
+	}
+
 	// --server flag takes highest priority
 	if serverOverride != "" && cfg != nil {
 		cfg.ServerURL = serverOverride
 	}
 
+	if cfg != nil && cfg.TimeFormat != "" {
+		timeLayout = timeFormatLayout(cfg.TimeFormat)
+	}
+
 	switch os.Args[1] {
 	case "version":
 		v := strings.TrimPrefix(version, "v")
 		fmt.Printf("izerop-cli v%s\n", v)
 	case "login":
-		if err := auth.Login(); err != nil {
+		useKeyring := len(os.Args) > 2 && os.Args[2] == "--keyring"
+		if err := auth.Login(useKeyring); err != nil {
 			fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -94,14 +145,28 @@ func main() {
 		cmdReconcile(cfg)
 	case "push":
 		cmdPush(cfg)
+	case "import", "import-dir":
+		cmdImport(cfg)
 	case "url":
 		cmdURL(cfg)
+	case "share":
+		cmdShare(cfg)
 	case "conflicts":
 		cmdConflicts(cfg)
 	case "pull":
 		cmdPull(cfg)
+	case "export":
+		cmdExport(cfg)
+	case "manifest":
+		cmdManifest(cfg)
+	case "verify":
+		cmdVerify(cfg)
+	case "cat":
+		cmdCat(cfg)
 	case "ls":
 		cmdList(cfg)
+	case "tree":
+		cmdTree(cfg)
 	case "mkdir":
 		cmdMkdir(cfg)
 	case "rm":
@@ -133,9 +198,19 @@ func main() {
 				}
 				cmdWatchStop()
 				return
+			case "restart":
+				// izerop watch restart [--all]
+				cmdWatchRestart()
+				return
 			case "status":
 				cmdWatchStatus()
 				return
+			case "pause":
+				signalWatcher(watcher.PauseSignal, "⏸ Paused")
+				return
+			case "resume":
+				signalWatcher(watcher.ResumeSignal, "▶ Resumed")
+				return
 			case "help", "--help", "-h":
 				printCommandHelp("watch")
 				return
@@ -161,12 +236,28 @@ func main() {
 		cmdWatch(cfg)
 	case "logs":
 		cmdLogs()
+	case "service":
+		cmdService(cfg)
 	case "update":
 		cmdUpdate()
 	case "profile":
 		cmdProfile()
 	case "client":
 		cmdClient(cfg)
+	case "clients":
+		cmdClients(cfg)
+	case "state":
+		cmdState()
+	case "ignore":
+		cmdIgnore(cfg)
+	case "config":
+		cmdConfig()
+	case "rename-profile":
+		cmdProfileRename(os.Args[2:])
+	case "whoami":
+		cmdWhoami(cfg)
+	case "doctor":
+		cmdDoctor(cfg, err)
 	case "help":
 		if len(os.Args) > 2 {
 			printCommandHelp(os.Args[2])
@@ -183,10 +274,103 @@ func main() {
 func newClient(cfg *config.Config) *api.Client {
 	client := api.NewClient(cfg.ServerURL, cfg.Token)
 	client.ClientKey = cfg.EnsureClientKey(activeProfile)
+	client.SetConnPool(cfg.MaxIdleConnsPerHost, cfg.IdleConnTimeout())
+	applyDebug(client)
 	return client
 }
 
+// applyDebug turns on request logging to stderr if --debug or IZEROP_DEBUG
+// asked for it.
+func applyDebug(client *api.Client) {
+	if debugHTTP {
+		client.EnableDebug(os.Stderr, debugBodies)
+	}
+}
+
+// requireFeature exits with a clear message if the server reports that it
+// lacks an optional feature the command needs. Servers that don't report
+// their features are let through; the request itself then fails with
+// api.ErrNotSupported if the endpoint is missing.
+func requireFeature(client *api.Client, feature api.Feature, what string) {
+	if err := client.Require(runCtx, feature); err != nil {
+		caps, _ := client.Capabilities(runCtx)
+		fmt.Fprintf(os.Stderr, "❌ %s %s", what, api.ErrNotSupported)
+		if caps != nil && caps.Version != "" {
+			fmt.Fprintf(os.Stderr, " (version %s)", caps.Version)
+		}
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+}
+
 func cmdStatus(cfg *config.Config) {
+	// Usage: izerop status [--check] [--verbose] [--watch [--refresh <seconds>]]
+	check := false
+	verbose := false
+	watch := false
+	refresh := statusRefresh
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--check":
+			check = true
+		case "--verbose", "-v":
+			verbose = true
+		case "--watch", "-w":
+			watch = true
+		case "--refresh":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "--refresh requires a number of seconds\n")
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Invalid --refresh value: %s (want a number of seconds, at least 1)\n", os.Args[i+1])
+				os.Exit(1)
+			}
+			refresh = time.Duration(n) * time.Second
+			i++
+		}
+	}
+
+	if watch {
+		watchStatus(refresh, func(w io.Writer) { printStatus(w, check, verbose) })
+		return
+	}
+	printStatus(os.Stdout, check, verbose)
+}
+
+// statusRefresh is how often 'izerop status --watch' redraws by default.
+const statusRefresh = 5 * time.Second
+
+// watchStatus redraws the status every interval until Ctrl+C. Each frame is
+// rendered before the screen is cleared, so a slow server doesn't leave it
+// blank. The cursor is hidden while it runs and restored on exit.
+func watchStatus(interval time.Duration, render func(io.Writer)) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h\n")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var frame bytes.Buffer
+		render(&frame)
+		fmt.Printf("\033[H\033[2J%s\n%s · refreshing every %s · Ctrl+C to exit",
+			frame.String(), time.Now().Format("15:04:05"), interval)
+		select {
+		case <-sigCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// printStatus writes each profile's server, watcher, and sync state to w.
+// check adds a drift report; verbose lists stuck and drifted paths.
+func printStatus(w io.Writer, check, verbose bool) {
 	profiles, _ := config.ListProfiles()
 	if len(profiles) == 0 {
 		profiles = []string{activeProfile}
@@ -194,12 +378,12 @@ func cmdStatus(cfg *config.Config) {
 
 	for i, name := range profiles {
 		if i > 0 {
-			fmt.Println()
+			fmt.Fprintln(w)
 		}
 
 		pcfg, err := config.LoadProfile(name)
 		if err != nil {
-			fmt.Printf("Profile: %s (error: %v)\n", name, err)
+			fmt.Fprintf(w, "Profile: %s (error: %v)\n", name, err)
 			continue
 		}
 
@@ -207,48 +391,126 @@ func cmdStatus(cfg *config.Config) {
 		if name == activeProfile {
 			active = " ★"
 		}
-		fmt.Printf("Profile: %s%s\n", name, active)
-		fmt.Printf("Server:  %s\n", pcfg.ServerURL)
+		fmt.Fprintf(w, "Profile: %s%s\n", name, active)
+		fmt.Fprintf(w, "Server:  %s\n", pcfg.ServerURL)
 		if pcfg.SyncDir != "" {
-			fmt.Printf("Sync:    %s\n", pcfg.SyncDir)
+			fmt.Fprintf(w, "Sync:    %s\n", pcfg.SyncDir)
 		}
 
 		// Watcher status
 		running, pid := getWatcherStatusForProfile(name)
 		if running {
-			fmt.Printf("Watcher: ✅ running (PID %d)\n", pid)
-			if statInfo, err := os.Stat(fmt.Sprintf("/proc/%d", pid)); err == nil {
-				uptime := time.Since(statInfo.ModTime()).Truncate(time.Second)
-				fmt.Printf("Uptime:  %s\n", uptime)
+			fmt.Fprintf(w, "Watcher: ✅ running (PID %d)\n", pid)
+			if uptime, ok := watcherUptime(name); ok {
+				fmt.Fprintf(w, "Uptime:  %s\n", uptime)
 			}
 		} else {
-			fmt.Printf("Watcher: ⏹ not running\n")
+			fmt.Fprintf(w, "Watcher: ⏹ not running\n")
 		}
 
 		// Remote stats
 		if pcfg.Token != "" {
 			client := api.NewClient(pcfg.ServerURL, pcfg.Token)
-			status, err := client.GetSyncStatus()
+			applyDebug(client)
+			status, err := client.GetSyncStatus(runCtx)
 			if err != nil {
-				fmt.Printf("Remote:  error (%v)\n", err)
+				fmt.Fprintf(w, "Remote:  error (%v)\n", err)
 			} else {
-				fmt.Printf("Files:   %d\n", status.FileCount)
-				fmt.Printf("Dirs:    %d\n", status.DirectoryCount)
-				fmt.Printf("Size:    %s\n", formatSize(status.TotalSize))
+				fmt.Fprintf(w, "Files:   %d\n", status.FileCount)
+				fmt.Fprintf(w, "Dirs:    %d\n", status.DirectoryCount)
+				fmt.Fprintf(w, "Size:    %s\n", formatSize(status.TotalSize))
 			}
 		}
 
 		// Local state
 		if pcfg.SyncDir != "" {
-			state, _ := sync.LoadState(name)
-			fmt.Printf("Tracked: %d files, %d notes\n", len(state.Files), len(state.Notes))
+			backend, _ := sync.ParseStateBackend(pcfg.StateBackend)
+			store, _ := sync.OpenStore(name, backend)
+			state := store.State()
+			tracked := 0
+			store.EachRecord(func(string, sync.FileRecord) { tracked++ })
+			fmt.Fprintf(w, "Tracked: %d files, %d notes\n", tracked, len(state.Notes))
+			printStatusStuck(w, state, pcfg.MaxUploadAttempts, verbose)
+
+			if check && pcfg.Token != "" {
+				client := api.NewClient(pcfg.ServerURL, pcfg.Token)
+				applyDebug(client)
+				engine := sync.NewEngine(client, pcfg.SyncDir, state)
+				engine.SetRoot(pcfg.SyncRoot)
+				engine.Store = store
+				engine.Ignore = sync.LoadIgnoreRules(pcfg.SyncDir, pcfg.DefaultIgnore...)
+				engine.FilterOSJunk = pcfg.OSJunkFiltered()
+				printDrift(w, engine, verbose)
+			}
+			store.Close()
+		}
+	}
+}
+
+// printStatusStuck summarizes files whose uploads have been failing, listing
+// each one with --verbose.
+func printStatusStuck(w io.Writer, state *sync.State, maxAttempts int, verbose bool) {
+	stuck := state.StuckUploads()
+	if len(stuck) == 0 {
+		return
+	}
+	skipped := 0
+	for _, s := range stuck {
+		if maxAttempts > 0 && s.Count >= maxAttempts {
+			skipped++
+		}
+	}
+	fmt.Fprintf(w, "Failing: ⛔ %d file(s) failed to upload", len(stuck))
+	if skipped > 0 {
+		fmt.Fprintf(w, ", %d skipped until they change", skipped)
+	}
+	fmt.Fprintln(w)
+	if verbose {
+		for _, s := range stuck {
+			fmt.Fprintf(w, "  %s — %d attempt(s), last %s: %s\n", s.Path, s.Count, formatTime(s.LastTry), s.LastError)
+		}
+	} else {
+		fmt.Fprintf(w, "         Run 'izerop status -v' to list them\n")
+	}
+}
+
+// printDrift runs a read-only integrity check of local sync state against
+// the server manifest and prints what disagrees.
+func printDrift(w io.Writer, engine *sync.Engine, verbose bool) {
+	report, err := engine.CheckDrift(runCtx)
+	if err != nil {
+		fmt.Fprintf(w, "Drift:   error (%v)\n", err)
+		return
+	}
+	if report.Clean() {
+		fmt.Fprintf(w, "Drift:   ✅ state matches server\n")
+		return
+	}
+
+	fmt.Fprintf(w, "Drift:   ⚠ %d untracked on server, %d missing on server, %d hash mismatches\n",
+		len(report.Untracked), len(report.MissingRemote), len(report.HashMismatch))
+	if verbose {
+		for _, p := range report.Untracked {
+			fmt.Fprintf(w, "  + %s (on server, untracked)\n", p)
+		}
+		for _, p := range report.MissingRemote {
+			fmt.Fprintf(w, "  - %s (tracked, missing on server)\n", p)
+		}
+		for _, p := range report.HashMismatch {
+			fmt.Fprintf(w, "  ≠ %s (hash differs)\n", p)
 		}
 	}
+	fmt.Fprintf(w, "         Run 'izerop reconcile' to repair\n")
 }
 
 // getWatcherStatusForProfile checks if a profile's watcher is running.
 func getWatcherStatusForProfile(profile string) (bool, int) {
-	pidPath := profilePIDPath(profile)
+	return watcherStatusAt(profilePIDPath(profile))
+}
+
+// watcherStatusAt checks if the process recorded in a PID file is running.
+// Stale PID files are removed.
+func watcherStatusAt(pidPath string) (bool, int) {
 	data, err := os.ReadFile(pidPath)
 	if err != nil {
 		return false, 0
@@ -273,18 +535,101 @@ func getWatcherStatusForProfile(profile string) (bool, int) {
 }
 
 func cmdSync(cfg *config.Config) {
-	// Usage: izerop sync [<directory>] [--push-only] [--pull-only] [--verbose]
+	// Usage: izerop sync [<directory> | --map <local>:<remote-path>] [--root <remote-dir>] [--confirm-first-sync] [--dry-run] [--dump-plan [--out <file>]] [--push-only] [--pull-only] [--only-new] [--no-text-api] [--no-delete] [--max-size <size>] [--skip-growing] [--exclude-vcs] [--exclude <glob>] [--include <glob>] [--checkpoint-every N] [--force] [--report-conflicts-only] [--watch-once] [--progress] [--verbose] [--no-progress]
 	syncDir := cfg.SyncDir
+	dryRun := false
 	pushOnly := false
 	pullOnly := false
 	verbose := false
+	progress := false
+	noDelete := cfg.NoDelete
+	skipGrowing := cfg.SkipGrowing
+	excludeVCS := cfg.ExcludeVCS
+	checkpointEvery := 0
+	force := false
+	dumpPlan := false
+	planOut := ""
+	conflictsOnly := false
+	onlyNew := false
+	noTextAPI := cfg.NoTextAPI
+	maxSize := int64(cfg.MaxFileSize)
+	mapArg := ""
+	rootArg := ""
+	confirmFirst := false
+	watchOnce := false
+	var runRules []string
 
 	for i := 2; i < len(os.Args); i++ {
 		switch os.Args[i] {
+		case "--force":
+			force = true
+		case "--watch-once":
+			watchOnce = true
+		case "--map", "--dir":
+			if i+1 < len(os.Args) {
+				mapArg = os.Args[i+1]
+				i++
+			}
+		case "--root":
+			if i+1 < len(os.Args) {
+				rootArg = os.Args[i+1]
+				i++
+			}
+		case "--confirm-first-sync":
+			confirmFirst = true
+		case "--report-conflicts-only":
+			conflictsOnly = true
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--dump-plan":
+			dumpPlan = true
+			dryRun = true
+		case "--out":
+			if i+1 < len(os.Args) {
+				planOut = os.Args[i+1]
+				i++
+			}
 		case "--push-only":
 			pushOnly = true
 		case "--pull-only":
 			pullOnly = true
+		case "--only-new":
+			onlyNew = true
+		case "--no-text-api":
+			noTextAPI = true
+		case "--max-size":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "--max-size requires a size (e.g. 500MB)\n")
+				os.Exit(1)
+			}
+			size, err := config.ParseByteSize(os.Args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --max-size: %v\n", err)
+				os.Exit(1)
+			}
+			maxSize = int64(size)
+			i++
+		case "--progress":
+			progress = true
+		case "--no-delete", "--delete-remote=false":
+			noDelete = true
+		case "--skip-growing":
+			skipGrowing = true
+		case "--exclude-vcs":
+			excludeVCS = true
+		case "--exclude", "--include":
+			runRules = appendRunRule(runRules, os.Args[i], os.Args[i+1:])
+			i++
+		case "--checkpoint-every":
+			if i+1 < len(os.Args) {
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil || n < 1 {
+					fmt.Fprintf(os.Stderr, "Invalid --checkpoint-every: %s\n", os.Args[i+1])
+					os.Exit(1)
+				}
+				checkpointEvery = n
+				i++
+			}
 		case "--verbose", "-v":
 			verbose = true
 		default:
@@ -294,10 +639,50 @@ func cmdSync(cfg *config.Config) {
 		}
 	}
 
+	// --map picks both the local directory and the remote root
+	remoteRoot := ""
+	if mapArg != "" {
+		local, root, err := parseMap(mapArg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		syncDir, remoteRoot = local, root
+	}
+	if rootArg != "" {
+		if mapArg != "" {
+			fmt.Fprintf(os.Stderr, "--root and --map both pick the remote directory; use one\n")
+			os.Exit(1)
+		}
+		root, err := parseRoot(rootArg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		remoteRoot = root
+	}
+
 	if syncDir == "" {
 		syncDir = "."
 	}
 
+	// Add-only mode never touches local files either, so there's no pull
+	if onlyNew {
+		if pullOnly {
+			fmt.Fprintf(os.Stderr, "--only-new uploads new files; it can't be combined with --pull-only\n")
+			os.Exit(1)
+		}
+		pushOnly = true
+	}
+
+	// In conflicts-only mode the run is silent unless something conflicts
+	out := io.Writer(os.Stdout)
+	if conflictsOnly {
+		out = io.Discard
+		verbose = false
+		progress = false
+	}
+
 	// Resolve to absolute path
 	absDir, err := filepath.Abs(syncDir)
 	if err != nil {
@@ -313,32 +698,165 @@ func cmdSync(cfg *config.Config) {
 		os.Exit(1)
 	}
 
+	if watchOnce {
+		if dryRun || pushOnly || pullOnly || len(runRules) > 0 || force {
+			fmt.Fprintf(os.Stderr, "--watch-once can't be combined with --dry-run, --dump-plan, --push-only, --pull-only, --only-new, --exclude, --include, or --force\n")
+			os.Exit(1)
+		}
+		if remoteRoot == "" {
+			remoteRoot = cfg.SyncRoot
+		}
+		cmdSyncWatchOnce(cfg, watcher.Config{
+			Profile:    activeProfile,
+			SyncDir:    syncDir,
+			ServerURL:  cfg.ServerURL,
+			SettleTime: time.Duration(cfg.SettleTimeMs) * time.Millisecond,
+			Verbose:    verbose,
+
+			SyncRoot:          remoteRoot,
+			TextExtensions:    cfg.TextExtensions,
+			BinaryExtensions:  cfg.BinaryExtensions,
+			NoTextAPI:         noTextAPI,
+			MaxTextSize:       cfg.TextSizeLimit(),
+			NoDelete:          noDelete,
+			SkipGrowing:       skipGrowing,
+			PreserveMode:      cfg.PreserveMode,
+			MaxUploadAttempts: cfg.MaxUploadAttempts,
+			MaxFileSize:       maxSize,
+			ExcludeVCS:        excludeVCS,
+			DefaultIgnore:     cfg.DefaultIgnore,
+			KeepOSJunk:        !cfg.OSJunkFiltered(),
+			ConflictStrategy:  conflictStrategy(cfg),
+			Symlinks:          symlinkPolicy(cfg),
+			StateBackend:      stateBackend(cfg),
+			Once:              true,
+		}, confirmFirst)
+		return
+	}
+
 	client := newClient(cfg)
 
+	// A dry run never saves state, so it doesn't need the lock
+	if !dryRun {
+		lockSync()
+		defer heldLock.Release()
+	}
+
 	// Migrate legacy state file if needed
 	sync.MigrateState(activeProfile, syncDir)
 
 	// Load sync state
-	state, _ := sync.LoadState(activeProfile)
+	store := openSyncStore(cfg)
+	defer store.Close()
+	state := store.State()
 
 	engine := sync.NewEngine(client, syncDir, state)
-	engine.Verbose = verbose
+	engine.SetRoot(cfg.SyncRoot)
+	engine.Store = store
+	engine.Ignore = sync.LoadIgnoreRules(syncDir, cfg.DefaultIgnore...)
+	engine.FilterOSJunk = cfg.OSJunkFiltered()
+	engine.ConflictStrategy = conflictStrategy(cfg)
+	engine.Symlinks = symlinkPolicy(cfg)
+	engine.Types = sync.LoadFileTypes(syncDir, cfg.TextExtensions, cfg.BinaryExtensions)
+	// Per-file lines would break up the progress bar
+	engine.Verbose = verbose && !progress
+	if engine.Verbose {
+		trackTransfers(client, largeTransferSize)
+	}
+	engine.PropagateDeletes = !noDelete
+	engine.Force = force
+	engine.OnlyNew = onlyNew
+	engine.NoTextAPI = noTextAPI
+	engine.MaxTextSize = cfg.TextSizeLimit()
+	engine.MaxFileSize = maxSize
+	engine.SkipGrowing = skipGrowing
+	engine.PreserveMode = preserveMode(cfg, client)
+	engine.MaxUploadAttempts = cfg.MaxUploadAttempts
+	engine.DryRun = dryRun
+	engine.Out = out
+	if excludeVCS {
+		engine.ExcludeVCS()
+	}
+	engine.Ignore.AddPatterns(runRules)
+	if remoteRoot != "" {
+		engine.RootDir = remoteRoot
+		engine.ScopeToRoot = true
+	}
+	if err := engine.CheckRoot(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		if project != nil && errors.Is(err, sync.ErrSyncDirMismatch) {
+			fmt.Fprintf(os.Stderr, "  Give this project a profile of its own: set \"profile\" in %s.\n",
+				filepath.Join(project.Root, config.ProjectConfigDir, "config"))
+		} else {
+			fmt.Fprintf(os.Stderr, "  Use a separate profile for each mapping (izerop --profile <name> sync --map ...),\n")
+			fmt.Fprintf(os.Stderr, "  or start fresh with 'izerop state reset'.\n")
+		}
+		heldLock.Release()
+		os.Exit(1)
+	}
+	if checkpointEvery > 0 && !dryRun {
+		// Save state as files finish so an interrupted sync keeps its progress
+		engine.CheckpointEvery = checkpointEvery
+		engine.Checkpoint = store.Flush
+	}
+	if progress {
+		engine.OnProgress = printProgress
+	}
+
+	if dumpPlan {
+		dumpSyncPlan(engine, store.Cursor(), !pushOnly, !pullOnly, planOut)
+		return
+	}
 
-	// Register/update client with server
-	client.RegisterClient(cfg.EnsureClientKey(activeProfile), cfg.ClientName, config.Platform(), version)
+	// Add-only runs never overwrite or delete, so they need no confirmation
+	if !dryRun && !onlyNew && !confirmFirst {
+		guardFirstSync(engine, store.Cursor(), "izerop sync")
+	}
+
+	// Counters are prefixed in a dry run so they can't be mistaken for real work
+	prefix := ""
+	remote := cfg.ServerURL
+	if remoteRoot != "" || engine.RootDir != sync.DefaultRootDir {
+		remote += " (/" + engine.RootDir + ")"
+	}
+	if dryRun {
+		prefix = "[dry run] "
+		fmt.Fprintf(out, "Sync (dry run): %s ↔ %s\n", syncDir, remote)
+	} else {
+		// Register/update client with server
+		client.RegisterClient(runCtx, cfg.EnsureClientKey(activeProfile), cfg.ClientName, config.Platform(), version)
+
+		fmt.Fprintf(out, "Syncing: %s ↔ %s\n", syncDir, remote)
+	}
 
-	fmt.Printf("Syncing: %s ↔ %s\n", syncDir, cfg.ServerURL)
+	// The engine already printed conflicts as they happened in these modes
+	listConflicts := !dryRun && !engine.Verbose
+	var conflicts []sync.ConflictDetail
 
 	// Pull remote changes
 	if !pushOnly {
-		fmt.Println("⬇ Pulling remote changes...")
-		pullResult, newCursor, err := engine.PullSync(state.Cursor)
+		fmt.Fprintln(out, "⬇ Pulling remote changes...")
+		pullResult, newCursor, err := engine.PullSync(runCtx, store.Cursor())
+		if progress {
+			fmt.Fprintln(out)
+		}
+		if errors.Is(err, context.Canceled) {
+			exitInterrupted(store, "izerop sync")
+		}
+		if errors.Is(err, api.ErrUnauthorized) && !dryRun {
+			exitAuthExpired(store, "izerop sync")
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Pull error: %v\n", err)
 		} else {
-			state.Cursor = newCursor
-			fmt.Printf("  Downloaded: %d, Deleted: %d, Conflicts: %d, Skipped: %d\n",
-				pullResult.Downloaded, pullResult.Deleted, pullResult.Conflicts, pullResult.Skipped)
+			store.SetCursor(newCursor)
+			conflicts = append(conflicts, pullResult.ConflictDetails...)
+			fmt.Fprintf(out, "  %sDownloaded: %d, Deleted: %d, Moved: %d, Conflicts: %d, Skipped: %d\n", prefix,
+				pullResult.Downloaded, pullResult.Deleted, pullResult.Moved, pullResult.Conflicts, pullResult.Skipped)
+			if listConflicts {
+				printConflicts(out, pullResult.ConflictDetails)
+			}
+			printDeletesSkipped(out, pullResult.DeletesSkipped)
 			for _, e := range pullResult.Errors {
 				fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
 			}
@@ -347,474 +865,2202 @@ func cmdSync(cfg *config.Config) {
 
 	// Push local changes
 	if !pullOnly {
-		fmt.Println("⬆ Pushing local changes...")
-		pushResult, err := engine.PushSync()
+		fmt.Fprintln(out, "⬆ Pushing local changes...")
+		pushResult, err := engine.PushSync(runCtx)
+		if progress {
+			fmt.Fprintln(out)
+		}
+		if errors.Is(err, context.Canceled) {
+			exitInterrupted(store, "izerop sync")
+		}
+		if errors.Is(err, api.ErrUnauthorized) && !dryRun {
+			exitAuthExpired(store, "izerop sync")
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Push error: %v\n", err)
+			if errors.Is(err, sync.ErrStaleState) {
+				fmt.Fprintf(os.Stderr, "  The sync state may be stale for this server (wrong profile, or the account was reset).\n")
+				fmt.Fprintf(os.Stderr, "  Start fresh with 'izerop state reset', or pass --force to push anyway.\n")
+			}
 		} else {
-			fmt.Printf("  Uploaded: %d, Conflicts: %d, Skipped: %d\n",
-				pushResult.Uploaded, pushResult.Conflicts, pushResult.Skipped)
+			conflicts = append(conflicts, pushResult.ConflictDetails...)
+			if onlyNew {
+				fmt.Fprintf(out, "  %sAdded: %d, Already on server: %d, Skipped: %d\n", prefix,
+					pushResult.Uploaded, pushResult.Existing, pushResult.Skipped)
+			} else {
+				fmt.Fprintf(out, "  %sUploaded: %d, Deleted: %d, Moved: %d, Conflicts: %d, Skipped: %d\n", prefix,
+					pushResult.Uploaded, pushResult.Deleted, pushResult.Moved, pushResult.Conflicts, pushResult.Skipped)
+			}
+			if listConflicts {
+				printConflicts(out, pushResult.ConflictDetails)
+			}
+			printDeletesSkipped(out, pushResult.DeletesSkipped)
+			if pushResult.StillWriting > 0 {
+				fmt.Fprintf(out, "  ✏ %d file(s) still being written — skipped, run sync again later\n", pushResult.StillWriting)
+			}
+			printStuckUploads(out, state, cfg.MaxUploadAttempts, pushResult.Stuck)
+			printTooLarge(out, pushResult.TooLarge, maxSize)
 			for _, e := range pushResult.Errors {
 				fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
 			}
 		}
 	}
 
-	// Save state
-	if err := sync.SaveState(activeProfile, state); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not save sync state: %v\n", err)
+	if dryRun {
+		// State was only updated in memory; leave the saved state and cursor alone
+		fmt.Fprintln(out, "🔍 Dry run complete (no changes made)")
+	} else {
+		if err := store.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save sync state: %v\n", err)
+		}
+		fmt.Fprintln(out, "✅ Sync complete")
 	}
 
-	fmt.Println("✅ Sync complete")
+	if conflictsOnly && len(conflicts) > 0 {
+		reportConflicts(conflicts)
+	}
 }
 
-func cmdReconcile(cfg *config.Config) {
-	// Usage: izerop reconcile [<directory>] [--dry-run] [--verbose]
-	syncDir := cfg.SyncDir
-	dryRun := false
-	verbose := false
+// reportConflicts lists the conflicts of a --report-conflicts-only sync
+// and exits non-zero so cron notices.
+func reportConflicts(conflicts []sync.ConflictDetail) {
+	fmt.Printf("⚠ %d conflict(s) during sync:\n", len(conflicts))
+	printConflicts(os.Stdout, conflicts)
+	heldLock.Release()
+	os.Exit(1)
+}
 
-	for i := 2; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--dry-run", "-n":
-			dryRun = true
-		case "--verbose", "-v":
-			verbose = true
-		default:
-			if !strings.HasPrefix(os.Args[i], "--") {
-				syncDir = os.Args[i]
-			}
+// printConflicts lists each conflict and where its local copy was saved.
+func printConflicts(w io.Writer, conflicts []sync.ConflictDetail) {
+	for _, c := range conflicts {
+		if c.ConflictFile != "" {
+			fmt.Fprintf(w, "  ⚠ Conflict: %s (local saved as %s)\n", c.Path, c.ConflictFile)
+		} else {
+			fmt.Fprintf(w, "  ⚠ Conflict: %s\n", c.Path)
 		}
 	}
+}
 
-	if syncDir == "" {
-		syncDir = "."
+// dumpSyncPlan writes the actions a sync would take as a JSON array to
+// stdout, or to outPath if set. Nothing is changed locally or on the server.
+func dumpSyncPlan(engine *sync.Engine, cursor string, pull, push bool, outPath string) {
+	plan, err := engine.Plan(runCtx, cursor, pull, push)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not build sync plan: %v\n", err)
+		os.Exit(1)
 	}
-
-	absDir, err := filepath.Abs(syncDir)
+	data, err := json.MarshalIndent(plan, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Could not encode sync plan: %v\n", err)
 		os.Exit(1)
 	}
-	syncDir = absDir
+	data = append(data, '\n')
 
-	info, err := os.Stat(syncDir)
-	if err != nil || !info.IsDir() {
-		fmt.Fprintf(os.Stderr, "Not a directory: %s\n", syncDir)
+	if outPath == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(outPath, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write %s: %v\n", outPath, err)
 		os.Exit(1)
 	}
+	fmt.Printf("📋 Wrote %d planned action(s) to %s\n", len(plan), outPath)
+}
 
-	client := newClient(cfg)
-	sync.MigrateState(activeProfile, syncDir)
-	state, _ := sync.LoadState(activeProfile)
-
-	engine := sync.NewEngine(client, syncDir, state)
-	engine.Verbose = verbose
+// exitAuthExpired stops a run whose token was rejected partway through.
+// Everything that finished is already recorded in the store, so it's
+// flushed before exiting and the next run only redoes what was left.
+func exitAuthExpired(store sync.Store, resume string) {
+	if err := store.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save sync state: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "🔒 Authentication expired mid-sync — progress so far was saved.\n")
+	fmt.Fprintf(os.Stderr, "  Re-login with 'izerop login', then run '%s' to resume.\n", resume)
+	heldLock.Release()
+	os.Exit(1)
+}
 
-	if dryRun {
-		fmt.Printf("Reconcile (dry run): %s ↔ %s\n", syncDir, cfg.ServerURL)
-	} else {
-		fmt.Printf("Reconciling: %s ↔ %s\n", syncDir, cfg.ServerURL)
+// exitInterrupted stops a run canceled by Ctrl-C. Like exitAuthExpired, it
+// flushes what finished so the next run only redoes what was left.
+func exitInterrupted(store sync.Store, resume string) {
+	if err := store.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save sync state: %v\n", err)
 	}
+	fmt.Fprintf(os.Stderr, "⏹ Interrupted — progress so far was saved. Run '%s' to continue.\n", resume)
+	heldLock.Release()
+	os.Exit(130)
+}
 
-	fmt.Println("📋 Fetching server manifest...")
-	result, err := engine.Reconcile(dryRun)
+// openSyncStore opens the profile's sync state with its state_backend,
+// exiting if it can't be read rather than syncing everything again from an
+// empty state.
+func openSyncStore(cfg *config.Config) sync.Store {
+	store, err := sync.OpenStore(activeProfile, stateBackend(cfg))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Reconcile error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		if errors.Is(err, sync.ErrStateCorrupt) {
+			fmt.Fprintf(os.Stderr, "  Start fresh with 'izerop state reset' (the next sync re-checks every file).\n")
+		}
+		heldLock.Release()
 		os.Exit(1)
 	}
+	return store
+}
 
-	fmt.Printf("\n  Downloaded: %d\n  Uploaded:   %d\n  Deleted:    %d\n  Conflicts:  %d\n  Skipped:    %d\n",
-		result.Downloaded, result.Uploaded, result.Deleted, result.Conflicts, result.Skipped)
-	for _, e := range result.Errors {
-		fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
+// guardFirstSync stops a sync that has no saved state when both the sync
+// dir and the server hold files: with nothing recorded, neither side knows
+// which files the other already has. It prints what the sync would meet and
+// asks on a terminal; otherwise it exits, pointing at --confirm-first-sync.
+// A server error is left for the sync itself to report.
+func guardFirstSync(engine *sync.Engine, cursor, command string) {
+	fr, err := engine.CheckFirstRun(runCtx, cursor)
+	if err != nil || fr == nil || !fr.BothPopulated() {
+		return
 	}
 
-	if !dryRun {
-		if err := sync.SaveState(activeProfile, state); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: could not save state: %v\n", err)
-		}
+	fmt.Fprintf(os.Stderr, "⚠ First sync: there is no sync state yet, and both sides already have files.\n")
+	fmt.Fprintf(os.Stderr, "  Local:  %d file(s), %s in %s\n", fr.LocalFiles, formatSize(fr.LocalBytes), engine.SyncDir)
+	fmt.Fprintf(os.Stderr, "  Server: %d file(s), %s in /%s\n", fr.RemoteFiles, formatSize(fr.RemoteBytes), engine.RootDir)
+	fmt.Fprintf(os.Stderr, "  %d local-only file(s) will be uploaded and %d server-only file(s) downloaded.\n",
+		fr.LocalFiles-fr.Both, fr.RemoteFiles-fr.Both)
+	if fr.Both > 0 {
+		fmt.Fprintf(os.Stderr, "  %d path(s) exist on both sides; any that differ are kept as conflict copies.\n", fr.Both)
 	}
 
-	if dryRun {
-		fmt.Println("\n🔍 Dry run complete (no changes made)")
-	} else {
-		fmt.Println("\n✅ Reconcile complete")
+	if stdinIsTerminal() {
+		fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer := strings.ToLower(strings.TrimSpace(line)); answer == "y" || answer == "yes" {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "Aborted.")
 	}
+	fmt.Fprintf(os.Stderr, "  Preview it with 'izerop sync --dry-run', then run '%s --confirm-first-sync'.\n", command)
+	heldLock.Release()
+	os.Exit(1)
 }
 
-func cmdPush(cfg *config.Config) {
-	// Usage: izerop push <file> [--dir <directory_id>] [--name <name>]
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: izerop push <file> [--dir <directory_id>] [--name <name>]\n")
-		os.Exit(1)
-	}
+// syncLockWait is how long sync and reconcile wait for another run (often
+// the watcher's) to finish before giving up.
+const syncLockWait = 10 * time.Second
 
-	filePath := os.Args[2]
-	var dirID, name string
+// heldLock is the profile's sync lock once lockSync has taken it.
+var heldLock *sync.Lock
 
-	for i := 3; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--dir":
-			if i+1 < len(os.Args) {
-				dirID = os.Args[i+1]
-				i++
-			}
-		case "--name":
-			if i+1 < len(os.Args) {
-				name = os.Args[i+1]
-				i++
+// runCtx is the context server calls run under. lockSync replaces it with
+// one that Ctrl-C cancels, so an interrupted sync aborts its transfers
+// instead of leaving them running.
+var runCtx = context.Background()
+
+// lockSync takes the profile's sync lock for the rest of the command, or
+// exits if another sync still holds it after syncLockWait. The first
+// Ctrl-C or SIGTERM cancels runCtx so the run stops and saves what
+// finished; a second one releases the lock and exits at once.
+func lockSync() {
+	lock, err := sync.AcquireLock(activeProfile, syncLockWait)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⏳ %v\n", err)
+		if errors.Is(err, sync.ErrLocked) {
+			if running, _ := watcherStatusAt(pidFilePath()); running {
+				fmt.Fprintf(os.Stderr, "  The watcher is syncing this profile; try again shortly, or pause it with 'izerop watch pause'.\n")
 			}
 		}
+		os.Exit(1)
 	}
+	heldLock = lock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runCtx = ctx
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintf(os.Stderr, "\n⏹ Stopping... (press Ctrl-C again to quit now)\n")
+		cancel()
+		<-sigCh
+		lock.Release()
+		os.Exit(130)
+	}()
+}
 
-	// Verify file exists
-	info, err := os.Stat(filePath)
+// cmdSyncWatchOnce runs 'izerop sync --watch-once': a bounded watcher run
+// that pulls, waits for the settle time to pass with no local changes (so
+// files an editor is still saving aren't pushed half-written), pushes, and
+// exits non-zero if the pull or push failed.
+func cmdSyncWatchOnce(cfg *config.Config, wcfg watcher.Config, confirmFirst bool) {
+	client := newClient(cfg)
+	if !confirmFirst {
+		store := openSyncStore(cfg)
+		state := store.State()
+		engine := sync.NewEngine(client, wcfg.SyncDir, state)
+		engine.SetRoot(wcfg.SyncRoot)
+		engine.Store = store
+		engine.Ignore = sync.LoadIgnoreRules(wcfg.SyncDir, cfg.DefaultIgnore...)
+		engine.FilterOSJunk = cfg.OSJunkFiltered()
+		engine.Symlinks = wcfg.Symlinks
+		if wcfg.ExcludeVCS {
+			engine.ExcludeVCS()
+		}
+		guardFirstSync(engine, state.Cursor, "izerop sync --watch-once")
+		store.Close()
+	}
+	client.RegisterClient(runCtx, cfg.EnsureClientKey(activeProfile), cfg.ClientName, config.Platform(), version)
+
+	wcfg.Client = client
+	wcfg.Logger = log.New(os.Stdout, "", log.LstdFlags)
+	w, err := watcher.New(wcfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "File not found: %s\n", filePath)
+		fmt.Fprintf(os.Stderr, "Failed to start watcher: %v\n", err)
 		os.Exit(1)
 	}
-	if info.IsDir() {
-		fmt.Fprintf(os.Stderr, "Cannot push a directory (yet). Use a file path.\n")
+	if err := w.Run(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "⏹ Interrupted — progress so far was saved\n")
+			os.Exit(130)
+		}
+		// The watcher already logged the details
+		fmt.Fprintf(os.Stderr, "❌ Sync failed (%s)\n", strings.SplitN(err.Error(), ":", 2)[0])
 		os.Exit(1)
 	}
+	fmt.Println("✅ Sync complete")
+}
 
-	client := newClient(cfg)
+// preserveMode reports whether a run should sync file modes: preserve_mode
+// is set and the server doesn't report that it can't store them.
+func preserveMode(cfg *config.Config, client *api.Client) bool {
+	if !cfg.PreserveMode {
+		return false
+	}
+	if client.Require(runCtx, api.FeatureFileModes) != nil {
+		fmt.Fprintf(os.Stderr, "⚠ preserve_mode is set, but this server doesn't store file modes; ignoring it\n")
+		return false
+	}
+	return true
+}
 
-	fmt.Printf("Uploading %s (%s)...\n", filePath, formatSize(info.Size()))
-	file, err := client.UploadFile(filePath, dirID, name)
+// conflictStrategy returns the profile's conflict_strategy, exiting if it
+// isn't one the engine knows.
+func conflictStrategy(cfg *config.Config) sync.ConflictStrategy {
+	strategy, err := sync.ParseConflictStrategy(cfg.ConflictStrategy)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Upload failed: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
-
-	fmt.Printf("✅ Uploaded: %s (%s)\n", file.Name, file.ID[:8])
+	return strategy
 }
 
-func cmdConflicts(cfg *config.Config) {
-	// Usage: izerop conflicts [--clean] [--keep-local|--keep-remote]
-	syncDir := cfg.SyncDir
-	if syncDir == "" {
-		syncDir = "."
+// symlinkPolicy returns the profile's symlink_policy, exiting if it isn't
+// one the engine knows.
+func symlinkPolicy(cfg *config.Config) sync.SymlinkPolicy {
+	policy, err := sync.ParseSymlinkPolicy(cfg.SymlinkPolicy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
-	absDir, err := filepath.Abs(syncDir)
+	return policy
+}
+
+// stateBackend returns the profile's state_backend, exiting if it isn't
+// valid.
+func stateBackend(cfg *config.Config) sync.StateBackend {
+	backend, err := sync.ParseStateBackend(cfg.StateBackend)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
+	return backend
+}
 
-	clean := false
-	keepLocal := false
-	keepRemote := false
+// parseMap splits a --map value "<local>:<remote-path>" into the local
+// directory and the remote root without its leading slash. The remote path
+// must be absolute and below "/", with no empty, "." or ".." elements, so
+// it maps back to the same local paths it was built from.
+func parseMap(arg string) (string, string, error) {
+	i := strings.LastIndex(arg, ":")
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid --map %q: want <local-dir>:<remote-path>, e.g. ~/notes:/work/notes", arg)
+	}
+	local, remote := arg[:i], arg[i+1:]
+	if !strings.HasPrefix(remote, "/") {
+		return "", "", fmt.Errorf("invalid --map %q: the remote path must start with /", arg)
+	}
+	root := strings.TrimSuffix(remote[1:], "/")
+	if root == "" {
+		return "", "", fmt.Errorf("invalid --map %q: the remote path must be a directory below /", arg)
+	}
+	for _, name := range strings.Split(root, "/") {
+		if !safeName(name) {
+			return "", "", fmt.Errorf("invalid --map %q: bad remote path element %q", arg, name)
+		}
+	}
+	return local, root, nil
+}
+
+// parseRoot validates a --root value like "work" or "/work/notes" and
+// returns it without the slashes at either end.
+func parseRoot(arg string) (string, error) {
+	root := strings.Trim(arg, "/")
+	if root == "" {
+		return "", fmt.Errorf("invalid --root %q: want a remote directory like work or work/notes", arg)
+	}
+	for _, name := range strings.Split(root, "/") {
+		if !safeName(name) {
+			return "", fmt.Errorf("invalid --root %q: bad remote path element %q", arg, name)
+		}
+	}
+	return root, nil
+}
+
+// appendRunRule adds the glob following an --exclude or --include flag to
+// rules as an ignore line, exiting if it's missing. Includes become "!"
+// negations. The rules apply to this run only and are added after the
+// file-based ones, so they take precedence.
+func appendRunRule(rules []string, flag string, rest []string) []string {
+	if len(rest) == 0 || rest[0] == "" {
+		fmt.Fprintf(os.Stderr, "%s needs a glob, e.g. %s 'build/**'\n", flag, flag)
+		os.Exit(1)
+	}
+	if flag == "--include" {
+		return append(rules, "!"+rest[0])
+	}
+	return append(rules, rest[0])
+}
+
+// stuckReportAt is how many failed uploads in a row make sync list a file
+// as stuck, or max_upload_attempts if that's lower.
+const stuckReportAt = 3
+
+// printStuckUploads lists files whose uploads keep failing, so a file the
+// server always rejects isn't buried in the per-run errors. skipped is how
+// many of them this push skipped under max_upload_attempts.
+func printStuckUploads(w io.Writer, state *sync.State, maxAttempts, skipped int) {
+	reportAt := stuckReportAt
+	if maxAttempts > 0 && maxAttempts < reportAt {
+		reportAt = maxAttempts
+	}
+	var stuck []sync.StuckUpload
+	for _, s := range state.StuckUploads() {
+		if s.Count >= reportAt {
+			stuck = append(stuck, s)
+		}
+	}
+	if len(stuck) == 0 && skipped == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  ⛔ %d file(s) keep failing to upload", len(stuck))
+	if skipped > 0 {
+		fmt.Fprintf(w, " (%d skipped until they change)", skipped)
+	}
+	fmt.Fprintln(w, ":")
+	for _, s := range stuck {
+		fmt.Fprintf(w, "     %s — %d attempts, last: %s\n", s.Path, s.Count, s.LastError)
+	}
+}
+
+// printTooLarge lists files that weren't uploaded for exceeding the size
+// limit.
+func printTooLarge(w io.Writer, paths []string, limit int64) {
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "  ⚠ %d file(s) larger than %s — skipped (raise max_file_size or --max-size to upload):\n", len(paths), formatSize(limit))
+	for _, p := range paths {
+		fmt.Fprintf(w, "     %s\n", p)
+	}
+}
+
+// printDeletesSkipped warns when --no-delete held back deletions, since
+// local and server will keep diverging until they're resolved.
+func printDeletesSkipped(w io.Writer, n int) {
+	if n > 0 {
+		fmt.Fprintf(w, "  ⏭ %d deletion(s) skipped (--no-delete) — local and server differ\n", n)
+	}
+}
+
+func cmdReconcile(cfg *config.Config) {
+	// Usage: izerop reconcile [<directory>] [--root <remote-dir>] [--dry-run] [--resume] [--no-delete] [--exclude-vcs] [--exclude <glob>] [--include <glob>] [--verbose] [--no-progress]
+	syncDir := cfg.SyncDir
+	dryRun := false
+	verbose := false
+	resume := false
+	noDelete := cfg.NoDelete
+	excludeVCS := cfg.ExcludeVCS
+	remoteRoot := ""
+	var runRules []string
 
 	for i := 2; i < len(os.Args); i++ {
 		switch os.Args[i] {
-		case "--clean":
-			clean = true
-		case "--keep-local":
-			keepLocal = true
-		case "--keep-remote":
-			keepRemote = true
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--resume":
+			resume = true
+		case "--root":
+			if i+1 < len(os.Args) {
+				root, err := parseRoot(os.Args[i+1])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					os.Exit(1)
+				}
+				remoteRoot = root
+				i++
+			}
+		case "--no-delete", "--delete-remote=false":
+			noDelete = true
+		case "--exclude-vcs":
+			excludeVCS = true
+		case "--exclude", "--include":
+			runRules = appendRunRule(runRules, os.Args[i], os.Args[i+1:])
+			i++
+		case "--verbose", "-v":
+			verbose = true
 		default:
 			if !strings.HasPrefix(os.Args[i], "--") {
-				absDir, _ = filepath.Abs(os.Args[i])
+				syncDir = os.Args[i]
 			}
 		}
 	}
 
-	// Find all conflict files
-	var conflicts []string
-	filepath.Walk(absDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if strings.HasPrefix(info.Name(), ".") && info.IsDir() {
-			return filepath.SkipDir
-		}
-		if strings.Contains(info.Name(), ".conflict") {
-			rel, _ := filepath.Rel(absDir, path)
-			conflicts = append(conflicts, rel)
-		}
-		return nil
-	})
+	if syncDir == "" {
+		syncDir = "."
+	}
 
-	if len(conflicts) == 0 {
-		fmt.Println("No conflict files found. ✅")
-		return
+	absDir, err := filepath.Abs(syncDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		os.Exit(1)
 	}
+	syncDir = absDir
 
-	fmt.Printf("Found %d conflict file(s):\n\n", len(conflicts))
-	for _, c := range conflicts {
-		// Figure out the original file name
-		original := strings.Replace(c, ".conflict", "", 1)
-		fmt.Printf("  ⚠ %s\n    original: %s\n", c, original)
+	info, err := os.Stat(syncDir)
+	if err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Not a directory: %s\n", syncDir)
+		os.Exit(1)
 	}
 
-	if !clean {
-		fmt.Println("\nTo resolve:")
-		fmt.Println("  izerop conflicts --clean              # delete all conflict files (keep originals)")
-		fmt.Println("  izerop conflicts --clean --keep-local  # keep local version, delete conflict copies")
-		fmt.Println("  izerop conflicts --clean --keep-remote # keep conflict (remote) version, replace originals")
-		return
+	client := newClient(cfg)
+	if !dryRun {
+		lockSync()
+		defer heldLock.Release()
 	}
+	sync.MigrateState(activeProfile, syncDir)
+	store := openSyncStore(cfg)
+	defer store.Close()
+	state := store.State()
 
-	removed := 0
-	for _, c := range conflicts {
-		conflictPath := filepath.Join(absDir, c)
+	engine := sync.NewEngine(client, syncDir, state)
+	engine.SetRoot(cfg.SyncRoot)
+	if remoteRoot != "" {
+		engine.RootDir = remoteRoot
+		engine.ScopeToRoot = true
+	}
+	engine.Store = store
+	engine.Verbose = verbose
+	engine.Ignore = sync.LoadIgnoreRules(syncDir, cfg.DefaultIgnore...)
+	engine.FilterOSJunk = cfg.OSJunkFiltered()
+	engine.ConflictStrategy = conflictStrategy(cfg)
+	engine.Symlinks = symlinkPolicy(cfg)
+	engine.Types = sync.LoadFileTypes(syncDir, cfg.TextExtensions, cfg.BinaryExtensions)
+	engine.PropagateDeletes = !noDelete
+	engine.Resume = resume
+	engine.MaxFileSize = int64(cfg.MaxFileSize)
+	engine.NoTextAPI = cfg.NoTextAPI
+	engine.MaxTextSize = cfg.TextSizeLimit()
+	engine.PreserveMode = preserveMode(cfg, client)
+	if excludeVCS {
+		engine.ExcludeVCS()
+	}
+	engine.Ignore.AddPatterns(runRules)
+	if verbose {
+		trackTransfers(client, largeTransferSize)
+	}
+	if !dryRun {
+		// Persist progress periodically so an interrupted run can --resume
+		engine.Checkpoint = store.Flush
+	}
 
-		if keepRemote {
-			// The conflict file is the remote version — replace original with it
-			original := strings.Replace(c, ".conflict", "", 1)
-			originalPath := filepath.Join(absDir, original)
-			if err := os.Rename(conflictPath, originalPath); err != nil {
-				fmt.Fprintf(os.Stderr, "  ✗ Could not replace %s: %v\n", original, err)
-				continue
-			}
-			fmt.Printf("  ✅ Replaced with remote: %s\n", original)
-			removed++
-		} else if keepLocal || (!keepLocal && !keepRemote) {
-			// Default: keep original, delete conflict file
-			if err := os.Remove(conflictPath); err != nil {
-				fmt.Fprintf(os.Stderr, "  ✗ Could not remove %s: %v\n", c, err)
-				continue
-			}
-			fmt.Printf("  🗑 Removed: %s\n", c)
-			removed++
+	if dryRun {
+		fmt.Printf("Reconcile (dry run): %s ↔ %s\n", syncDir, cfg.ServerURL)
+	} else {
+		fmt.Printf("Reconciling: %s ↔ %s\n", syncDir, cfg.ServerURL)
+	}
+	if resume && len(state.ReconcileDone) > 0 {
+		fmt.Printf("↪ Resuming: %d file(s) already reconciled\n", len(state.ReconcileDone))
+	} else if !resume && len(state.ReconcileDone) > 0 {
+		fmt.Printf("ℹ Previous reconcile was interrupted; starting over (use --resume to continue it)\n")
+	}
+
+	fmt.Println("📋 Fetching server manifest...")
+	result, err := engine.Reconcile(runCtx, dryRun)
+	if errors.Is(err, context.Canceled) {
+		exitInterrupted(store, "izerop reconcile --resume")
+	}
+	if errors.Is(err, api.ErrUnauthorized) && !dryRun {
+		exitAuthExpired(store, "izerop reconcile --resume")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Reconcile error: %v\n", err)
+		heldLock.Release()
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n  Downloaded: %d\n  Uploaded:   %d\n  Deleted:    %d\n  Conflicts:  %d\n  Skipped:    %d\n",
+		result.Downloaded, result.Uploaded, result.Deleted, result.Conflicts, result.Skipped)
+	if !dryRun && !engine.Verbose {
+		printConflicts(os.Stdout, result.ConflictDetails)
+	}
+	printDeletesSkipped(os.Stdout, result.DeletesSkipped)
+	printTooLarge(os.Stdout, result.TooLarge, engine.MaxFileSize)
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
+	}
+
+	if !dryRun {
+		if err := store.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save state: %v\n", err)
 		}
 	}
 
-	fmt.Printf("\n✅ Resolved %d conflict(s)\n", removed)
+	if dryRun {
+		fmt.Println("\n🔍 Dry run complete (no changes made)")
+	} else {
+		fmt.Println("\n✅ Reconcile complete")
+	}
 }
 
-func cmdURL(cfg *config.Config) {
-	// Usage: izerop url <file>
-	// Resolves a local file path to its remote URL via the sync state or by searching remote files.
+func cmdPush(cfg *config.Config) {
+	// Usage: izerop push <file|dir> [--dir <directory_id|/path>] [--name <name>] [--recursive] [--progress|--no-progress]
 	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: izerop url <file>\n")
+		fmt.Fprintf(os.Stderr, "Usage: izerop push <file|dir> [--dir <directory_id|/path>] [--name <name>] [--recursive] [--progress|--no-progress]\n")
 		os.Exit(1)
 	}
 
 	filePath := os.Args[2]
+	var dirID, name string
+	recursive := false
 
-	// Resolve to absolute path
-	absPath, err := filepath.Abs(filePath)
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--recursive", "-r":
+			recursive = true
+		case "--dir":
+			if i+1 < len(os.Args) {
+				dirID = os.Args[i+1]
+				i++
+			}
+		case "--name":
+			if i+1 < len(os.Args) {
+				name = os.Args[i+1]
+				i++
+			}
+		}
+	}
+
+	// Verify file exists
+	info, err := os.Stat(filePath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Invalid path: %v\n", err)
+		fmt.Fprintf(os.Stderr, "File not found: %s\n", filePath)
+		os.Exit(1)
+	}
+	if info.IsDir() && !recursive {
+		fmt.Fprintf(os.Stderr, "%s is a directory. Use --recursive to push it.\n", filePath)
 		os.Exit(1)
 	}
 
 	client := newClient(cfg)
+	progress := trackTransfers(client, 0)
 
-	// Try to find via sync state first (faster, no API calls for ID lookup)
-	syncDir := cfg.SyncDir
-	if syncDir != "" {
-		absSyncDir, _ := filepath.Abs(syncDir)
-		if strings.HasPrefix(absPath, absSyncDir+"/") {
-			relPath, _ := filepath.Rel(absSyncDir, absPath)
-			state, _ := sync.LoadState(activeProfile)
-
-			// Check Files state
-			if rec, ok := state.Files[relPath]; ok && rec.RemoteID != "" {
-				file, err := client.GetFile(rec.RemoteID)
-				if err == nil && file.URL != "" {
-					fmt.Println(file.URL)
-					return
-				}
-				// If URL not available, fall through to show the download endpoint
-				if err == nil {
-					fmt.Printf("%s/api/v1/files/%s/download\n", cfg.ServerURL, rec.RemoteID)
-					return
-				}
-			}
+	dirID, err = resolveDirID(client, dirID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 
-			// Check Notes state
-			if noteID, ok := state.Notes[relPath]; ok {
-				file, err := client.GetFile(noteID)
-				if err == nil && file.URL != "" {
-					fmt.Println(file.URL)
-					return
-				}
-				if err == nil {
-					fmt.Printf("%s/api/v1/files/%s/download\n", cfg.ServerURL, noteID)
-					return
-				}
-			}
-		}
+	if info.IsDir() {
+		types := sync.LoadFileTypes(filePath, cfg.TextExtensions, cfg.BinaryExtensions)
+		pushDirectory(client, types, cfg.TextSizeLimit(), filePath, dirID, name)
+		return
 	}
 
-	// Fallback: search remote files by name
-	fileName := filepath.Base(absPath)
-	dirs, err := client.ListDirectories()
+	fmt.Printf("Uploading %s (%s)...\n", filePath, formatSize(info.Size()))
+	file, err := client.UploadFile(runCtx, filePath, dirID, name)
+	progress.clear()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Upload failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	for _, dir := range dirs {
-		files, err := client.ListFiles(dir.ID)
-		if err != nil {
-			continue
+	fmt.Printf("✅ Uploaded: %s (%s)\n", file.Name, file.ID[:8])
+}
+
+// pushDirectory uploads a local directory tree under the given remote parent,
+// recreating its folder structure. Failures are reported per file.
+func pushDirectory(client *api.Client, types *sync.FileTypes, maxText int64, localDir, parentID, name string) {
+	absDir, err := filepath.Abs(localDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		os.Exit(1)
+	}
+	if name == "" {
+		name = filepath.Base(absDir)
+	}
+
+	fmt.Printf("Uploading %s/ recursively...\n", localDir)
+
+	dir, err := client.CreateDirectory(runCtx, name, parentID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create directory: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("  📁 %s/\n", name)
+
+	uploaded, dirsCreated, errs := uploadTree(client, types, maxText, nil, absDir, dir.ID, false, 1)
+	printTreeSummary(uploaded, dirsCreated+1, errs, false)
+}
+
+// uploadTree uploads the contents of absDir into the remote directory rootID,
+// creating remote subdirectories to match. Hidden and conflict files are
+// skipped, as is anything matched by ignore (if non-nil). Text files up to
+// maxText bytes become notes; bigger ones are uploaded as binaries.
+// Directories are created first, in walk order; files are then uploaded up
+// to jobs at a time. With dryRun, nothing is created and the would-be
+// actions are printed.
+func uploadTree(client *api.Client, types *sync.FileTypes, maxText int64, ignore *sync.IgnoreRules, absDir, rootID string, dryRun bool, jobs int) (uploaded, dirsCreated int, errs []string) {
+	// Maps local directory paths to their remote directory IDs
+	remoteDirs := map[string]string{absDir: rootID}
+
+	type treeUpload struct {
+		path, rel, dirID string
+		info             os.FileInfo
+	}
+	var pending []treeUpload
+
+	filepath.Walk(absDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			errs = append(errs, fmt.Sprintf("walk %s: %v", path, walkErr))
+			return nil
 		}
-		for _, f := range files {
-			if f.Name == fileName {
-				if f.URL != "" {
-					fmt.Println(f.URL)
-				} else {
-					fmt.Printf("%s/api/v1/files/%s/download\n", cfg.ServerURL, f.ID)
+		if path == absDir {
+			return nil
+		}
+
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(absDir, path)
+		if ignore != nil && ignore.IsIgnored(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if !dryRun {
+				dir, err := client.CreateDirectory(runCtx, info.Name(), remoteDirs[filepath.Dir(path)])
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("mkdir %s: %v", relPath, err))
+					return filepath.SkipDir
 				}
-				return
+				remoteDirs[path] = dir.ID
+			}
+			dirsCreated++
+			fmt.Printf("  📁 %s/\n", relPath)
+			return nil
+		}
+
+		if strings.Contains(info.Name(), ".conflict") {
+			return nil
+		}
+
+		pending = append(pending, treeUpload{path: path, rel: relPath, dirID: remoteDirs[filepath.Dir(path)], info: info})
+		return nil
+	})
+
+	var mu gosync.Mutex
+	sync.ForEach(jobs, len(pending), func(i int) {
+		u := pending[i]
+		var err error
+		if !dryRun {
+			if u.info.Size() <= maxText && types.IsText(u.path, u.rel, u.info) {
+				var contents []byte
+				if contents, err = os.ReadFile(u.path); err != nil {
+					err = fmt.Errorf("read %s: %w", u.rel, err)
+				} else if _, err = client.CreateTextFile(runCtx, u.info.Name(), string(contents), u.dirID, ""); err != nil {
+					err = fmt.Errorf("create text %s: %w", u.rel, err)
+				}
+			} else if _, err = client.UploadFile(runCtx, u.path, u.dirID, u.info.Name()); err != nil {
+				err = fmt.Errorf("upload %s: %w", u.rel, err)
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = append(errs, err.Error())
+			return
+		}
+		uploaded++
+		fmt.Printf("  ⬆ %s (%s)\n", u.rel, formatSize(u.info.Size()))
+	})
+
+	return uploaded, dirsCreated, errs
+}
+
+// printTreeSummary prints the result of uploadTree and exits non-zero on errors.
+func printTreeSummary(uploaded, dirsCreated int, errs []string, dryRun bool) {
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
+	}
+	if dryRun {
+		fmt.Printf("Dry run: would upload %d file(s), create %d dir(s)", uploaded, dirsCreated)
+	} else {
+		fmt.Printf("✅ Uploaded %d file(s), created %d dir(s)", uploaded, dirsCreated)
+	}
+	if len(errs) > 0 {
+		fmt.Printf(", %d error(s)", len(errs))
+	}
+	fmt.Println()
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// cmdImport bulk-uploads a local tree without enabling sync: no sync state
+// is read or written, so nothing it uploads is ever deleted by a later sync.
+// It also serves the older 'import-dir <local-dir> <remote-dir-id>' form.
+func cmdImport(cfg *config.Config) {
+	// Usage: izerop import <src-dir> [--dir <directory_id|/path>] [--concurrency <n>] [--dry-run]
+	//        izerop import-dir <local-dir> <remote-dir-id> [--dry-run]
+	var positional []string
+	parentRef := ""
+	dryRun := false
+	jobs := sync.DefaultWorkers
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--dir":
+			if i+1 < len(os.Args) {
+				parentRef = os.Args[i+1]
+				i++
+			}
+		case "--concurrency", "-j":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "%s requires a number\n", os.Args[i])
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Invalid %s value: %s (want a number of at least 1)\n", os.Args[i], os.Args[i+1])
+				os.Exit(1)
+			}
+			jobs = n
+			i++
+		default:
+			if !strings.HasPrefix(os.Args[i], "-") {
+				positional = append(positional, os.Args[i])
+			}
+		}
+	}
+	if len(positional) == 2 && parentRef == "" {
+		positional, parentRef = positional[:1], positional[1]
+	}
+	if len(positional) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: izerop import <src-dir> [--dir <directory_id|/path>] [--concurrency <n>] [--dry-run]\n")
+		os.Exit(1)
+	}
+	localDir := positional[0]
+
+	absDir, err := filepath.Abs(localDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		os.Exit(1)
+	}
+	if info, err := os.Stat(absDir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Not a directory: %s\n", localDir)
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	types := sync.LoadFileTypes(absDir, cfg.TextExtensions, cfg.BinaryExtensions)
+
+	targetID, err := resolveDirID(client, parentRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	target := parentRef
+	if target == "" {
+		target = "/"
+	}
+
+	if dryRun {
+		fmt.Printf("Import (dry run): %s/ → %s\n", localDir, target)
+	} else {
+		fmt.Printf("Importing %s/ → %s\n", localDir, target)
+	}
+
+	// No sync state is read or written — this is a one-shot ingest
+	uploaded, dirsCreated, errs := uploadTree(client, types, cfg.TextSizeLimit(), sync.LoadIgnoreRules(absDir, cfg.DefaultIgnore...), absDir, targetID, dryRun, jobs)
+	printTreeSummary(uploaded, dirsCreated, errs, dryRun)
+}
+
+func cmdConflicts(cfg *config.Config) {
+	// Usage: izerop conflicts [--diff] [--clean] [--keep-local|--keep-remote] [--interactive]
+	syncDir := cfg.SyncDir
+	if syncDir == "" {
+		syncDir = "."
+	}
+	absDir, err := filepath.Abs(syncDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	clean := false
+	keepLocal := false
+	keepRemote := false
+	showDiff := false
+	interactive := false
+
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--diff":
+			showDiff = true
+		case "--interactive", "-i":
+			interactive = true
+		case "--clean":
+			clean = true
+		case "--keep-local":
+			keepLocal = true
+		case "--keep-remote":
+			keepRemote = true
+		default:
+			if !strings.HasPrefix(os.Args[i], "--") {
+				absDir, _ = filepath.Abs(os.Args[i])
+			}
+		}
+	}
+
+	// Find all conflict files, whichever conflict_strategy saved them
+	conflicts := sync.FindConflicts(absDir)
+
+	if len(conflicts) == 0 {
+		fmt.Println("No conflict files found. ✅")
+		return
+	}
+
+	// Conflict sources are only tracked for the configured sync dir
+	store, _ := sync.OpenStore(activeProfile, stateBackend(cfg))
+	defer store.Close()
+	state := store.State()
+
+	types := sync.LoadFileTypes(absDir, cfg.TextExtensions, cfg.BinaryExtensions)
+
+	if interactive {
+		resolved := resolveConflictsInteractively(types, state, absDir, conflicts)
+		forgetResolvedConflicts(stateBackend(cfg), absDir, conflicts)
+		fmt.Printf("\n✅ Resolved %d conflict(s)\n", resolved)
+		return
+	}
+
+	fmt.Printf("Found %d conflict file(s):\n\n", len(conflicts))
+	for _, c := range conflicts {
+		original, _ := sync.ConflictOriginal(c)
+		fmt.Printf("  ⚠ %s\n    original: %s\n", c, original)
+		if from, ok := state.Conflicts[c]; ok {
+			fmt.Printf("    remote version from: %s\n", from)
+		}
+		if showDiff {
+			printConflictDiff(types, absDir, original, c)
+		}
+	}
+
+	if !clean {
+		fmt.Println("\nTo resolve:")
+		fmt.Println("  izerop conflicts --clean              # delete all conflict files (keep originals)")
+		fmt.Println("  izerop conflicts --clean --keep-local  # keep local version, delete conflict copies")
+		fmt.Println("  izerop conflicts --clean --keep-remote # keep conflict (remote) version, replace originals")
+		fmt.Println("  izerop conflicts --interactive         # decide file by file")
+		return
+	}
+
+	removed := 0
+	for _, c := range conflicts {
+		if keepRemote {
+			if keepConflictRemote(absDir, c) {
+				removed++
+			}
+		} else if keepLocal || (!keepLocal && !keepRemote) {
+			// Default: keep original, delete conflict file
+			if keepConflictLocal(absDir, c) {
+				removed++
+			}
+		}
+	}
+
+	forgetResolvedConflicts(stateBackend(cfg), absDir, conflicts)
+
+	fmt.Printf("\n✅ Resolved %d conflict(s)\n", removed)
+}
+
+// keepConflictRemote replaces the original with its .conflict copy, which
+// holds the remote version.
+func keepConflictRemote(absDir, conflict string) bool {
+	original, _ := sync.ConflictOriginal(conflict)
+	if err := os.Rename(filepath.Join(absDir, conflict), filepath.Join(absDir, original)); err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Could not replace %s: %v\n", original, err)
+		return false
+	}
+	pruneConflictDirs(absDir, conflict)
+	fmt.Printf("  ✅ Replaced with remote: %s\n", original)
+	return true
+}
+
+// keepConflictLocal keeps the original and deletes its .conflict copy.
+func keepConflictLocal(absDir, conflict string) bool {
+	if err := os.Remove(filepath.Join(absDir, conflict)); err != nil {
+		fmt.Fprintf(os.Stderr, "  ✗ Could not remove %s: %v\n", conflict, err)
+		return false
+	}
+	pruneConflictDirs(absDir, conflict)
+	fmt.Printf("  🗑 Removed: %s\n", conflict)
+	return true
+}
+
+// pruneConflictDirs removes directories under the conflicts folder that
+// resolving conflict left empty.
+func pruneConflictDirs(absDir, conflict string) {
+	if !strings.HasPrefix(conflict, sync.ConflictsDir+string(filepath.Separator)) {
+		return
+	}
+	for dir := filepath.Dir(conflict); dir != "."; dir = filepath.Dir(dir) {
+		if os.Remove(filepath.Join(absDir, dir)) != nil {
+			return // not empty
+		}
+	}
+}
+
+// forgetResolvedConflicts drops state entries for conflict files that no
+// longer exist. It takes the sync lock and reloads the state first, so the
+// save can't overwrite what a sync or the watcher recorded meanwhile.
+func forgetResolvedConflicts(backend sync.StateBackend, absDir string, conflicts []string) {
+	var gone []string
+	for _, c := range conflicts {
+		if _, err := os.Stat(filepath.Join(absDir, c)); os.IsNotExist(err) {
+			gone = append(gone, c)
+		}
+	}
+	if len(gone) == 0 {
+		return
+	}
+
+	lockSync()
+	defer heldLock.Release()
+	store, err := sync.OpenStore(activeProfile, backend)
+	if err != nil {
+		return
+	}
+	defer store.Close()
+	state := store.State()
+	if len(state.Conflicts) == 0 {
+		return
+	}
+	for _, c := range gone {
+		delete(state.Conflicts, c)
+	}
+	if err := store.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save sync state: %v\n", err)
+	}
+}
+
+// resolveConflictsInteractively prompts for each conflict file in turn and
+// applies the chosen resolution. It returns how many were resolved.
+func resolveConflictsInteractively(types *sync.FileTypes, state *sync.State, absDir string, conflicts []string) int {
+	reader := bufio.NewReader(os.Stdin)
+	resolved := 0
+
+	fmt.Printf("Found %d conflict file(s). For each, choose:\n", len(conflicts))
+	fmt.Println("  [l] keep local (delete the .conflict copy)")
+	fmt.Println("  [r] keep remote (replace the original with the .conflict copy)")
+	fmt.Println("  [d] view diff   [s] skip   [q] quit")
+
+	for n, c := range conflicts {
+		original, _ := sync.ConflictOriginal(c)
+		fmt.Printf("\n(%d/%d) ⚠ %s\n    original: %s\n", n+1, len(conflicts), c, original)
+		if from, ok := state.Conflicts[c]; ok {
+			fmt.Printf("    remote version from: %s\n", from)
+		}
+
+	prompt:
+		for {
+			fmt.Print("Keep [l]ocal, [r]emote, [d]iff, [s]kip, [q]uit? ")
+			line, err := reader.ReadString('\n')
+			answer := strings.ToLower(strings.TrimSpace(line))
+			if err != nil && answer == "" {
+				// stdin closed — stop rather than loop forever
+				fmt.Println()
+				return resolved
+			}
+
+			switch answer {
+			case "l", "local":
+				if keepConflictLocal(absDir, c) {
+					resolved++
+				}
+				break prompt
+			case "r", "remote":
+				if keepConflictRemote(absDir, c) {
+					resolved++
+				}
+				break prompt
+			case "d", "diff":
+				printConflictDiff(types, absDir, original, c)
+			case "s", "skip", "":
+				fmt.Println("  ⏭ Skipped")
+				break prompt
+			case "q", "quit":
+				return resolved
+			default:
+				fmt.Println("  Please answer l, r, d, s, or q.")
+			}
+		}
+	}
+	return resolved
+}
+
+// maxConflictDiffSize is the largest file conflicts --diff will read.
+const maxConflictDiffSize = 1024 * 1024
+
+// printConflictDiff prints a unified diff from a file to its .conflict copy.
+// Binary or very large files only get their sizes reported.
+func printConflictDiff(types *sync.FileTypes, absDir, original, conflict string) {
+	origPath := filepath.Join(absDir, original)
+	conflictPath := filepath.Join(absDir, conflict)
+	origInfo, err := os.Stat(origPath)
+	if err != nil {
+		fmt.Println("    (original is missing — nothing to diff)")
+		fmt.Println()
+		return
+	}
+	conflictInfo, err := os.Stat(conflictPath)
+	if err != nil {
+		fmt.Printf("    could not read %s: %v\n\n", conflict, err)
+		return
+	}
+
+	sizes := fmt.Sprintf("%s (original) vs %s (conflict)", formatSize(origInfo.Size()), formatSize(conflictInfo.Size()))
+	if !types.IsText(origPath, original, origInfo) || !types.IsText(conflictPath, conflict, conflictInfo) {
+		fmt.Printf("    binary: %s\n\n", sizes)
+		return
+	}
+	if origInfo.Size() > maxConflictDiffSize || conflictInfo.Size() > maxConflictDiffSize {
+		fmt.Printf("    too large to diff: %s\n\n", sizes)
+		return
+	}
+
+	a, errA := os.ReadFile(origPath)
+	b, errB := os.ReadFile(conflictPath)
+	if errA != nil || errB != nil {
+		fmt.Printf("    could not read files to diff: %s\n\n", sizes)
+		return
+	}
+	if bytes.IndexByte(a, 0) >= 0 || bytes.IndexByte(b, 0) >= 0 {
+		fmt.Printf("    binary: %s\n\n", sizes)
+		return
+	}
+
+	diff, ok := sync.UnifiedDiff(original, conflict, string(a), string(b))
+	switch {
+	case !ok:
+		fmt.Printf("    too many differences to diff: %s\n", sizes)
+	case diff == "":
+		fmt.Println("    (contents are identical)")
+	default:
+		fmt.Println()
+		fmt.Print(diff)
+	}
+	fmt.Println()
+}
+
+func cmdURL(cfg *config.Config) {
+	// Usage: izerop url <file>
+	// Resolves a local file path to its remote URL via the sync state or by searching remote files.
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: izerop url <file>\n")
+		os.Exit(1)
+	}
+
+	filePath := os.Args[2]
+
+	// Resolve to absolute path
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid path: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+
+	// Try to find via sync state first (faster, no API calls for ID lookup)
+	if remoteID := syncedFileID(cfg, absPath); remoteID != "" {
+		if file, err := client.GetFile(runCtx, remoteID); err == nil {
+			fmt.Println(fileURL(cfg, file.ID, file.URL))
+			return
+		}
+	}
+
+	// Fallback: search remote files by name
+	fileName := filepath.Base(absPath)
+	dirs, err := client.ListDirectories(runCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, dir := range dirs {
+		files, err := client.ListFiles(runCtx, dir.ID)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if f.Name == fileName {
+				fmt.Println(fileURL(cfg, f.ID, f.URL))
+				return
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "File not found on server: %s\n", fileName)
+	os.Exit(1)
+}
+
+// fileURL returns a file's asset URL, or its download endpoint on the
+// server when no URL was sent.
+func fileURL(cfg *config.Config, id, url string) string {
+	if url != "" {
+		return url
+	}
+	return fmt.Sprintf("%s/api/v1/files/%s/download", cfg.ServerURL, id)
+}
+
+func cmdShare(cfg *config.Config) {
+	// Usage: izerop share <file-id> [--off] | izerop share --dir <directory_id|/path> [--off]
+	fileID := ""
+	dirRef := ""
+	public := true
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--off":
+			public = false
+		case "--dir":
+			if i+1 < len(os.Args) {
+				dirRef = os.Args[i+1]
+				i++
+			}
+		default:
+			if !strings.HasPrefix(os.Args[i], "-") {
+				fileID = os.Args[i]
+			}
+		}
+	}
+
+	if fileID == "" && dirRef == "" {
+		fmt.Fprintf(os.Stderr, "Usage: izerop share <file-id> [--off]\n")
+		fmt.Fprintf(os.Stderr, "       izerop share --dir <directory_id|/path> [--off]\n")
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	requireFeature(client, api.FeaturePublicLinks, "Sharing is")
+
+	if dirRef != "" {
+		dirID, err := resolveDirID(client, dirRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if dirID == "" {
+			fmt.Fprintf(os.Stderr, "The root can't be shared; pick a directory\n")
+			os.Exit(1)
+		}
+		dir, err := client.SetDirectoryPublic(runCtx, dirID, public)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		name := dir.Path
+		if name == "" {
+			name = dirRef
+		}
+		if public {
+			fmt.Printf("🔗 Shared directory: %s\n", name)
+		} else {
+			fmt.Printf("🔒 Unshared directory: %s\n", name)
+		}
+		return
+	}
+
+	file, err := client.SetFilePublic(runCtx, fileID, public)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !public {
+		fmt.Printf("🔒 Unshared: %s\n", file.Name)
+		return
+	}
+	fmt.Printf("🔗 Shared: %s\n", file.Name)
+	fmt.Println(fileURL(cfg, file.ID, file.URL))
+}
+
+// syncedFileID looks up the remote file ID for a local path inside the sync
+// dir using the sync state. Returns "" if the path isn't tracked.
+func syncedFileID(cfg *config.Config, absPath string) string {
+	if cfg.SyncDir == "" {
+		return ""
+	}
+	absSyncDir, _ := filepath.Abs(cfg.SyncDir)
+	if !strings.HasPrefix(absPath, absSyncDir+string(filepath.Separator)) {
+		return ""
+	}
+	relPath, _ := filepath.Rel(absSyncDir, absPath)
+	store, _ := sync.OpenStore(activeProfile, stateBackend(cfg))
+	defer store.Close()
+
+	if rec, ok := store.GetRecord(relPath); ok && rec.RemoteID != "" {
+		return rec.RemoteID
+	}
+	if noteID, ok := store.State().Notes[relPath]; ok {
+		return noteID
+	}
+	return ""
+}
+
+func cmdCat(cfg *config.Config) {
+	// Usage: izerop cat <file-id|path>
+	// Only file bytes go to stdout so output can be piped.
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: izerop cat <file-id|path>\n")
+		os.Exit(1)
+	}
+
+	fileID := os.Args[2]
+	if absPath, err := filepath.Abs(fileID); err == nil {
+		if remoteID := syncedFileID(cfg, absPath); remoteID != "" {
+			fileID = remoteID
+		}
+	}
+
+	client := newClient(cfg)
+	if _, err := client.DownloadFile(runCtx, fileID, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "cat failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func cmdPull(cfg *config.Config) {
+	// Usage: izerop pull <file_id> [--out <path>] [--direct] [--progress|--no-progress]
+	//        izerop pull --dir <directory_id|/path> [--out-dir <local_dir>] [--overwrite] [--recursive] [--jobs <n>] [--direct]
+	var fileID, dirRef, outPath, outDir string
+	recursive := false
+	direct := false
+	overwrite := false
+	jobs := sync.DefaultWorkers
+
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--out":
+			if i+1 < len(os.Args) {
+				outPath = os.Args[i+1]
+				i++
+			}
+		case "--out-dir":
+			if i+1 < len(os.Args) {
+				outDir = os.Args[i+1]
+				i++
+			}
+		case "--overwrite":
+			overwrite = true
+		case "--dir":
+			if i+1 < len(os.Args) {
+				dirRef = os.Args[i+1]
+				i++
+			}
+		case "--recursive", "-r":
+			recursive = true
+		case "--direct":
+			direct = true
+		case "--jobs", "-j":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "%s requires a number\n", os.Args[i])
+				os.Exit(1)
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Invalid %s value: %s (want a number of at least 1)\n", os.Args[i], os.Args[i+1])
+				os.Exit(1)
+			}
+			jobs = n
+			i++
+		default:
+			if !strings.HasPrefix(os.Args[i], "-") {
+				fileID = os.Args[i]
+			}
+		}
+	}
+
+	if fileID == "" && dirRef == "" {
+		fmt.Fprintf(os.Stderr, "Usage: izerop pull <file_id> [--out <path>] [--direct] [--progress|--no-progress]\n")
+		fmt.Fprintf(os.Stderr, "       izerop pull --dir <directory_id|/path> [--out-dir <local_dir>] [--overwrite] [--recursive] [--jobs <n>] [--direct]\n")
+		os.Exit(1)
+	}
+	if outDir != "" && dirRef == "" {
+		fmt.Fprintf(os.Stderr, "--out-dir is for 'pull --dir'; use --out <path> for a single file\n")
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	progress := trackTransfers(client, 0)
+
+	if dirRef != "" {
+		// --out is the older spelling of --out-dir here
+		if outDir == "" {
+			outDir = outPath
+		}
+		pullDirectory(client, progress, dirRef, outDir, recursive, overwrite, jobs, direct)
+		return
+	}
+
+	// The server's content_hash, when known, is checked before any download
+	// replaces a file
+	wantHash := ""
+	meta, metaErr := client.GetFile(runCtx, fileID)
+	if metaErr == nil {
+		wantHash = meta.ContentHash
+	}
+
+	if direct && metaErr == nil {
+		if path, ok := pullDirect(client, progress, meta, outPath, fileID); ok {
+			info, _ := os.Stat(path)
+			fmt.Printf("✅ Downloaded: %s (%s)\n", path, formatSize(info.Size()))
+			return
+		}
+	}
+
+	// Large files download into a .izerop-part file next to the destination
+	// so an interrupted pull can be resumed by running it again
+	if metaErr == nil && meta.Size >= api.ResumableThreshold {
+		if outPath == "" {
+			outPath = localFileName(meta.Name, fileID)
+		}
+		partPath := outPath + ".izerop-part"
+
+		fmt.Printf("Downloading %s (%s, resumable)...\n", fileID, formatSize(meta.Size))
+		_, err := client.DownloadFileResumable(runCtx, fileID, partPath, meta.Size)
+		progress.clear()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Partial download kept at %s — run the same command to resume.\n", partPath)
+			os.Exit(1)
+		}
+		if err := sync.VerifyHash(partPath, wantHash); err != nil {
+			os.Remove(partPath)
+			exitBadDownload(err)
+		}
+		if err := os.Rename(partPath, outPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not move %s into place: %v\n", partPath, err)
+			os.Exit(1)
+		}
+	} else if outPath == "" {
+		// If no output path, we need to figure out the filename
+		// First download to a buffer to get the filename from headers
+		// Download to temp, then rename. The temp file goes in the current
+		// directory so the rename stays on one filesystem when it can.
+		tmpFile, err := os.CreateTemp(".", ".izerop-dl-*")
+		if err != nil {
+			tmpFile, err = os.CreateTemp("", "izerop-dl-*")
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not create temp file: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Downloading %s...\n", fileID)
+		filename, err := client.DownloadFile(runCtx, fileID, tmpFile)
+		progress.clear()
+		tmpFile.Close()
+		if err != nil {
+			os.Remove(tmpFile.Name())
+			fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+			os.Exit(1)
+		}
+		if err := sync.VerifyHash(tmpFile.Name(), wantHash); err != nil {
+			os.Remove(tmpFile.Name())
+			exitBadDownload(err)
+		}
+
+		outPath = localFileName(filename, fileID)
+
+		if err := moveFile(tmpFile.Name(), outPath); err != nil {
+			os.Remove(tmpFile.Name())
+			fmt.Fprintf(os.Stderr, "Could not save %s: %v\n", outPath, err)
+			os.Exit(1)
+		}
+	} else {
+		// Download beside outPath so a failed or corrupt download never
+		// clobbers an existing file
+		partPath := outPath + ".izerop-part"
+		f, err := os.Create(partPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not create file: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Downloading %s...\n", fileID)
+		_, err = client.DownloadFile(runCtx, fileID, f)
+		f.Close()
+		progress.clear()
+		if err != nil {
+			os.Remove(partPath)
+			fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+			os.Exit(1)
+		}
+		if err := sync.VerifyHash(partPath, wantHash); err != nil {
+			os.Remove(partPath)
+			exitBadDownload(err)
+		}
+		if err := os.Rename(partPath, outPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not move %s into place: %v\n", partPath, err)
+			os.Exit(1)
+		}
+	}
+
+	info, _ := os.Stat(outPath)
+	fmt.Printf("✅ Downloaded: %s (%s)\n", outPath, formatSize(info.Size()))
+}
+
+// pullDirect downloads a single file for 'pull --direct' from the asset URL
+// in its metadata. It reports false, leaving no file behind, when there is
+// no direct URL, a partial download is waiting to be resumed, or the direct
+// fetch fails; the caller then falls back to the authenticated download.
+func pullDirect(client *api.Client, progress *transferProgress, meta *api.FileEntry, outPath, fileID string) (string, bool) {
+	if !isDirectURL(meta.URL) {
+		return "", false
+	}
+	if outPath == "" {
+		outPath = localFileName(meta.Name, fileID)
+	}
+	partPath := outPath + ".izerop-part"
+	if _, err := os.Stat(partPath); err == nil {
+		return "", false
+	}
+
+	fmt.Printf("Downloading %s directly...\n", fileID)
+	err := downloadDirect(client, *meta, partPath)
+	progress.clear()
+	if err == nil {
+		err = os.Rename(partPath, outPath)
+	}
+	if err != nil {
+		os.Remove(partPath)
+		fmt.Fprintf(os.Stderr, "Direct download failed (%v); downloading through the server\n", err)
+		return "", false
+	}
+	return outPath, true
+}
+
+// isDirectURL reports whether a file's URL is an absolute http(s) URL that
+// can be fetched without going through the server's download endpoint.
+func isDirectURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// downloadDirect fetches f from its direct URL into path and checks the
+// result against f.ContentHash. On failure path is removed.
+func downloadDirect(client *api.Client, f api.FileEntry, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = client.DownloadFromURL(runCtx, f.URL, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = sync.VerifyHash(path, f.ContentHash)
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// exitBadDownload reports a download that failed hash verification. The
+// destination was left untouched.
+func exitBadDownload(err error) {
+	fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
+	fmt.Fprintf(os.Stderr, "Nothing was written; run the same command to try again.\n")
+	os.Exit(1)
+}
+
+// rename is os.Rename; tests replace it to force moveFile's copy fallback.
+var rename = os.Rename
+
+// moveFile renames src to dst, copying instead when the rename fails (e.g.
+// across filesystems). If the copy fails, the partial dst is removed and
+// src is left in place.
+func moveFile(src, dst string) error {
+	if err := rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// pullDirectory downloads every file in a remote directory into outDir
+// (default: the directory's name), and its subdirectories with recursive.
+// Files that already exist locally are skipped unless overwrite is set.
+// Files are fetched by up to jobs downloads at a time.
+func pullDirectory(client *api.Client, progress *transferProgress, dirRef, outDir string, recursive, overwrite bool, jobs int, direct bool) {
+	dirID, err := resolveDirID(client, dirRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if dirID == "" {
+		fmt.Fprintf(os.Stderr, "Specify a directory to pull, not the root\n")
+		os.Exit(1)
+	}
+
+	dirs, err := client.ListDirectories(runCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing directories: %v\n", err)
+		os.Exit(1)
+	}
+
+	children := make(map[string][]api.Directory)
+	var root *api.Directory
+	for i, d := range dirs {
+		if d.ParentID != nil {
+			children[*d.ParentID] = append(children[*d.ParentID], d)
+		}
+		if d.ID == dirID {
+			root = &dirs[i]
+		}
+	}
+	if root == nil {
+		fmt.Fprintf(os.Stderr, "directory not found: %s\n", dirRef)
+		os.Exit(1)
+	}
+	if outDir == "" {
+		outDir = root.Name
+	}
+
+	// The progress line tracks one transfer at a time, so it is only
+	// drawn when downloads run serially
+	if jobs > 1 && progress != nil {
+		client.OnTransfer = nil
+		progress = nil
+	}
+
+	fmt.Printf("Downloading %s/ into %s...\n", root.Path, outDir)
+	start := time.Now()
+	t := &treeDownloader{client: client, progress: progress, children: children, recursive: recursive, direct: direct, keepExisting: !overwrite}
+	t.plan(dirID, outDir, "")
+	t.run(jobs)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	for _, e := range t.errs {
+		fmt.Fprintf(os.Stderr, "  ⚠ %s\n", e)
+	}
+	fmt.Printf("✅ Downloaded %d file(s) (%s)", t.files, formatSize(t.bytes))
+	if recursive {
+		fmt.Printf(", %d dir(s)", t.dirs)
+	}
+	if t.skipped > 0 {
+		fmt.Printf(", %d skipped (already exist; use --overwrite to replace)", t.skipped)
+	}
+	if len(t.errs) > 0 {
+		fmt.Printf(", %d error(s)", len(t.errs))
+	}
+	fmt.Printf(" in %s\n", elapsed)
+	if len(t.errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// treeDownloader is the read counterpart of uploadTree: it copies a remote
+// directory into a local one, following the ParentID graph when recursive.
+// plan walks the tree and creates local directories; run then downloads
+// the planned files in parallel.
+type treeDownloader struct {
+	client    *api.Client
+	progress  *transferProgress
+	children  map[string][]api.Directory
+	recursive bool
+	direct    bool // try each file's direct URL first (pull --direct)
+	pending   []treeDownload
+	// keepExisting makes plan skip files that already exist locally
+	keepExisting bool
+
+	mu                   gosync.Mutex
+	files, dirs, skipped int
+	bytes                int64
+	errs                 []string
+}
+
+// treeDownload is one file planned by treeDownloader.plan.
+type treeDownload struct {
+	file api.FileEntry
+	dest string
+	rel  string
+}
+
+// localFileName turns a server-suggested name (file metadata or a
+// Content-Disposition header) into a file name in the current directory.
+// Directory components are stripped, and anything left that isn't a safe
+// single path element (empty, ".", "..") becomes fallback.
+func localFileName(name, fallback string) string {
+	seps := `/\`
+	if runtime.GOOS == "windows" {
+		seps += ":" // "C:name" is relative to another drive's cwd
+	}
+	if i := strings.LastIndexAny(name, seps); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(name)
+	if !safeName(name) || strings.ContainsRune(name, 0) {
+		return fallback
+	}
+	return name
+}
+
+// safeName reports whether a remote name can be used as a single local path
+// element without escaping the destination directory.
+func safeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// plan creates localDir and queues the files of remote directory dirID for
+// download. relDir is the path shown in output, relative to the top of the
+// pull.
+func (t *treeDownloader) plan(dirID, localDir, relDir string) {
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		t.errs = append(t.errs, fmt.Sprintf("mkdir %s: %v", localDir, err))
+		return
+	}
+
+	files, err := t.client.ListFiles(runCtx, dirID)
+	if err != nil {
+		t.errs = append(t.errs, fmt.Sprintf("list %s: %v", localDir, err))
+	}
+	for _, f := range files {
+		rel := filepath.Join(relDir, f.Name)
+		if !safeName(f.Name) {
+			t.errs = append(t.errs, fmt.Sprintf("skipping unsafe name %q", rel))
+			continue
+		}
+		dest := filepath.Join(localDir, f.Name)
+		if t.keepExisting {
+			if _, err := os.Lstat(dest); err == nil {
+				t.skipped++
+				fmt.Printf("  ⏭ %s (exists)\n", rel)
+				continue
 			}
 		}
+		t.pending = append(t.pending, treeDownload{file: f, dest: dest, rel: rel})
 	}
 
-	fmt.Fprintf(os.Stderr, "File not found on server: %s\n", fileName)
-	os.Exit(1)
-}
-
-func cmdPull(cfg *config.Config) {
-	// Usage: izerop pull <file_id> [--out <path>]
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: izerop pull <file_id> [--out <path>]\n")
-		os.Exit(1)
+	if !t.recursive {
+		return
 	}
-
-	fileID := os.Args[2]
-	var outPath string
-
-	for i := 3; i < len(os.Args); i++ {
-		if os.Args[i] == "--out" && i+1 < len(os.Args) {
-			outPath = os.Args[i+1]
-			i++
+	for _, d := range t.children[dirID] {
+		rel := filepath.Join(relDir, d.Name)
+		if !safeName(d.Name) {
+			t.errs = append(t.errs, fmt.Sprintf("skipping unsafe name %q", rel))
+			continue
 		}
+		t.dirs++
+		fmt.Printf("  📁 %s/\n", rel)
+		t.plan(d.ID, filepath.Join(localDir, d.Name), rel)
 	}
+}
 
-	client := newClient(cfg)
-
-	// If no output path, we need to figure out the filename
-	// First download to a buffer to get the filename from headers
-	if outPath == "" {
-		// Download to temp, then rename
-		tmpFile, err := os.CreateTemp("", "izerop-dl-*")
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Could not create temp file: %v\n", err)
-			os.Exit(1)
-		}
+// run downloads the planned files with up to jobs transfers at a time. A
+// failed file is recorded in errs and the rest of the batch carries on.
+func (t *treeDownloader) run(jobs int) {
+	sync.ForEach(jobs, len(t.pending), func(i int) {
+		d := t.pending[i]
+		err := t.downloadFile(d.file, d.dest)
 
-		fmt.Printf("Downloading %s...\n", fileID)
-		filename, err := client.DownloadFile(fileID, tmpFile)
-		tmpFile.Close()
+		t.mu.Lock()
+		defer t.mu.Unlock()
 		if err != nil {
-			os.Remove(tmpFile.Name())
-			fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
-			os.Exit(1)
+			t.errs = append(t.errs, fmt.Sprintf("download %s: %v", d.rel, err))
+			return
 		}
+		t.files++
+		t.bytes += d.file.Size
+		fmt.Printf("  ⬇ %s (%s)\n", d.rel, formatSize(d.file.Size))
+	})
+}
 
-		if filename == "" {
-			filename = fileID
+// downloadFile writes one remote file to dest via a .izerop-part file, so a
+// failed download never leaves a truncated file under the real name. Large
+// partial downloads are kept and resume on the next pull; one that fails
+// the content_hash check is discarded. With direct set, a file whose
+// listing has a direct URL is fetched from it first.
+func (t *treeDownloader) downloadFile(f api.FileEntry, dest string) error {
+	partPath := dest + ".izerop-part"
+	if t.direct && isDirectURL(f.URL) {
+		if _, err := os.Stat(partPath); err != nil {
+			err := downloadDirect(t.client, f, partPath)
+			t.progress.clear()
+			if err == nil {
+				return os.Rename(partPath, dest)
+			}
+			// Fall back to the authenticated download
 		}
-		outPath = filename
+	}
 
-		if err := os.Rename(tmpFile.Name(), outPath); err != nil {
-			// Cross-device rename, copy instead
-			src, _ := os.Open(tmpFile.Name())
-			dst, _ := os.Create(outPath)
-			io.Copy(dst, src)
-			src.Close()
-			dst.Close()
-			os.Remove(tmpFile.Name())
-		}
+	var err error
+	if f.Size >= api.ResumableThreshold {
+		_, err = t.client.DownloadFileResumable(runCtx, f.ID, partPath, f.Size)
 	} else {
-		f, err := os.Create(outPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Could not create file: %v\n", err)
-			os.Exit(1)
+		out, createErr := os.Create(partPath)
+		if createErr != nil {
+			return createErr
 		}
-		defer f.Close()
-
-		fmt.Printf("Downloading %s...\n", fileID)
-		_, err = client.DownloadFile(fileID, f)
+		_, err = t.client.DownloadFile(runCtx, f.ID, out)
+		out.Close()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Download failed: %v\n", err)
-			os.Exit(1)
+			os.Remove(partPath)
 		}
 	}
-
-	info, _ := os.Stat(outPath)
-	fmt.Printf("✅ Downloaded: %s (%s)\n", outPath, formatSize(info.Size()))
+	t.progress.clear()
+	if err != nil {
+		return err
+	}
+	if err := sync.VerifyHash(partPath, f.ContentHash); err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	return os.Rename(partPath, dest)
 }
 
 func cmdList(cfg *config.Config) {
+	// Usage: izerop ls [<directory-id>] [--since|--modified-since <time|duration>] [--id-only|--path-only]
+	dirID := ""
+	var since time.Time
+	idOnly := false
+	pathOnly := false
+
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--id-only":
+			idOnly = true
+		case "--path-only":
+			pathOnly = true
+		case "--since", "--modified-since":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "%s requires a time or duration\n", os.Args[i])
+				os.Exit(1)
+			}
+			t, err := parseSince(os.Args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			since = t
+			i++
+		default:
+			// Optional directory ID
+			if !strings.HasPrefix(os.Args[i], "--") {
+				dirID = os.Args[i]
+			}
+		}
+	}
+
 	client := newClient(cfg)
 
-	// Optional directory ID as second arg
-	dirID := ""
-	if len(os.Args) > 2 && !strings.HasPrefix(os.Args[2], "--") {
-		dirID = os.Args[2]
+	dirID, err := resolveDirID(client, dirID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
 
 	// List directories
-	dirs, err := client.ListDirectories()
+	dirs, err := client.ListDirectories(runCtx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error listing directories: %v\n", err)
 		os.Exit(1)
 	}
 
+	// --id-only and --path-only print one bare value per line for pipelines,
+	// so directory headers and other decoration are left out
+	bare := idOnly || pathOnly
+	printFile := func(dirPath string, f api.FileEntry) {
+		switch {
+		case idOnly:
+			fmt.Println(f.ID)
+		case pathOnly:
+			if f.Path != "" {
+				fmt.Println(f.Path)
+			} else {
+				fmt.Println(dirPath + "/" + f.Name)
+			}
+		default:
+			fmt.Printf("  📄 %-28s  %8s  %s  %s\n", f.Name, formatSize(f.Size), formatTime(f.UpdatedAt), f.ID)
+		}
+	}
+
 	if dirID == "" {
 		// Show all directories and all files
 		for _, d := range dirs {
-			fmt.Printf("📁 %-30s  %d files  %s\n", d.Path+"/", d.FileCount, d.ID)
-
 			// List files in this directory
-			files, err := client.ListFiles(d.ID)
+			files, err := client.ListFiles(runCtx, d.ID)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "  ⚠ Error listing files: %v\n", err)
+				if !bare {
+					fmt.Printf("📁 %-30s  %d files  %s\n", d.Path+"/", d.FileCount, d.ID)
+				}
+				fmt.Fprintf(os.Stderr, "  ⚠ Error listing files in %s: %v\n", d.Path, err)
 				continue
 			}
+			if !since.IsZero() {
+				files = filterSince(files, since)
+				if len(files) == 0 {
+					continue // nothing recent in this directory
+				}
+			}
+
+			if !bare {
+				fmt.Printf("📁 %-30s  %d files  %s\n", d.Path+"/", d.FileCount, d.ID)
+			}
 			for _, f := range files {
-				size := formatSize(f.Size)
-				fmt.Printf("  📄 %-28s  %8s  %s  %s\n", f.Name, size, f.UpdatedAt, f.ID)
+				printFile(d.Path, f)
 			}
 		}
 
 		// Also show files without a directory filter (root-level)
 	} else {
 		// List files in specific directory
-		files, err := client.ListFiles(dirID)
+		files, err := client.ListFiles(runCtx, dirID)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error listing files: %v\n", err)
 			os.Exit(1)
 		}
+		if !since.IsZero() {
+			files = filterSince(files, since)
+		}
 		if len(files) == 0 {
-			fmt.Println("No files found.")
+			if !bare {
+				fmt.Println("No files found.")
+			}
 			return
 		}
+		dirPath := ""
+		for _, d := range dirs {
+			if d.ID == dirID {
+				dirPath = d.Path
+				break
+			}
+		}
 		for _, f := range files {
-			size := formatSize(f.Size)
-			fmt.Printf("  📄 %-28s  %8s  %s  %s\n", f.Name, size, f.UpdatedAt, f.ID)
+			printFile(dirPath, f)
+		}
+	}
+}
+
+func cmdTree(cfg *config.Config) {
+	// Usage: izerop tree [<directory-id|/path>] [--depth N] [--ids]
+	rootRef := ""
+	depth := 0
+	showIDs := false
+
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--depth", "-L":
+			if i+1 < len(os.Args) {
+				n, err := strconv.Atoi(os.Args[i+1])
+				if err != nil || n < 1 {
+					fmt.Fprintf(os.Stderr, "Invalid depth: %s\n", os.Args[i+1])
+					os.Exit(1)
+				}
+				depth = n
+				i++
+			}
+		case "--ids":
+			showIDs = true
+		default:
+			if !strings.HasPrefix(os.Args[i], "-") {
+				rootRef = os.Args[i]
+			}
+		}
+	}
+
+	client := newClient(cfg)
+
+	rootID, err := resolveDirID(client, rootRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	dirs, err := client.ListDirectories(runCtx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing directories: %v\n", err)
+		os.Exit(1)
+	}
+
+	children := make(map[string][]api.Directory)
+	label := "/"
+	for _, d := range dirs {
+		parent := ""
+		if d.ParentID != nil {
+			parent = *d.ParentID
+		}
+		children[parent] = append(children[parent], d)
+		if d.ID == rootID {
+			label = d.Path + "/"
+		}
+	}
+	for _, list := range children {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+
+	t := &treePrinter{client: client, children: children, maxDepth: depth, showIDs: showIDs}
+	if rootID != "" && showIDs {
+		label += "  " + rootID
+	}
+	fmt.Println(label)
+	t.print(rootID, "", 1)
+	fmt.Printf("\n%d directories, %d files (%s)\n", t.dirs, t.files, formatSize(t.bytes))
+}
+
+// treePrinter draws the remote directory hierarchy for cmdTree. Files are
+// listed per directory only when that directory is printed, so --depth
+// also limits API calls.
+type treePrinter struct {
+	client   *api.Client
+	children map[string][]api.Directory
+	maxDepth int
+	showIDs  bool
+
+	dirs, files int
+	bytes       int64
+}
+
+// print writes the subdirectories and files of dirID, one level deeper
+// than its parent. An empty dirID means the top level, which has no files.
+func (t *treePrinter) print(dirID, prefix string, level int) {
+	if t.maxDepth > 0 && level > t.maxDepth {
+		return
+	}
+
+	var files []api.FileEntry
+	if dirID != "" {
+		list, err := t.client.ListFiles(runCtx, dirID)
+		if err != nil {
+			fmt.Printf("%s└── ⚠ error listing files: %v\n", prefix, err)
+		}
+		files = list
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	}
+
+	subdirs := t.children[dirID]
+	total := len(subdirs) + len(files)
+	n := 0
+	connector := func() (string, string) {
+		n++
+		if n == total {
+			return "└── ", "    "
+		}
+		return "├── ", "│   "
+	}
+
+	for _, d := range subdirs {
+		branch, indent := connector()
+		line := d.Name + "/"
+		if t.showIDs {
+			line += "  " + d.ID
+		}
+		fmt.Println(prefix + branch + line)
+		t.dirs++
+		t.print(d.ID, prefix+indent, level+1)
+	}
+	for _, f := range files {
+		branch, _ := connector()
+		line := fmt.Sprintf("%s  %s", f.Name, formatSize(f.Size))
+		if t.showIDs {
+			line += "  " + f.ID
+		}
+		fmt.Println(prefix + branch + line)
+		t.files++
+		t.bytes += f.Size
+	}
+}
+
+// resolveDirID turns a directory reference into an ID. References starting
+// with "/" are paths like /photos/2024 matched against Directory.Path ("/"
+// is the top level); anything else is taken as an ID.
+func resolveDirID(client *api.Client, ref string) (string, error) {
+	if !strings.HasPrefix(ref, "/") {
+		return ref, nil
+	}
+	path := strings.TrimSuffix(ref, "/")
+	if path == "" {
+		return "", nil
+	}
+
+	dirs, err := client.ListDirectories(runCtx)
+	if err != nil {
+		return "", fmt.Errorf("could not list directories: %w", err)
+	}
+	for _, d := range dirs {
+		if strings.TrimSuffix(d.Path, "/") == path {
+			return d.ID, nil
+		}
+	}
+	return "", fmt.Errorf("directory not found: %s", ref)
+}
+
+// ensureDirPath resolves a directory path like resolveDirID, creating any
+// missing directories along the way (like mkdir -p).
+func ensureDirPath(client *api.Client, ref string) (string, error) {
+	dirs, err := client.ListDirectories(runCtx)
+	if err != nil {
+		return "", fmt.Errorf("could not list directories: %w", err)
+	}
+	byPath := make(map[string]string, len(dirs))
+	for _, d := range dirs {
+		byPath[strings.TrimSuffix(d.Path, "/")] = d.ID
+	}
+
+	parentID, current := "", ""
+	for _, part := range strings.Split(strings.Trim(ref, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		current += "/" + part
+		if id, ok := byPath[current]; ok {
+			parentID = id
+			continue
+		}
+		dir, err := client.CreateDirectory(runCtx, part, parentID)
+		if err != nil {
+			return "", fmt.Errorf("could not create %s: %w", current, err)
+		}
+		fmt.Printf("📁 Created: %s/ (%s)\n", current, dir.ID)
+		byPath[current] = dir.ID
+		parentID = dir.ID
+	}
+	return parentID, nil
+}
+
+// parseSince parses an RFC3339 timestamp, a bare date (YYYY-MM-DD, local
+// time), or a duration ago like "24h", "90m", or "7d".
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (expected RFC3339 like 2024-01-01T09:00:00Z, a date like 2024-01-01, or a duration like 24h or 7d)", s)
+}
+
+// filterSince keeps files updated after the given time. Files with an
+// unparseable UpdatedAt are dropped.
+func filterSince(files []api.FileEntry, since time.Time) []api.FileEntry {
+	var kept []api.FileEntry
+	for _, f := range files {
+		updated, err := time.Parse(time.RFC3339, f.UpdatedAt)
+		if err == nil && updated.After(since) {
+			kept = append(kept, f)
 		}
 	}
+	return kept
 }
 
 func cmdMkdir(cfg *config.Config) {
-	// Usage: izerop mkdir <name> [--parent <directory_id>]
+	// Usage: izerop mkdir <name|/path> [--parent <directory_id|/path>] [--parents]
 	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: izerop mkdir <name> [--parent <directory_id>]\n")
+		fmt.Fprintf(os.Stderr, "Usage: izerop mkdir <name|/path> [--parent <directory_id|/path>] [--parents]\n")
 		os.Exit(1)
 	}
 
 	name := os.Args[2]
-	var parentID string
+	var parentRef string
+	parents := false
 
 	for i := 3; i < len(os.Args); i++ {
-		if os.Args[i] == "--parent" && i+1 < len(os.Args) {
-			parentID = os.Args[i+1]
-			i++
+		switch os.Args[i] {
+		case "--parent":
+			if i+1 < len(os.Args) {
+				parentRef = os.Args[i+1]
+				i++
+			}
+		case "--parents", "-p":
+			parents = true
+		}
+	}
+
+	// A full path like /photos/2024 names both the parent and the new directory
+	if strings.HasPrefix(name, "/") {
+		trimmed := strings.TrimSuffix(name, "/")
+		slash := strings.LastIndex(trimmed, "/")
+		parentRef, name = trimmed[:slash], trimmed[slash+1:]
+		if parentRef == "" {
+			parentRef = "/"
 		}
 	}
 
 	client := newClient(cfg)
 
-	dir, err := client.CreateDirectory(name, parentID)
+	var parentID string
+	var err error
+	if parents && strings.HasPrefix(parentRef, "/") {
+		parentID, err = ensureDirPath(client, parentRef)
+	} else {
+		parentID, err = resolveDirID(client, parentRef)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	dir, err := client.CreateDirectory(runCtx, name, parentID)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create directory: %v\n", err)
 		os.Exit(1)
@@ -824,31 +3070,44 @@ func cmdMkdir(cfg *config.Config) {
 }
 
 func cmdRm(cfg *config.Config) {
-	// Usage: izerop rm <id> [--dir]
+	// Usage: izerop rm <id|/path/glob> [--dir] [--yes]
 	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: izerop rm <file_id|directory_id> [--dir]\n")
+		fmt.Fprintf(os.Stderr, "Usage: izerop rm <file_id|directory_id|/path/glob> [--dir] [--yes]\n")
 		os.Exit(1)
 	}
 
 	id := os.Args[2]
 	isDir := false
+	yes := false
 
 	for i := 3; i < len(os.Args); i++ {
-		if os.Args[i] == "--dir" {
+		switch os.Args[i] {
+		case "--dir":
 			isDir = true
+		case "--yes", "-y":
+			yes = true
 		}
 	}
 
 	client := newClient(cfg)
 
+	if isPathPattern(id) {
+		if isDir {
+			fmt.Fprintf(os.Stderr, "--dir takes a directory ID, not a path pattern\n")
+			os.Exit(1)
+		}
+		rmMatching(client, id, yes)
+		return
+	}
+
 	if isDir {
-		if err := client.DeleteDirectory(id); err != nil {
+		if err := client.DeleteDirectory(runCtx, id); err != nil {
 			fmt.Fprintf(os.Stderr, "Delete failed: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("✅ Directory deleted: %s\n", id)
 	} else {
-		if err := client.DeleteFile(id); err != nil {
+		if err := client.DeleteFile(runCtx, id); err != nil {
 			fmt.Fprintf(os.Stderr, "Delete failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -856,54 +3115,217 @@ func cmdRm(cfg *config.Config) {
 	}
 }
 
+// isPathPattern reports whether an rm argument is a remote path or glob
+// rather than an ID.
+func isPathPattern(arg string) bool {
+	return strings.HasPrefix(arg, "/") || strings.ContainsAny(arg, "*?[")
+}
+
+// rmMatching deletes every file whose path matches pattern, after listing
+// them and asking for confirmation unless yes is set.
+func rmMatching(client *api.Client, pattern string, yes bool) {
+	files, err := resolveFiles(client, pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "No files match %s\n", pattern)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d file(s) match %s:\n", len(files), pattern)
+	for _, f := range files {
+		fmt.Printf("  %s  %s\n", f.Path, formatSize(f.Size))
+	}
+	if !yes {
+		fmt.Print("Delete these files? [y/N] ")
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	failed := 0
+	for _, f := range files {
+		if err := client.DeleteFile(runCtx, f.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "  ❌ %s: %v\n", f.Path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("  ✅ %s\n", f.Path)
+	}
+	fmt.Printf("Deleted %d of %d file(s)\n", len(files)-failed, len(files))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// resolveFiles returns the manifest files whose path matches a glob like
+// "/logs/*.log". As with filepath.Match, "*" does not cross directories.
+func resolveFiles(client *api.Client, glob string) ([]api.ManifestEntry, error) {
+	pattern := filepath.FromSlash(strings.TrimPrefix(glob, "/"))
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", glob, err)
+	}
+
+	manifest, err := client.GetManifest(runCtx, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch manifest: %w", err)
+	}
+	var matches []api.ManifestEntry
+	for _, f := range manifest.Files {
+		rel := filepath.FromSlash(strings.TrimPrefix(f.Path, "/"))
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			matches = append(matches, f)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Path < matches[j].Path })
+	return matches, nil
+}
+
 func cmdMv(cfg *config.Config) {
-	// Usage: izerop mv <file_id> [--name <new_name>] [--dir <directory_id>]
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: izerop mv <file_id> [--name <new_name>] [--dir <directory_id>]\n")
+	// Usage: izerop mv <file_id> [--name <new_name>] [--dir <directory_id|/path>]
+	//        izerop mv [--dir-id] <directory_id|/path> [--name <new_name>] [--parent <directory_id|/path>]
+	var fileID, dirRef, newName, newDirID string
+
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--name":
+			if i+1 < len(os.Args) {
+				newName = os.Args[i+1]
+				i++
+			}
+		case "--dir", "--parent":
+			if i+1 < len(os.Args) {
+				newDirID = os.Args[i+1]
+				i++
+			}
+		case "--dir-id":
+			if i+1 < len(os.Args) {
+				dirRef = os.Args[i+1]
+				i++
+			}
+		default:
+			if !strings.HasPrefix(os.Args[i], "-") {
+				fileID = os.Args[i]
+			}
+		}
+	}
+
+	if fileID == "" && dirRef == "" {
+		fmt.Fprintf(os.Stderr, "Usage: izerop mv <file_id> [--name <new_name>] [--dir <directory_id|/path>]\n")
+		fmt.Fprintf(os.Stderr, "       izerop mv [--dir-id] <directory_id|/path> [--name <new_name>] [--parent <directory_id|/path>]\n")
+		os.Exit(1)
+	}
+
+	if newName == "" && newDirID == "" {
+		fmt.Fprintf(os.Stderr, "Specify --name and/or --dir\n")
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+
+	// A directory path, or an ID that belongs to a directory, moves the directory
+	if dirRef == "" && (strings.HasPrefix(fileID, "/") || isDirectoryID(client, fileID)) {
+		dirRef = fileID
+	}
+	if dirRef != "" {
+		moveDirectory(client, dirRef, newName, newDirID)
+		return
+	}
+
+	newDirID, err := resolveDirID(client, newDirID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	file, err := client.MoveFile(runCtx, fileID, newName, newDirID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Move failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	fileID := os.Args[2]
-	var newName, newDirID string
+	fmt.Printf("✅ Moved: %s → %s\n", fileID[:8], file.Name)
+}
 
-	for i := 3; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--name":
-			if i+1 < len(os.Args) {
-				newName = os.Args[i+1]
-				i++
-			}
-		case "--dir":
-			if i+1 < len(os.Args) {
-				newDirID = os.Args[i+1]
-				i++
-			}
+// isDirectoryID reports whether id names a remote directory rather than a file.
+func isDirectoryID(client *api.Client, id string) bool {
+	dirs, err := client.ListDirectories(runCtx)
+	if err != nil {
+		return false
+	}
+	for _, d := range dirs {
+		if d.ID == id {
+			return true
 		}
 	}
+	return false
+}
 
-	if newName == "" && newDirID == "" {
-		fmt.Fprintf(os.Stderr, "Specify --name and/or --dir\n")
+// moveDirectory renames a remote directory and/or moves it under a new
+// parent, then prints where it ended up.
+func moveDirectory(client *api.Client, dirRef, newName, parentRef string) {
+	dirID, err := resolveDirID(client, dirRef)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if dirID == "" {
+		fmt.Fprintf(os.Stderr, "The top-level directory can't be moved\n")
 		os.Exit(1)
 	}
 
-	client := newClient(cfg)
+	parentID := ""
+	if parentRef != "" {
+		parentID, err = resolveDirID(client, parentRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if parentID == "" {
+			fmt.Fprintf(os.Stderr, "Moving a directory to the top level isn't supported; give a parent directory\n")
+			os.Exit(1)
+		}
+		if parentID == dirID {
+			fmt.Fprintf(os.Stderr, "A directory can't be moved into itself\n")
+			os.Exit(1)
+		}
+	}
 
-	file, err := client.MoveFile(fileID, newName, newDirID)
+	dir, err := client.MoveDirectory(runCtx, dirID, newName, parentID)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Move failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Moved: %s → %s\n", fileID[:8], file.Name)
+	newPath := dir.Path
+	if newPath == "" {
+		newPath = dir.Name
+	}
+	fmt.Printf("✅ Moved directory: %s → %s\n", dirRef, newPath)
 }
 
 func cmdWatch(cfg *config.Config) {
-	// Usage: izerop watch [<directory>] [--interval <seconds>] [--daemon] [--log <path>] [--verbose]
+	// Usage: izerop watch [<directory>] [--root <remote-dir>] [--confirm-first-sync] [--interval <seconds>] [--reconcile-interval <seconds>] [--quiet-hours <HH:MM-HH:MM>] [--no-delete] [--skip-growing] [--exclude-vcs] [--daemon] [--log <path>] [--log-format text|json] [--json-log] [--pidfile <path>] [--verbose]
 	syncDir := cfg.SyncDir
-	interval := 30 * time.Second
+	interval := time.Duration(cfg.PollIntervalS) * time.Second
+	reconcileInterval := time.Duration(cfg.ReconcileIntervalS) * time.Second
 	verbose := false
 	daemon := false
 	logPath := ""
+	noDelete := cfg.NoDelete
+	quietHours := cfg.QuietHours
+	skipGrowing := cfg.SkipGrowing
+	excludeVCS := cfg.ExcludeVCS
+	syncRoot := cfg.SyncRoot
+	confirmFirst := false
+	logFormat := cfg.LogFormat
+	if logFormat == "" {
+		logFormat = "text"
+	}
 
 	for i := 2; i < len(os.Args); i++ {
 		switch os.Args[i] {
@@ -917,6 +3339,16 @@ func cmdWatch(cfg *config.Config) {
 				interval = time.Duration(secs) * time.Second
 				i++
 			}
+		case "--reconcile-interval":
+			if i+1 < len(os.Args) {
+				secs, err := strconv.Atoi(os.Args[i+1])
+				if err != nil || secs < 0 {
+					fmt.Fprintf(os.Stderr, "Invalid reconcile interval: %s\n", os.Args[i+1])
+					os.Exit(1)
+				}
+				reconcileInterval = time.Duration(secs) * time.Second
+				i++
+			}
 		case "--daemon", "-d", "--background":
 			daemon = true
 		case "--log":
@@ -924,6 +3356,41 @@ func cmdWatch(cfg *config.Config) {
 				logPath = os.Args[i+1]
 				i++
 			}
+		case "--pidfile":
+			if i+1 < len(os.Args) {
+				setPIDFileOverride(os.Args[i+1])
+				i++
+			}
+		case "--quiet-hours":
+			if i+1 < len(os.Args) {
+				quietHours = os.Args[i+1]
+				i++
+			}
+		case "--log-format":
+			if i+1 < len(os.Args) {
+				logFormat = os.Args[i+1]
+				i++
+			}
+		case "--json-log":
+			logFormat = "json"
+		case "--root":
+			if i+1 < len(os.Args) {
+				root, err := parseRoot(os.Args[i+1])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					os.Exit(1)
+				}
+				syncRoot = root
+				i++
+			}
+		case "--confirm-first-sync":
+			confirmFirst = true
+		case "--no-delete", "--delete-remote=false":
+			noDelete = true
+		case "--skip-growing":
+			skipGrowing = true
+		case "--exclude-vcs":
+			excludeVCS = true
 		case "--verbose", "-v":
 			verbose = true
 		default:
@@ -933,6 +3400,21 @@ func cmdWatch(cfg *config.Config) {
 		}
 	}
 
+	if logFormat != "text" && logFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid --log-format %q (expected text or json)\n", logFormat)
+		os.Exit(1)
+	}
+
+	var quiet *watcher.QuietHours
+	if quietHours != "" {
+		q, err := watcher.ParseQuietHours(quietHours)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		quiet = q
+	}
+
 	if syncDir == "" {
 		syncDir = "."
 	}
@@ -951,18 +3433,37 @@ func cmdWatch(cfg *config.Config) {
 	}
 
 	// Check if a watcher is already running for this profile
-	if running, pid := getWatcherStatusForProfile(activeProfile); running {
+	if running, pid := watcherStatusAt(pidFilePath()); running {
 		fmt.Fprintf(os.Stderr, "⚠ Watcher already running for profile %q (PID %d)\n", activeProfile, pid)
 		fmt.Fprintf(os.Stderr, "   Stop it first: izerop --profile %s watch --stop\n", activeProfile)
 		os.Exit(1)
 	}
 
+	// The watcher starts with a full sync, so it gets the same first-run check
+	if !confirmFirst {
+		store := openSyncStore(cfg)
+		state := store.State()
+		engine := sync.NewEngine(newClient(cfg), syncDir, state)
+		engine.SetRoot(syncRoot)
+		engine.Store = store
+		engine.Ignore = sync.LoadIgnoreRules(syncDir, cfg.DefaultIgnore...)
+		engine.FilterOSJunk = cfg.OSJunkFiltered()
+		engine.Symlinks = symlinkPolicy(cfg)
+		if excludeVCS {
+			engine.ExcludeVCS()
+		}
+		guardFirstSync(engine, state.Cursor, "izerop watch")
+		store.Close()
+		// Confirmed (or not needed): a daemon child mustn't ask again
+		os.Args = append(os.Args, "--confirm-first-sync")
+	}
+
 	// Daemon mode: fork and exit parent
 	if daemon {
 		if logPath == "" {
 			logPath = defaultLogPath()
 		}
-		if err := daemonize(logPath); err != nil {
+		if err := daemonize(syncDir, logPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Daemon failed: %v\n", err)
 			os.Exit(1)
 		}
@@ -970,7 +3471,7 @@ func cmdWatch(cfg *config.Config) {
 	}
 
 	// Set up logger
-	logger := log.New(os.Stdout, "", log.LstdFlags)
+	var logOut io.Writer = os.Stdout
 	if logPath != "" {
 		logFile, err := openLogFile(logPath)
 		if err != nil {
@@ -978,7 +3479,13 @@ func cmdWatch(cfg *config.Config) {
 			os.Exit(1)
 		}
 		defer logFile.Close()
-		logger = log.New(logFile, "", log.LstdFlags)
+		logOut = logFile
+	}
+	logger := log.New(logOut, "", log.LstdFlags)
+	var jsonLog *watcher.JSONLogWriter
+	if logFormat == "json" {
+		jsonLog = watcher.NewJSONLogWriter(logOut, activeProfile)
+		logger = log.New(jsonLog, "", 0)
 	}
 
 	// Write PID file and daemon args
@@ -1006,6 +3513,26 @@ func cmdWatch(cfg *config.Config) {
 		SettleTime:   settleTime,
 		Verbose:      verbose,
 		Logger:       logger,
+
+		ReconcileInterval: reconcileInterval,
+		SyncRoot:          syncRoot,
+		TextExtensions:    cfg.TextExtensions,
+		BinaryExtensions:  cfg.BinaryExtensions,
+		NoTextAPI:         cfg.NoTextAPI,
+		MaxTextSize:       cfg.TextSizeLimit(),
+		NoDelete:          noDelete,
+		QuietHours:        quiet,
+		SkipGrowing:       skipGrowing,
+		PreserveMode:      cfg.PreserveMode,
+		MaxUploadAttempts: cfg.MaxUploadAttempts,
+		MaxFileSize:       int64(cfg.MaxFileSize),
+		ExcludeVCS:        excludeVCS,
+		DefaultIgnore:     cfg.DefaultIgnore,
+		KeepOSJunk:        !cfg.OSJunkFiltered(),
+		ConflictStrategy:  conflictStrategy(cfg),
+		Symlinks:          symlinkPolicy(cfg),
+		StateBackend:      stateBackend(cfg),
+		JSONLog:           jsonLog,
 	})
 	if err != nil {
 		logger.Fatalf("Failed to start watcher: %v", err)
@@ -1025,36 +3552,65 @@ func cmdWatch(cfg *config.Config) {
 // originalArgs stores the full os.Args before --server extraction.
 var originalArgs []string
 
-func daemonize(logPath string) error {
+// debugHTTP logs every API request to stderr (--debug or IZEROP_DEBUG);
+// debugBodies also dumps text bodies (--debug=bodies).
+var debugHTTP, debugBodies bool
+
+// serverOverride holds the --server flag value, if given.
+var serverOverride string
+
+// watchValueFlags are watch flags that take a value and are passed through to the daemon.
+var watchValueFlags = map[string]bool{
+	"--interval":           true,
+	"--reconcile-interval": true,
+	"--quiet-hours":        true,
+	"--log-format":         true,
+	"--root":               true,
+}
+
+func daemonize(syncDir, logPath string) error {
 	// Re-exec ourselves with --log and without --daemon
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("could not find executable path: %w", err)
 	}
 
-	// Use original args (before --server was stripped) to preserve all flags
-	srcArgs := originalArgs
-	if len(srcArgs) == 0 {
-		srcArgs = os.Args
+	// Resolve paths up front — the daemon runs from a different working directory
+	if syncDir, err = filepath.Abs(syncDir); err != nil {
+		return fmt.Errorf("invalid directory: %w", err)
 	}
-
-	args := []string{execPath}
-	hasProfile := false
-	for _, arg := range srcArgs[1:] {
-		if arg == "--daemon" || arg == "-d" || arg == "--background" {
-			continue
-		}
-		if arg == "--profile" {
-			hasProfile = true
-		}
-		args = append(args, arg)
+	if logPath, err = filepath.Abs(logPath); err != nil {
+		return fmt.Errorf("invalid log path: %w", err)
 	}
+
 	// Always inject --profile so the daemon uses the correct profile
 	// even if the active profile changes later
-	if !hasProfile {
-		args = append(args, "--profile", activeProfile)
+	args := []string{execPath, "--profile", activeProfile}
+	if serverOverride != "" {
+		args = append(args, "--server", serverOverride)
+	}
+	args = append(args, "watch", syncDir)
+	for i := 2; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case arg == "start" || arg == "--daemon" || arg == "-d" || arg == "--background":
+			continue
+		case arg == "--log" || arg == "--pidfile":
+			i++ // replaced by the absolute path below
+		case watchValueFlags[arg]:
+			if i+1 < len(os.Args) {
+				args = append(args, arg, os.Args[i+1])
+				i++
+			}
+		case strings.HasPrefix(arg, "-"):
+			args = append(args, arg)
+		}
+		// Positional args (the directory) were resolved above
 	}
 	args = append(args, "--log", logPath)
+	if pidFileOverride != "" {
+		args = append(args, "--pidfile", pidFileOverride)
+	}
 
 	// Open log file for the child
 	os.MkdirAll(filepath.Dir(logPath), 0755)
@@ -1062,16 +3618,29 @@ func daemonize(logPath string) error {
 	if err != nil {
 		return err
 	}
+	defer logFile.Close()
+
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	// Run from a directory that won't disappear out from under the daemon
+	workDir, err := os.UserHomeDir()
+	if err != nil {
+		workDir = string(filepath.Separator)
+	}
 
 	attr := &os.ProcAttr{
-		Dir:   ".",
+		Dir:   workDir,
 		Env:   os.Environ(),
-		Files: []*os.File{os.Stdin, logFile, logFile},
+		Files: []*os.File{devNull, logFile, logFile},
+		Sys:   detachedProcAttr(),
 	}
 
 	proc, err := os.StartProcess(execPath, args, attr)
 	if err != nil {
-		logFile.Close()
 		return fmt.Errorf("could not start daemon: %w", err)
 	}
 
@@ -1080,10 +3649,19 @@ func daemonize(logPath string) error {
 	fmt.Printf("   Stop: izerop watch --stop\n")
 
 	proc.Release()
-	logFile.Close()
 	return nil
 }
 
+// watcherUptime reports how long a profile's watcher has been running, based
+// on when it wrote its PID file.
+func watcherUptime(profile string) (time.Duration, bool) {
+	info, err := os.Stat(profilePIDPath(profile))
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(info.ModTime()).Truncate(time.Second), true
+}
+
 func openLogFile(path string) (*os.File, error) {
 	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 }
@@ -1092,12 +3670,32 @@ func defaultLogPath() string {
 	return profileLogPath(activeProfile)
 }
 
+// pidFileOverride is set by --pidfile to use a PID file outside the profile dir.
+var pidFileOverride string
+
+// setPIDFileOverride records a --pidfile path, resolved to absolute.
+func setPIDFileOverride(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	pidFileOverride = path
+}
+
 func pidFilePath() string {
+	if pidFileOverride != "" {
+		return pidFileOverride
+	}
 	return profilePIDPath(activeProfile)
 }
 
 func watchArgsPath() string {
-	dir, _ := config.ProfileDir(activeProfile)
+	return profileWatchArgsPath(activeProfile)
+}
+
+// profileWatchArgsPath is where a profile's running watcher saves the
+// arguments it was started with, so it can be relaunched the same way.
+func profileWatchArgsPath(profile string) string {
+	dir, _ := config.ProfileDir(profile)
 	return filepath.Join(dir, "watch.args.json")
 }
 
@@ -1121,10 +3719,16 @@ func profilePIDPath(profile string) string {
 
 func cmdWatchStop() {
 	// If --all flag, stop all profile watchers
-	for _, arg := range os.Args[2:] {
-		if arg == "--all" {
+	for i := 2; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--all":
 			stopAllWatchers()
 			return
+		case "--pidfile":
+			if i+1 < len(os.Args) {
+				setPIDFileOverride(os.Args[i+1])
+				i++
+			}
 		}
 	}
 
@@ -1159,6 +3763,147 @@ func cmdWatchStop() {
 	fmt.Printf("⏹ Stopped watcher for %q (PID %d)\n", activeProfile, pid)
 }
 
+// signalWatcher sends a pause/resume signal to the profile's running watcher.
+func signalWatcher(sig os.Signal, done string) {
+	for i := 3; i < len(os.Args); i++ {
+		if os.Args[i] == "--pidfile" && i+1 < len(os.Args) {
+			setPIDFileOverride(os.Args[i+1])
+			i++
+		}
+	}
+
+	if sig == nil {
+		fmt.Fprintf(os.Stderr, "Pausing a running watcher is not supported on %s\n", runtime.GOOS)
+		os.Exit(1)
+	}
+
+	running, pid := watcherStatusAt(pidFilePath())
+	if !running {
+		fmt.Fprintf(os.Stderr, "No running watcher found for profile %q\n", activeProfile)
+		os.Exit(1)
+	}
+	proc, err := os.FindProcess(pid)
+	if err == nil {
+		err = proc.Signal(sig)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not signal process %d: %v\n", pid, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s watcher for %q (PID %d)\n", done, activeProfile, pid)
+}
+
+// cmdWatchRestart stops the profile's watcher (every running profile's with
+// --all), waits for it to exit, and starts it again as a daemon with the
+// arguments it was started with, so config changes apply without retyping
+// flags like --interval and --log.
+func cmdWatchRestart() {
+	all := false
+	for i := 3; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--all":
+			all = true
+		case "--pidfile":
+			if i+1 < len(os.Args) {
+				setPIDFileOverride(os.Args[i+1])
+				i++
+			}
+		}
+	}
+
+	if !all {
+		running, pid := watcherStatusAt(pidFilePath())
+		if !running {
+			fmt.Fprintf(os.Stderr, "No running watcher found for profile %q\n", activeProfile)
+			os.Exit(1)
+		}
+		if err := restartWatcher(activeProfile, pidFilePath(), pid); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	profiles, _ := config.ListProfiles()
+	restarted, failed := 0, 0
+	for _, name := range profiles {
+		running, pid := getWatcherStatusForProfile(name)
+		if !running {
+			continue
+		}
+		if err := restartWatcher(name, profilePIDPath(name), pid); err != nil {
+			fmt.Fprintf(os.Stderr, "  ✗ %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		restarted++
+	}
+	if restarted == 0 && failed == 0 {
+		fmt.Println("No running watchers found.")
+		return
+	}
+	fmt.Printf("\n🔄 Restarted %d, failed %d\n", restarted, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// restartWatcher stops a profile's running watcher, waits for it to exit,
+// and relaunches it as a daemon with its saved arguments.
+func restartWatcher(profile, pidPath string, pid int) error {
+	// Read the arguments first: the watcher removes the file as it exits
+	args := savedWatchArgs(profile)
+
+	fmt.Printf("🔄 Restarting watcher for %q (PID %d)...\n", profile, pid)
+	if err := stopWatcherPID(pid); err != nil {
+		return fmt.Errorf("could not stop watcher (PID %d): %w", pid, err)
+	}
+	os.Remove(pidPath)
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find executable path: %w", err)
+	}
+	cmd := exec.Command(execPath, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("watcher stopped but could not be started again: %w (run 'izerop --profile %s watch start')", err, profile)
+	}
+	return nil
+}
+
+// savedWatchArgs returns the command line that relaunches a profile's
+// watcher as a daemon: the arguments it saved in watch.args.json, or a
+// plain 'watch --daemon' if there are none.
+func savedWatchArgs(profile string) []string {
+	var saved []string
+	if data, err := os.ReadFile(profileWatchArgsPath(profile)); err == nil {
+		json.Unmarshal(data, &saved)
+	}
+	if len(saved) == 0 {
+		saved = []string{"watch"}
+	}
+
+	hasProfile, hasDaemon := false, false
+	for _, a := range saved {
+		switch a {
+		case "--profile":
+			hasProfile = true
+		case "--daemon", "-d", "--background":
+			hasDaemon = true
+		}
+	}
+	// A foreground watcher may have relied on the active profile
+	if !hasProfile {
+		saved = append([]string{"--profile", profile}, saved...)
+	}
+	if !hasDaemon {
+		saved = append(saved, "--daemon")
+	}
+	return saved
+}
+
 func stopAllWatchers() {
 	profiles, _ := config.ListProfiles()
 	stopped := 0
@@ -1240,8 +3985,8 @@ func cmdWatchStatus() {
 
 		if running {
 			uptime := ""
-			if statInfo, err := os.Stat(fmt.Sprintf("/proc/%d", pid)); err == nil {
-				uptime = fmt.Sprintf(", uptime %s", time.Since(statInfo.ModTime()).Truncate(time.Second))
+			if d, ok := watcherUptime(name); ok {
+				uptime = fmt.Sprintf(", uptime %s", d)
 			}
 			fmt.Printf("  ✅ %-15s  PID %d%s  %s\n", name, pid, uptime, syncDir)
 		} else {
@@ -1249,61 +3994,221 @@ func cmdWatchStatus() {
 			if syncDir == "" {
 				status = "⏭ no sync dir"
 			}
-			fmt.Printf("  %s %-15s  %s\n", status, name, syncDir)
+			fmt.Printf("  %s %-15s  %s\n", status, name, syncDir)
+		}
+	}
+}
+
+func cmdClient(cfg *config.Config) {
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "Not logged in. Run 'izerop login' first.\n")
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	clientKey := cfg.EnsureClientKey(activeProfile)
+
+	if len(os.Args) < 3 {
+		// Show current client info
+		info, err := client.RegisterClient(runCtx, clientKey, cfg.ClientName, config.Platform(), version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Client Key:  %s\n", info.ClientKey)
+		fmt.Printf("Name:        %s\n", info.Name)
+		fmt.Printf("Platform:    %s\n", info.Platform)
+		fmt.Printf("Version:     %s\n", info.Version)
+		fmt.Printf("Last Seen:   %s\n", formatTime(info.LastSeenAt))
+		return
+	}
+
+	switch os.Args[2] {
+	case "name":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "Usage: izerop client name <name>\n")
+			os.Exit(1)
+		}
+		name := strings.Join(os.Args[3:], " ")
+		cfg.ClientName = name
+		config.UpdateProfile(activeProfile, func(stored *config.Config) { stored.ClientName = name })
+
+		info, err := client.RegisterClient(runCtx, clientKey, name, config.Platform(), version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating server: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Client named %q\n", info.Name)
+	case "register":
+		info, err := client.RegisterClient(runCtx, clientKey, cfg.ClientName, config.Platform(), version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Client registered: %s (%s)\n", info.Name, info.ClientKey)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown client command: %s\n", os.Args[2])
+		fmt.Fprintf(os.Stderr, "Usage: izerop client [name <name>|register]\n")
+		os.Exit(1)
+	}
+}
+
+func cmdClients(cfg *config.Config) {
+	// Usage: izerop clients [list|rm <key>]
+	if cfg == nil {
+		fmt.Fprintf(os.Stderr, "Not logged in. Run 'izerop login' first.\n")
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	requireFeature(client, api.FeatureClients, "Client management is")
+	currentKey := cfg.EnsureClientKey(activeProfile)
+
+	sub := "list"
+	if len(os.Args) > 2 {
+		sub = os.Args[2]
+	}
+
+	switch sub {
+	case "list", "ls":
+		clients, err := client.ListClients(runCtx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing clients: %v\n", err)
+			os.Exit(1)
+		}
+		if len(clients) == 0 {
+			fmt.Println("No clients registered.")
+			return
+		}
+		for _, c := range clients {
+			marker := "  "
+			if c.ClientKey == currentKey {
+				marker = "★ "
+			}
+			name := c.Name
+			if name == "" {
+				name = "(unnamed)"
+			}
+			fmt.Printf("%s%-24s  %-14s  %-10s  %-25s  %s\n", marker, name, c.Platform, c.Version, formatTime(c.LastSeenAt), c.ClientKey)
+		}
+	case "rm", "remove", "revoke":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "Usage: izerop clients rm <client-key>\n")
+			os.Exit(1)
+		}
+		key := os.Args[3]
+		if key == currentKey {
+			fmt.Fprintf(os.Stderr, "⚠ Revoking the current client; it will re-register on next sync.\n")
+		}
+		if err := client.DeleteClient(runCtx, key); err != nil {
+			fmt.Fprintf(os.Stderr, "Revoke failed: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("🗑 Client revoked: %s\n", key)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown clients command: %s\n", sub)
+		fmt.Fprintf(os.Stderr, "Usage: izerop clients [list|rm <key>]\n")
+		os.Exit(1)
 	}
 }
 
-func cmdClient(cfg *config.Config) {
-	if cfg == nil {
+// whoamiInfo is the --json output of whoami.
+type whoamiInfo struct {
+	Profile    string       `json:"profile"`
+	Server     string       `json:"server"`
+	ClientKey  string       `json:"client_key"`
+	ClientName string       `json:"client_name,omitempty"`
+	Account    *api.Account `json:"account,omitempty"`
+	Valid      bool         `json:"valid"`
+	Error      string       `json:"error,omitempty"`
+}
+
+func cmdWhoami(cfg *config.Config) {
+	// Usage: izerop whoami [--json]
+	asJSON := len(os.Args) > 2 && os.Args[2] == "--json"
+	if cfg == nil || cfg.Token == "" {
 		fmt.Fprintf(os.Stderr, "Not logged in. Run 'izerop login' first.\n")
 		os.Exit(1)
 	}
 
 	client := newClient(cfg)
-	clientKey := cfg.EnsureClientKey(activeProfile)
+	info := whoamiInfo{
+		Profile:    activeProfile,
+		Server:     cfg.ServerURL,
+		ClientKey:  cfg.EnsureClientKey(activeProfile),
+		ClientName: cfg.ClientName,
+	}
 
-	if len(os.Args) < 3 {
-		// Show current client info
-		info, err := client.RegisterClient(clientKey, cfg.ClientName, config.Platform(), version)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+	// Prefer the identity endpoint; fall back to any authenticated call to
+	// at least validate the token
+	account, err := client.GetAccount(runCtx)
+	if err == nil && account == nil {
+		_, err = client.GetSyncStatus(runCtx)
+	}
+	if err != nil {
+		info.Error = err.Error()
+	} else {
+		info.Valid = true
+		info.Account = account
+	}
+
+	if asJSON {
+		data, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("Profile:     %s\n", info.Profile)
+		fmt.Printf("Server:      %s\n", info.Server)
+		if info.Account != nil {
+			if info.Account.Email != "" {
+				fmt.Printf("Account:     %s\n", info.Account.Email)
+			}
+			if info.Account.Name != "" {
+				fmt.Printf("Name:        %s\n", info.Account.Name)
+			}
 		}
 		fmt.Printf("Client Key:  %s\n", info.ClientKey)
-		fmt.Printf("Name:        %s\n", info.Name)
-		fmt.Printf("Platform:    %s\n", info.Platform)
-		fmt.Printf("Version:     %s\n", info.Version)
-		fmt.Printf("Last Seen:   %s\n", info.LastSeenAt)
+		if info.ClientName != "" {
+			fmt.Printf("Client Name: %s\n", info.ClientName)
+		}
+		switch {
+		case info.Valid:
+			fmt.Printf("Token:       ✅ valid\n")
+		case errors.Is(err, api.ErrUnauthorized):
+			fmt.Printf("Token:       ❌ invalid or expired — run 'izerop login'\n")
+		default:
+			fmt.Printf("Token:       ⚠ could not verify (%v)\n", err)
+		}
+	}
+
+	if !info.Valid {
+		os.Exit(1)
+	}
+}
+
+func cmdState() {
+	// Usage: izerop state reset
+	if len(os.Args) < 3 {
+		printCommandHelp("state")
 		return
 	}
 
 	switch os.Args[2] {
-	case "name":
-		if len(os.Args) < 4 {
-			fmt.Fprintf(os.Stderr, "Usage: izerop client name <name>\n")
-			os.Exit(1)
-		}
-		name := strings.Join(os.Args[3:], " ")
-		cfg.ClientName = name
-		config.SaveProfile(activeProfile, cfg)
-
-		info, err := client.RegisterClient(clientKey, name, config.Platform(), version)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error updating server: %v\n", err)
+	case "reset":
+		if running, pid := getWatcherStatusForProfile(activeProfile); running {
+			fmt.Fprintf(os.Stderr, "⚠ Watcher is running for profile %q (PID %d); it would rewrite the state.\n", activeProfile, pid)
+			fmt.Fprintf(os.Stderr, "   Stop it first: izerop --profile %s watch --stop\n", activeProfile)
 			os.Exit(1)
 		}
-		fmt.Printf("✅ Client named %q\n", info.Name)
-	case "register":
-		info, err := client.RegisterClient(clientKey, cfg.ClientName, config.Platform(), version)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err := sync.ResetState(activeProfile); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not reset state: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("✅ Client registered: %s (%s)\n", info.Name, info.ClientKey)
+		fmt.Printf("🗑 Sync state reset for %q — the next sync starts fresh\n", activeProfile)
+	case "help", "--help", "-h":
+		printCommandHelp("state")
 	default:
-		fmt.Fprintf(os.Stderr, "Unknown client command: %s\n", os.Args[2])
-		fmt.Fprintf(os.Stderr, "Usage: izerop client [name <name>|register]\n")
+		fmt.Fprintf(os.Stderr, "Unknown state command: %s\n", os.Args[2])
+		fmt.Fprintf(os.Stderr, "Usage: izerop state reset\n")
 		os.Exit(1)
 	}
 }
@@ -1324,9 +4229,11 @@ func cmdProfile() {
 		cmdProfileRemove()
 	case "use", "switch":
 		cmdProfileUse()
+	case "rename", "mv":
+		cmdProfileRename(os.Args[3:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown profile command: %s\n", os.Args[2])
-		fmt.Fprintf(os.Stderr, "Usage: izerop profile [list|add|remove|use]\n")
+		fmt.Fprintf(os.Stderr, "Usage: izerop profile [list|add|remove|use|rename]\n")
 		os.Exit(1)
 	}
 }
@@ -1429,6 +4336,64 @@ func cmdProfileRemove() {
 	}
 }
 
+// cmdProfileRename renames a profile, keeping its sync state, logs, and
+// client key. A running watcher is stopped first, since its PID file and
+// state move with the profile directory.
+func cmdProfileRename(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: izerop profile rename <old> <new>\n")
+		os.Exit(1)
+	}
+	oldName, newName := args[0], args[1]
+
+	if path, err := servicePath(oldName); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			fmt.Fprintf(os.Stderr, "Profile %q has an OS service installed, which would keep using the old name.\n", oldName)
+			fmt.Fprintf(os.Stderr, "Uninstall it first: izerop --profile %s service uninstall\n", oldName)
+			os.Exit(1)
+		}
+	}
+
+	restart := false
+	if running, pid := getWatcherStatusForProfile(oldName); running {
+		if err := stopWatcherPID(pid); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not stop the watcher for %q (PID %d): %v\n", oldName, pid, err)
+			os.Exit(1)
+		}
+		fmt.Printf("⏹ Stopped watcher for %q (PID %d)\n", oldName, pid)
+		restart = true
+	}
+
+	if err := config.RenameProfile(oldName, newName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✏️ Profile %q renamed to %q\n", oldName, newName)
+	if restart {
+		fmt.Printf("   Restart the watcher: izerop --profile %s watch --daemon\n", newName)
+	}
+}
+
+// stopWatcherPID asks the watcher with the given PID to exit and waits for
+// it to remove its PID file, so nothing writes to the profile afterwards.
+func stopWatcherPID(pid int) error {
+	proc, err := os.FindProcess(pid)
+	if err == nil {
+		err = proc.Signal(syscall.SIGTERM)
+	}
+	if err != nil {
+		return err
+	}
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		if err := proc.Signal(syscall.Signal(0)); err != nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("still running after 10s")
+}
+
 func cmdProfileUse() {
 	if len(os.Args) < 4 {
 		fmt.Fprintf(os.Stderr, "Usage: izerop profile use <name>\n")
@@ -1451,10 +4416,11 @@ func cmdProfileUse() {
 }
 
 func cmdLogs() {
-	// Usage: izerop logs [--tail <n>] [--follow] [--profile <name>]
+	// Usage: izerop logs [--tail <n>] [--follow] [--json] [--profile <name>]
 	logPath := defaultLogPath()
 	tail := 50
 	follow := false
+	asJSON := false
 
 	for i := 2; i < len(os.Args); i++ {
 		switch os.Args[i] {
@@ -1468,6 +4434,8 @@ func cmdLogs() {
 			}
 		case "--follow", "-f":
 			follow = true
+		case "--json":
+			asJSON = true
 		case "--path":
 			if i+1 < len(os.Args) {
 				logPath = os.Args[i+1]
@@ -1481,28 +4449,69 @@ func cmdLogs() {
 		os.Exit(1)
 	}
 
+	args := []string{"-n", strconv.Itoa(tail), logPath}
 	if follow {
-		args := []string{"-n", strconv.Itoa(tail), "-f", logPath}
-		proc := exec.Command("tail", args...)
-		proc.Stdout = os.Stdout
-		proc.Stderr = os.Stderr
+		args = []string{"-n", strconv.Itoa(tail), "-f", logPath}
+	}
+	proc := exec.Command("tail", args...)
+	proc.Stderr = os.Stderr
+	out, err := proc.StdoutPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read log: %v\n", err)
+		os.Exit(1)
+	}
+	if err := proc.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read log: %v\n", err)
+		os.Exit(1)
+	}
 
+	if follow {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		go func() {
 			<-sigCh
-			if proc.Process != nil {
-				proc.Process.Kill()
-			}
+			proc.Process.Kill()
 		}()
+	}
 
-		proc.Run()
-	} else {
-		args := []string{"-n", strconv.Itoa(tail), logPath}
-		proc := exec.Command("tail", args...)
-		proc.Stdout = os.Stdout
-		proc.Stderr = os.Stderr
-		proc.Run()
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		fmt.Println(formatLogLine(scanner.Text(), asJSON))
+	}
+	proc.Wait()
+}
+
+// formatLogLine renders one watcher log line. JSON lines (--log-format json)
+// are shown in the same "date time message" layout as text logs, or passed
+// through unchanged with asJSON. With asJSON, text lines are converted to
+// JSON so the output is uniform either way.
+func formatLogLine(line string, asJSON bool) string {
+	var entry watcher.LogLine
+	isJSON := strings.HasPrefix(line, "{") && json.Unmarshal([]byte(line), &entry) == nil
+
+	switch {
+	case isJSON && asJSON:
+		return line
+	case isJSON:
+		ts := entry.TS
+		if t, err := time.Parse(time.RFC3339, entry.TS); err == nil {
+			ts = t.Local().Format("2006/01/02 15:04:05")
+		}
+		return ts + " " + entry.Msg
+	case asJSON:
+		// Text lines start with log.LstdFlags' "2006/01/02 15:04:05 "
+		entry = watcher.LogLine{Msg: line}
+		if len(line) > 20 {
+			if t, err := time.ParseInLocation("2006/01/02 15:04:05", line[:19], time.Local); err == nil {
+				entry.TS = t.UTC().Format(time.RFC3339)
+				entry.Msg = line[20:]
+			}
+		}
+		entry.Level = watcher.LogLevel(entry.Msg)
+		data, _ := json.Marshal(entry)
+		return string(data)
+	default:
+		return line
 	}
 }
 
@@ -1530,9 +4539,18 @@ func cmdUpdate() {
 		os.Exit(1)
 	}
 
+	checksum, err := updater.ExpectedChecksum(release, asset)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not verify release: %v\n", err)
+		os.Exit(1)
+	}
+
 	fmt.Printf("Downloading %s (%s)...\n", asset.Name, formatSize(asset.Size))
 
-	if err := updater.DownloadAndReplace(asset); err != nil {
+	if err := updater.DownloadAndReplace(asset, checksum); err != nil {
+		if errors.Is(err, updater.ErrChecksumMismatch) {
+			fmt.Fprintf(os.Stderr, "⚠ Downloaded binary failed verification; current version left in place.\n")
+		}
 		fmt.Fprintf(os.Stderr, "Update failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -1540,49 +4558,151 @@ func cmdUpdate() {
 	fmt.Printf("✅ Updated to %s!\n", release.TagName)
 
 	// Restart daemon if running
-	pidPath := pidFilePath()
-	if data, err := os.ReadFile(pidPath); err == nil {
-		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
-		if err == nil {
-			if proc, err := os.FindProcess(pid); err == nil {
-				if err := proc.Signal(syscall.Signal(0)); err == nil {
-					// Daemon is running — stop it
-					fmt.Printf("Restarting watcher daemon (PID %d)...\n", pid)
-					proc.Signal(syscall.SIGTERM)
-					// Wait briefly for it to stop
-					time.Sleep(1 * time.Second)
-					os.Remove(pidPath)
-
-					// Re-launch with saved watch args
-					execPath, _ := os.Executable()
-					watchArgs := []string{"watch", "--daemon"}
-					if argsData, err := os.ReadFile(watchArgsPath()); err == nil {
-						var savedArgs []string
-						if json.Unmarshal(argsData, &savedArgs) == nil && len(savedArgs) > 0 {
-							// Ensure --daemon is present
-							hasDaemon := false
-							for _, a := range savedArgs {
-								if a == "--daemon" || a == "-d" || a == "--background" {
-									hasDaemon = true
-								}
-							}
-							if !hasDaemon {
-								savedArgs = append(savedArgs, "--daemon")
-							}
-							watchArgs = savedArgs
-						}
-					}
-					newProc := exec.Command(execPath, watchArgs...)
-					newProc.Stdout = os.Stdout
-					newProc.Stderr = os.Stderr
-					if err := newProc.Run(); err != nil {
-						fmt.Fprintf(os.Stderr, "⚠ Could not restart daemon: %v\n", err)
-						fmt.Fprintf(os.Stderr, "  Start manually: izerop watch <dir> --daemon\n")
-					}
-				}
-			}
+	if running, pid := watcherStatusAt(pidFilePath()); running {
+		if err := restartWatcher(activeProfile, pidFilePath(), pid); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Could not restart daemon: %v\n", err)
+		}
+	}
+}
+
+// printProgress redraws a single-line aggregate progress bar.
+func printProgress(p sync.Progress) {
+	const width = 30
+	filled := width
+	if p.FilesTotal > 0 {
+		filled = p.FilesDone * width / p.FilesTotal
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	fmt.Printf("\r  [%s] %d/%d files  %s / %s ", bar, p.FilesDone, p.FilesTotal,
+		formatSize(p.BytesDone), formatSize(p.BytesTotal))
+}
+
+// largeTransferSize is the smallest file that gets a transfer progress line
+// during sync and reconcile; smaller files finish too quickly to matter.
+const largeTransferSize = 1024 * 1024
+
+// transferProgress draws a single self-overwriting line on stderr with bytes
+// transferred and the rate, so stdout stays clean for scripting.
+type transferProgress struct {
+	minSize int64
+	start   time.Time
+	last    time.Time
+	shown   bool
+}
+
+// trackTransfers hooks a progress line into the client's uploads and
+// downloads when --progress is given, or by default when stderr is a
+// terminal (--no-progress turns it off). Transfers smaller than minSize
+// are not shown. The returned printer is nil when progress is off.
+func trackTransfers(client *api.Client, minSize int64) *transferProgress {
+	enabled := stderrIsTerminal()
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--progress":
+			enabled = true
+		case "--no-progress":
+			enabled = false
 		}
 	}
+	if !enabled {
+		return nil
+	}
+	t := &transferProgress{minSize: minSize}
+	client.OnTransfer = t.report
+	return t
+}
+
+func (t *transferProgress) report(done, total int64) {
+	if total >= 0 && total < t.minSize {
+		return
+	}
+	now := time.Now()
+	if t.start.IsZero() {
+		t.start = now
+	}
+	if total >= 0 && done >= total {
+		t.clear()
+		return
+	}
+	// Redraw at most a few times a second
+	if now.Sub(t.last) < 200*time.Millisecond {
+		return
+	}
+	t.last = now
+
+	rate := ""
+	if elapsed := now.Sub(t.start).Seconds(); elapsed > 0 {
+		rate = formatSize(int64(float64(done)/elapsed)) + "/s"
+	}
+	if total > 0 {
+		const width = 30
+		filled := int(done * width / total)
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+		fmt.Fprintf(os.Stderr, "\r\033[K  [%s] %s / %s  %s", bar, formatSize(done), formatSize(total), rate)
+	} else {
+		fmt.Fprintf(os.Stderr, "\r\033[K  %s  %s", formatSize(done), rate)
+	}
+	t.shown = true
+}
+
+// clear erases the progress line and resets timing for the next transfer.
+// Safe to call on a nil printer.
+func (t *transferProgress) clear() {
+	if t == nil {
+		return
+	}
+	if t.shown {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	t.start, t.last, t.shown = time.Time{}, time.Time{}, false
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stderrIsTerminal reports whether stderr is an interactive terminal.
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// timeUTC makes formatTime show UTC instead of the local time zone (--utc).
+var timeUTC bool
+
+// defaultTimeLayout is how timestamps are shown unless time_format says
+// otherwise.
+const defaultTimeLayout = "2006-01-02 15:04"
+
+// timeLayout is the layout formatTime uses, from time_format.
+var timeLayout = defaultTimeLayout
+
+// timeFormatLayout turns a time_format setting into a Go time layout:
+// "rfc3339" for full timestamps, or a layout written as the reference
+// time, like "2006-01-02 15:04" or "Jan 2 3:04PM".
+func timeFormatLayout(format string) string {
+	if strings.EqualFold(format, "rfc3339") {
+		return time.RFC3339
+	}
+	return format
+}
+
+// formatTime shows a server timestamp (RFC 3339, usually UTC) in the local
+// time zone, or in UTC with --utc, using time_format. Anything that doesn't
+// parse is shown as is.
+func formatTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	if timeUTC {
+		t = t.UTC()
+	} else {
+		t = t.Local()
+	}
+	return t.Format(timeLayout)
 }
 
 func formatSize(bytes int64) string {
@@ -1605,21 +4725,38 @@ func formatSize(bytes int64) string {
 
 func printCommandHelp(cmd string) {
 	help := map[string]string{
-		"login": `izerop login
+		"login": `izerop login [--keyring]
 
   Authenticate with an izerop server. Prompts for server URL and API token.
   Config is saved to ~/.config/izerop/config.json.
 
+  Options:
+    --keyring      Store the token in the OS keychain (macOS Keychain,
+                   Secret Service via secret-tool, or Windows Credential
+                   Manager) instead of config.json.
+                   Falls back to plaintext with a warning if unavailable.
+
   Examples:
     izerop login
+    izerop login --keyring
     izerop --server http://localhost:3000 login`,
 
-		"status": `izerop status
+		"status": `izerop status [options]
 
   Show server connection, file/directory counts, storage usage, and sync cursor.
 
+  Options:
+    --check        Compare the server manifest with local sync state and report
+                   drift (read-only; nothing is downloaded or changed)
+    -v, --verbose  List each file failing to upload and, with --check, each
+                   drifted path
+    -w, --watch    Redraw the status every few seconds until Ctrl+C
+    --refresh <n>  With --watch, redraw every n seconds (default 5)
+
   Examples:
     izerop status
+    izerop status --check -v
+    izerop status --watch --refresh 2
     izerop --server http://localhost:3000 status`,
 
 		"sync": `izerop sync [<directory>] [options]
@@ -1628,9 +4765,60 @@ func printCommandHelp(cmd string) {
   Downloads remote changes first, then uploads local changes.
 
   Options:
+    --map <local>:<remote-path>
+                   Sync local dir with an arbitrary remote directory (e.g.
+                   ~/notes:/work/notes) instead of /root; paths outside it
+                   are left alone. The sync state remembers the remote
+                   root, so use a separate profile for each mapping
+    --root <remote-dir>
+                   Sync with this remote directory (e.g. work) instead of
+                   /root (default: sync_root from config)
+    -n, --dry-run  Show what would be downloaded, uploaded, deleted, or
+                   conflict without changing any files or the sync state
+    --dump-plan    Print every planned action (phase, path, action, reason,
+                   size, remote_id) as a JSON array, without executing any
+    --out <file>   With --dump-plan, write the JSON to a file instead
     --pull-only    Only download remote changes
     --push-only    Only upload local changes
-    -v, --verbose  Show detailed output
+    --only-new     Add-only upload: create files the server doesn't have and
+                   never update, move, or delete anything (implies --push-only)
+    --no-text-api  Upload every new file as a binary, never as a text note
+                   (default: no_text_api from config)
+    --progress     Show an aggregate progress bar instead of per-file output
+    --no-delete    Only add and update files; don't sync deletions either way
+                   (default: no_delete from config)
+    --max-size <size>
+                   Don't upload files larger than this, e.g. 500MB
+                   (default: max_file_size from config)
+    --skip-growing Skip files that are still being written (e.g. a log being
+                   appended to) instead of uploading a partial copy
+                   (default: skip_growing from config)
+    --exclude-vcs  Also ignore .git, .svn, .hg, node_modules, __pycache__,
+                   and similar VCS/dependency dirs (default: exclude_vcs
+                   from config). A "!" line in .izeropignore re-includes one.
+    --exclude <glob>
+                   Ignore matching paths for this run only (repeatable)
+    --include <glob>
+                   Sync matching paths for this run even if ignored
+                   (repeatable). Both take precedence over .izeropignore
+                   and apply in the order given.
+    --checkpoint-every N
+                   Save sync state after every N files so an interrupted
+                   sync doesn't re-examine files it already finished
+    --force        Push even if most tracked files are missing on the server
+    --confirm-first-sync
+                   Skip the confirmation a sync with no saved state asks for
+                   when both the directory and the server already have files
+    --report-conflicts-only
+                   Sync as usual but print nothing unless files conflicted;
+                   then list their paths and exit 1 (for cron jobs)
+    --watch-once   Pull, then watch the directory until it has been quiet
+                   for the settle time (settle_time_ms) so in-flight saves
+                   finish, push, and exit. For scripts that don't want a
+                   running watcher; exits 1 if the pull or push failed
+    -v, --verbose  Show detailed output, with a progress line on stderr for
+                   transfers of 1 MB or more (when stderr is a terminal)
+    --no-progress  Don't show per-transfer progress with --verbose
 
   Ignore patterns:
     Create a .izeropignore file in the sync directory to skip files/dirs.
@@ -1642,11 +4830,28 @@ func printCommandHelp(cmd string) {
       secret.env      # skip specific file
       !important.log  # un-ignore a file
 
+    Start .izeropignore with "# mode: include" to sync only what it lists:
+      # mode: include
+      *.md            # sync Markdown files
+      assets/         # and everything under assets/
+      !drafts/        # except the drafts directory
+
+    Patterns in ~/.config/izerop/ignore apply to every sync directory, and
+    a profile's "default_ignore" list in config.json to each of its dirs.
+    An "ignore" list in a project's .izerop/config applies in between.
+    Local .izeropignore rules take precedence over global ones.
+
   Examples:
     izerop sync                    # sync current directory
     izerop sync ~/izerop           # sync a specific directory
     izerop sync --pull-only        # download only
-    izerop sync ~/izerop -v        # verbose output`,
+    izerop sync --dry-run          # preview a sync
+    izerop sync --dump-plan | jq '.[] | select(.action != "skipped")'
+    izerop sync ~/izerop -v        # verbose output
+    izerop sync --checkpoint-every 100   # long sync, save state as it goes
+    izerop sync --report-conflicts-only  # cron: quiet unless conflicts
+    izerop sync --exclude 'build/**'     # skip build output this once
+    izerop --profile work sync --map ~/notes:/work/notes`,
 
 		"watch": `izerop watch <subcommand|directory> [options]
 
@@ -1659,13 +4864,47 @@ func printCommandHelp(cmd string) {
   Subcommands:
     start [--all]    Start watcher daemon (all profiles with --all)
     stop [--all]     Stop watcher daemon (all profiles with --all)
+    restart [--all]  Stop the running daemon and start it again with the
+                     same flags, e.g. to apply a config change (all running
+                     profiles with --all)
     status           Show watcher status for all profiles
+    pause            Pause syncing without stopping the daemon (keeps
+                     cursor and file watches; changes sync on resume)
+    resume           Resume a paused daemon and run one full sync
     help             Show this help
 
   Options (for direct watch):
-    --interval N   Server poll interval in seconds (default: 30)
+    --root <remote-dir>
+                   Sync with this remote directory instead of /root
+                   (default: sync_root from config)
+    --confirm-first-sync
+                   Start even if there is no sync state yet and both sides
+                   already have files (see 'izerop help sync')
+    --interval N   Server poll interval in seconds (default: poll_interval_s
+                   from config, or 30)
+    --reconcile-interval N
+                   Run a full manifest reconcile every N seconds to catch
+                   changes the cursor missed (default: off, or
+                   reconcile_interval_s from config)
+    --quiet-hours HH:MM-HH:MM
+                   Defer large binary uploads and scheduled reconciles during
+                   this daily window (default: quiet_hours from config)
+    --no-delete    Don't sync deletions either way (default: no_delete from config)
+    --skip-growing Hold back files that are still being written until they
+                   stop changing (default: skip_growing from config)
+    --exclude-vcs  Ignore VCS/dependency dirs like .git and node_modules
+                   (default: exclude_vcs from config)
     -d, --daemon   Run in background (writes PID file)
     --log <path>   Log file path (default: ~/.config/izerop/profiles/<name>/watch.log)
+    --log-format text|json
+                   Log line format. json emits {ts, level, msg, profile}
+                   objects for log aggregators, plus event, path, and
+                   counts on per-file and summary lines (default:
+                   log_format from config, else text)
+    --json-log     Same as --log-format json
+    --pidfile <path>
+                   PID file path (default: ~/.config/izerop/profiles/<name>/watch.pid).
+                   Pass the same flag to 'watch stop' or 'watch restart'.
     -v, --verbose  Log every poll tick, not just changes
 
   Examples:
@@ -1677,6 +4916,8 @@ func printCommandHelp(cmd string) {
     izerop watch start --all              # start daemons for all profiles
     izerop watch stop                     # stop current profile watcher
     izerop watch stop --all               # stop all watchers
+    izerop watch restart                  # restart with the same flags
+    izerop watch restart --all            # restart every running watcher
     izerop watch status                   # show all watcher statuses
 
   Multi-profile:
@@ -1684,6 +4925,23 @@ func printCommandHelp(cmd string) {
     izerop --profile ranger watch start        # start ranger watcher
     izerop --profile ranger watch stop         # stop ranger only`,
 
+		"service": `izerop service <subcommand>
+
+  Let the OS supervise the watcher instead of 'watch --daemon'. Installs a
+  systemd user unit (Linux) or launchd agent (macOS) for the active profile's
+  sync directory, so the watcher starts at login and restarts on crash.
+
+  Subcommands:
+    install      Write and enable the service for the active profile
+    uninstall    Stop, disable, and remove the service
+    status       Show the service manager's status
+
+  Examples:
+    izerop service install
+    izerop --profile ranger service install
+    izerop service status
+    izerop service uninstall`,
+
 		"client": `izerop client [subcommand]
 
   View or name this sync client. Each device gets a unique key on first use.
@@ -1700,6 +4958,99 @@ func printCommandHelp(cmd string) {
     izerop client name "Patrick's Laptop"  # name this device
     izerop client name "Work Desktop"      # rename it`,
 
+		"clients": `izerop clients [subcommand]
+
+  Manage all devices syncing this account. The current device is marked with ★.
+
+  Subcommands:
+    list            List clients with name, platform, version, and last seen (default)
+    rm <key>        Revoke a client by its client key
+
+  Examples:
+    izerop clients                 # list all devices
+    izerop clients rm 1a2b3c4d-...  # revoke a stale device`,
+
+		"whoami": `izerop whoami [--json]
+
+  Show the identity in use: active profile, server URL, account (if the
+  server reports it), and this device's client key. Exits non-zero if the
+  token is invalid or can't be verified.
+
+  Options:
+    --json         Print machine-readable JSON
+
+  Examples:
+    izerop whoami
+    izerop --profile work whoami --json`,
+
+		"doctor": `izerop doctor
+
+  Diagnose common setup problems for the active profile. Checks that the
+  config loads, the server is reachable, the token is accepted, the sync
+  directory exists and is writable, the watcher PID file matches a running
+  process, and the sync state file is valid. Prints a checklist with a fix
+  for each problem and exits non-zero if a critical check failed.
+
+  Examples:
+    izerop doctor
+    izerop --profile work doctor`,
+
+		"state": `izerop state <subcommand>
+
+  Manage the local sync state (cursor and tracked files) for the active profile.
+
+  Subcommands:
+    reset           Delete the sync state so the next sync starts fresh.
+                    Use this if the profile now points at a different server
+                    or the account was reset.
+
+  Examples:
+    izerop state reset
+    izerop --profile work state reset`,
+
+		"ignore": `izerop ignore test-run [dir] [options]
+
+  Walk a sync directory (default: the profile's) applying the same ignore
+  rules as sync, and list the files sync would consider and the paths it
+  would leave out, each with the pattern that excluded it. Only the local
+  filesystem is read: no server calls, and the sync state isn't touched.
+
+  Options:
+    --exclude-vcs     Also apply the built-in VCS/dependency ignore set
+                      (default: exclude_vcs from config)
+    --exclude <glob>  Add a run-only ignore pattern, as with sync (repeatable)
+    --include <glob>  Add a run-only include pattern, as with sync (repeatable)
+    --excluded        Only list excluded paths
+
+  Examples:
+    izerop ignore test-run
+    izerop ignore test-run ~/izerop --excluded
+    izerop ignore test-run --exclude 'build/**'`,
+
+		"config": `izerop config <subcommand>
+
+  Show or change the settings in the active profile's config.json (or the
+  --profile one) without editing the JSON by hand. Keys are the config.json
+  names, and values are checked before anything is saved.
+
+  Subcommands:
+    list               List every setting and its stored value (empty
+                       means the built-in default)
+    get <key>          Print one setting
+    set <key> <value>  Change a setting; "" resets it to the default.
+                       Lists are comma separated (text_extensions=.tex,.org)
+                       and sizes take units (max_file_size=500MB)
+
+  The token, token_source, and client_key can't be set here; use
+  'izerop login' for the token. Restart a running watcher to apply changes.
+
+  Examples:
+    izerop config list
+    izerop config get sync_dir
+    izerop config set settle_time_ms 5000
+    izerop --profile work config set server_url https://izerop.example.com
+    izerop config set quiet_hours ""`,
+
 		"profile": `izerop profile <subcommand>
 
   Manage multiple profiles. Each profile has its own server, token, sync
@@ -1711,11 +5062,15 @@ func printCommandHelp(cmd string) {
     add <name>        Create a new profile
     remove <name>     Delete a profile
     use <name>        Set the active (default) profile
+    rename <old> <new>
+                      Rename a profile, keeping its sync state, logs, and
+                      client key (stops its watcher first)
 
   The active profile is used when no --profile flag is given.
 
   Config: ~/.config/izerop/profiles/<name>/config.json
   State:  ~/.config/izerop/profiles/<name>/sync-state.json
+          (sync-state.db with state_backend sqlite)
 
   Examples:
     izerop profile list                    # show all profiles
@@ -1724,6 +5079,7 @@ func printCommandHelp(cmd string) {
     izerop profile use ranger              # make ranger the default
     izerop sync                            # syncs using ranger (active)
     izerop --profile default sync          # explicitly use default
+    izerop profile rename ranger scout     # rename ranger to scout
     izerop profile remove ranger           # delete ranger profile`,
 
 		"logs": `izerop logs [options]
@@ -1733,12 +5089,16 @@ func printCommandHelp(cmd string) {
   Options:
     -n, --tail N     Number of lines to show (default: 50)
     -f, --follow     Follow log output (like tail -f)
+    --json           Print JSON lines: logs written with --log-format json
+                     pass through as-is, text lines are converted. Without
+                     it, JSON logs are shown like text logs.
     --path <file>    Use a custom log file path
 
   Examples:
     izerop logs                   # last 50 lines
     izerop logs --tail 100        # last 100 lines
-    izerop logs --follow          # tail -f style`,
+    izerop logs --follow          # tail -f style
+    izerop logs --json | jq 'select(.event == "uploaded") | .path'`,
 
 		"reconcile": `izerop reconcile [<directory>] [options]
 
@@ -1754,24 +5114,125 @@ func printCommandHelp(cmd string) {
 
   Options:
     -n, --dry-run  Preview what would change without doing it
-    -v, --verbose  Show detailed output
+    --root <remote-dir>
+                   Reconcile with this remote directory instead of /root
+                   (default: sync_root from config)
+    --resume       Continue an interrupted reconcile, skipping files it
+                   already finished
+    --no-delete    Keep local files that were deleted on the server
+    --exclude-vcs  Ignore VCS/dependency dirs like .git and node_modules
+    --exclude <glob>, --include <glob>
+                   Ignore or sync matching paths for this run only, like
+                   sync's flags of the same name (repeatable)
+    -v, --verbose  Show detailed output, with a progress line on stderr for
+                   transfers of 1 MB or more (when stderr is a terminal)
+    --no-progress  Don't show per-transfer progress with --verbose
 
   Examples:
     izerop reconcile                   # full reconcile of sync dir
     izerop reconcile --dry-run         # preview only
+    izerop reconcile --resume          # continue after an interruption
     izerop reconcile ~/izerop -v       # verbose, specific dir`,
 
-		"push": `izerop push <file> [options]
+		"import": `izerop import <src-dir> [options]
+
+  One-shot bulk import of a local folder's contents into the server,
+  preserving subdirectory structure. Unlike sync, no sync state is kept and
+  no watcher is started, so nothing is ever deleted later. Hidden files,
+  conflict files, and paths matched by .izeropignore (and the global ignore
+  file) are skipped; text files become notes as they would in sync.
+
+  'izerop import-dir <src-dir> <remote-dir-id>' is the older form of
+  'izerop import <src-dir> --dir <remote-dir-id>'.
+
+  Options:
+    --dir <id|path>        Remote parent directory (default: the top level)
+    -j, --concurrency <n>  Upload up to n files at once (default 4)
+    -n, --dry-run          Show what would be uploaded without uploading
+
+  Examples:
+    izerop import ~/old-archive --dir /archive --dry-run   # preview
+    izerop import ~/old-archive --dir /archive             # import
+    izerop import ~/seed --concurrency 8`,
+
+		"export": `izerop export <dest-dir> [options]
+
+  One-shot backup of everything on the server into dest-dir, mirroring the
+  remote directory tree. Unlike sync and reconcile, no sync state is read or
+  written, and nothing is uploaded or deleted. Files that already exist in
+  dest-dir are skipped unless --overwrite is given.
+
+  Options:
+    --overwrite            Replace files that already exist locally
+    -j, --concurrency <n>  Download up to n files at once (default 4);
+                           progress is only shown with --concurrency 1
+    --progress             Show download progress on stderr
+    --no-progress          Don't show download progress
+
+  Examples:
+    izerop export ~/backups/izerop
+    izerop export ~/backups/izerop --overwrite --concurrency 8`,
+
+		"manifest": `izerop manifest [--json]
+
+  Print every remote file with its path, size, content hash, update time,
+  and ID, sorted by path. Save snapshots and diff them over time, or feed
+  one to another tool to verify an independent backup. Read-only; the
+  sync state isn't touched.
+
+  Plain output is one tab-separated line per file, with a total on stderr.
 
-  Upload a file to the server.
+  Options:
+    --json   Print a JSON document: {"server", "generated_at", "files": [...]}
+
+  Examples:
+    izerop manifest > manifest-$(date +%F).tsv
+    izerop manifest --json | jq '.files[] | select(.size > 1e9)'`,
+
+		"verify": `izerop verify [<directory>] [options]
+
+  Check that the sync directory really matches the server: every tracked
+  file is hashed afresh and compared with the server's content hash (from
+  the manifest). Lists files whose content differs, tracked files missing on
+  the server or locally, and files that couldn't be read. Nothing is
+  downloaded, uploaded, or changed. Exits 1 if anything didn't match, so it
+  fits backup checks and monitoring scripts.
+
+  Unlike 'status --check', which compares the hashes recorded at the last
+  sync, verify reads every file, so it catches local corruption too.
+
+  Options:
+    --download     Download and hash files the server has no hash for,
+                   instead of reporting them as unchecked
+    --json         Print a JSON document: {"server", "sync_dir", "checked",
+                   "ok", "discrepancies", "files": [...]}; each entry has a
+                   path, status (mismatch, missing_remote, missing_local,
+                   unverified, error), and both hashes
+    -v, --verbose  Show both hashes for mismatches and list unchecked files
+
+  Examples:
+    izerop verify
+    izerop verify --json | jq '.files[] | select(.status == "mismatch")'`,
+
+		"push": `izerop push <file|dir> [options]
+
+  Upload a file to the server. With --recursive, upload a whole directory,
+  recreating its folder structure under the --dir parent. Hidden and
+  .conflict files are skipped.
 
   Options:
-    --dir <id>     Target directory ID
-    --name <name>  Override the filename on the server
+    --dir <id|path>  Target (parent) directory ID, or a path like /photos/2024
+    --name <name>    Override the file or top-level directory name on the server
+    -r, --recursive  Push a directory and everything in it
+    --progress       Show upload progress on stderr (default when stderr
+                     is a terminal)
+    --no-progress    Don't show upload progress
 
   Examples:
     izerop push photo.jpg --dir abc123
-    izerop push IMG_001.jpg --dir abc123 --name vacation.jpg`,
+    izerop push photo.jpg --dir /photos/2024
+    izerop push IMG_001.jpg --dir abc123 --name vacation.jpg
+    izerop push ./reports --dir abc123 --recursive`,
 
 		"conflicts": `izerop conflicts [options]
 
@@ -1781,15 +5242,27 @@ func printCommandHelp(cmd string) {
   the sync engine saves the other version as a .conflict file. This command
   helps you find and clean them up.
 
+  Where conflict copies go is set by "conflict_strategy" in config.json:
+  "sibling" (default, name.conflict.ext), "folder" (same relative path
+  under .izerop-conflicts/), or "timestamped" (name.conflict-YYYYMMDD-
+  HHMMSS.ext). All three layouts are found and cleaned up here.
+
   Options:
+    --diff           Show a unified diff of each text conflict against its
+                     original (binary conflicts just show both sizes)
     --clean          Remove conflict files (default: keep originals)
     --keep-local     Keep your local version, delete conflict copies (default)
     --keep-remote    Replace originals with the remote (conflict) version
+    -i, --interactive
+                     Go through conflicts one at a time and choose keep
+                     local, keep remote, view diff, or skip for each
 
   Examples:
     izerop conflicts                          # list all conflicts
+    izerop conflicts --diff                   # review what diverged
     izerop conflicts --clean                  # delete all .conflict files
-    izerop conflicts --clean --keep-remote    # use remote versions instead`,
+    izerop conflicts --clean --keep-remote    # use remote versions instead
+    izerop conflicts --interactive            # decide per file`,
 
 		"url": `izerop url <file>
 
@@ -1803,59 +5276,150 @@ func printCommandHelp(cmd string) {
     izerop url ~/izerop/docs/readme.md        # absolute path
     izerop push photo.jpg && izerop url photo.jpg   # push then get URL`,
 
+		"share": `izerop share <file-id> [--off]
+       izerop share --dir <directory-id|/path> [--off]
+
+  Make a file public and print its shareable link (the server's asset URL,
+  or the download endpoint if it sends none). With --dir, share a whole
+  directory instead.
+
+  Options:
+    --dir <id|/path>  Share a directory instead of a file
+    --off             Make it private again
+
+  Examples:
+    izerop share abc123               # share a file, print the link
+    izerop share abc123 --off         # revoke
+    izerop share --dir /photos/2024   # share a directory`,
+
 		"pull": `izerop pull <file-id> [options]
+       izerop pull --dir <directory-id|/path> [options]
 
-  Download a file by ID.
+  Download a file by ID, or with --dir every file in a remote directory.
+  Files of 8 MB or more download into a <name>.izerop-part file first; if
+  the transfer is interrupted, run the same command again to resume.
 
   Options:
-    --out <path>   Save to a specific local path (default: auto-named)
+    --out <path>     Save to a specific local path (default: auto-named)
+    --dir <id|/path> Download a whole directory instead of one file
+    --out-dir <dir>  With --dir, the local directory to download into,
+                     created if needed (default: the remote directory's name)
+    --overwrite      With --dir, replace files that already exist locally
+                     (default: skip them)
+    -r, --recursive  With --dir, also download subdirectories
+    -j, --jobs <n>   With --dir, download up to n files at once (default 4);
+                     progress is only shown with --jobs 1
+    --direct         Fetch files straight from the storage URL the server
+                     lists for them, skipping its download redirect; falls
+                     back to the normal download if that fails
+    --progress       Show download progress on stderr (default when stderr
+                     is a terminal)
+    --no-progress    Don't show download progress
 
   Examples:
     izerop pull abc123                   # auto-named from server
-    izerop pull abc123 --out photo.jpg   # save to specific path`,
+    izerop pull abc123 --out photo.jpg   # save to specific path
+    izerop pull --dir /photos/2024 --out-dir ~/Pictures/2024 --recursive
+    izerop pull --dir /backups --out-dir ./backups --overwrite --jobs 8
+    izerop pull --dir /videos --direct`,
+
+		"cat": `izerop cat <file-id|path>
 
-		"ls": `izerop ls [<directory-id>]
+  Stream a remote file's contents to stdout. Accepts a file ID or a local
+  path inside the sync directory (resolved via sync state). Nothing else is
+  written to stdout, so output can be piped.
+
+  Examples:
+    izerop cat abc123 | grep TODO
+    izerop cat ~/izerop/notes/todo.txt`,
+
+		"ls": `izerop ls [<directory-id|/path>] [options]
 
   List remote directories and files with names, sizes, timestamps, and IDs.
 
+  Options:
+    --since <time>  Only show files updated after this time: RFC3339,
+                    YYYY-MM-DD, or a duration ago like 24h or 7d.
+                    Directories with no matching files are hidden.
+    --modified-since <time>
+                    Same as --since
+    --id-only       Print only file IDs, one per line, with no headers
+    --path-only     Print only remote file paths, one per line
+
+  Examples:
+    izerop ls                       # list all directories and files
+    izerop ls abc123                # list files in a specific directory
+    izerop ls /photos/2024          # ...or by path
+    izerop ls --since 2024-01-01    # files changed since a date
+    izerop ls --modified-since 24h  # what other devices uploaded today
+    izerop ls /logs --id-only | xargs -n1 izerop rm`,
+
+		"tree": `izerop tree [<directory-id|/path>] [options]
+
+  Show remote directories and files as an indented tree with sizes. Starts
+  at the top level, or at the given directory.
+
+  Options:
+    -L, --depth N  Only descend N levels
+    --ids          Show directory and file IDs
+
   Examples:
-    izerop ls              # list all directories and files
-    izerop ls abc123       # list files in a specific directory`,
+    izerop tree                     # everything
+    izerop tree /photos --depth 1   # one level under /photos
+    izerop tree --ids               # include IDs for push/mv/rm`,
 
 		"mkdir": `izerop mkdir <name> [options]
 
-  Create a remote directory.
+  Create a remote directory. The name may be a full path like /photos/2024.
 
   Options:
-    --parent <id>  Parent directory ID (for subdirectories)
+    --parent <id|path>  Parent directory ID or path (for subdirectories)
+    -p, --parents       Create missing parent directories along the path
 
   Examples:
-    izerop mkdir photos                        # top-level directory
-    izerop mkdir thumbnails --parent abc123     # subdirectory`,
+    izerop mkdir photos                          # top-level directory
+    izerop mkdir thumbnails --parent abc123       # subdirectory
+    izerop mkdir thumbnails --parent /photos      # parent by path
+    izerop mkdir /photos/2024/jan --parents       # create the whole path`,
 
-		"rm": `izerop rm <id> [options]
+		"rm": `izerop rm <id|/path/glob> [options]
 
   Delete a file or directory (soft-delete on server).
 
+  An argument starting with "/" or containing *, ? or [ is matched against
+  remote file paths; the matching files are listed and deleted after
+  confirmation. "*" does not cross directories.
+
   Options:
-    --dir   Treat the ID as a directory (default: file)
+    --dir       Treat the ID as a directory (default: file)
+    --yes, -y   Delete matching files without asking
 
   Examples:
-    izerop rm abc123           # delete a file
-    izerop rm abc123 --dir     # delete a directory`,
+    izerop rm abc123               # delete a file
+    izerop rm abc123 --dir         # delete a directory
+    izerop rm '/logs/*.log'        # delete every .log file in /logs
+    izerop rm '/tmp/*' --yes       # no confirmation prompt`,
 
 		"mv": `izerop mv <file-id> [options]
+       izerop mv [--dir-id] <directory-id|/path> [options]
 
-  Move or rename a file.
+  Move or rename a file or a directory. A /path, or an ID that belongs to a
+  directory, moves the directory; --dir-id says so explicitly.
 
   Options:
-    --name <name>  New filename
-    --dir <id>     Move to a different directory
+    --name <name>  New file or directory name
+    --dir <id|path>
+                   Move to a different directory (ID or path like /photos)
+    --parent <id|path>
+                   Same as --dir; reads better when moving a directory
+    --dir-id <id|path>
+                   The directory to move
 
   Examples:
     izerop mv abc123 --name new-name.txt
     izerop mv abc123 --dir def456
-    izerop mv abc123 --name new-name.txt --dir def456`,
+    izerop mv abc123 --name new-name.txt --dir def456
+    izerop mv /photos/2023 --name archive --parent /old`,
 
 		"update": `izerop update
 
@@ -1869,6 +5433,7 @@ func printCommandHelp(cmd string) {
 
   Print the current version.`,
 	}
+	help["import-dir"] = help["import"]
 
 	if h, ok := help[cmd]; ok {
 		fmt.Println(h)
@@ -1893,15 +5458,29 @@ Commands:
   reconcile Full reconcile using server manifest (recovery/verification)
   watch     Watch and sync (fsnotify + polling, --daemon for background)
   logs      View watch daemon logs (--follow, --tail N)
+  service   Install the watcher as a systemd/launchd service
   push      Upload files to server
+  import    Bulk-upload a local folder tree to the server (no sync state)
   url       Get the direct asset URL for a file
+  share     Make a file or directory public and print its link (--off to revoke)
   conflicts List and resolve conflict files
   pull      Download files from server
+  export    Download the whole remote tree into a folder (no sync state)
+  manifest  Print every remote file with its size, hash, and ID (--json)
+  verify    Re-hash tracked files and compare them with the server
+  cat       Print a remote file to stdout
   ls        List remote files and directories
+  tree      Show remote directories and files as a tree
   rm        Delete a file or directory
-  mv        Move/rename a file
+  mv        Move/rename a file or directory
   client    Name this device for sync tracking
+  clients   List or revoke all devices syncing this account
+  state     Reset local sync state
+  ignore    Check ignore rules: list what sync would include and exclude
+  whoami    Show which account, server, and profile are in use
+  doctor    Check config, server, token, sync dir, watcher, and state
   profile   Manage profiles (list, add, remove, use)
+  config    Show or change profile settings (list, get, set)
   update    Self-update to latest release
   version   Print version
   help      Show this help
@@ -1911,15 +5490,21 @@ Profile Commands:
   profile add <name> [opts]     Create a profile (--server, --token, --sync-dir)
   profile remove <name>         Delete a profile
   profile use <name>            Set active profile
+  profile rename <old> <new>    Rename a profile (also: rename-profile)
 
 Options:
   --server URL      Override server URL
   --profile NAME    Use a specific profile (default: active profile)
+  --utc             Show timestamps in UTC instead of local time
+  --local           Show timestamps in local time (the default)
+  --debug           Log every API request (method, URL, status, time) to stderr
+  --debug=bodies    Also dump request/response headers and text bodies
 
 Environment:
   IZEROP_SERVER_URL   Override server URL
   IZEROP_TOKEN        Override API token
   IZEROP_SYNC_DIR     Override sync directory
+  IZEROP_DEBUG        1 to log API requests, bodies to dump bodies too
 
 Precedence: --server flag > env vars > config file
 
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestLocalFileName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"  spaced.txt ", "spaced.txt"},
+		{"../x", "x"},
+		{"../../etc/passwd", "passwd"},
+		{"/etc/passwd", "passwd"},
+		{`..\x`, "x"},
+		{`C:\Windows\evil.dll`, "evil.dll"},
+		{"dir/", "fallback"},
+		{"", "fallback"},
+		{"   ", "fallback"},
+		{".", "fallback"},
+		{"..", "fallback"},
+		{"a/..", "fallback"},
+		{`a\..`, "fallback"},
+		{"nul\x00byte", "fallback"},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, struct{ name, want string }{"C:evil.dll", "evil.dll"})
+	}
+	for _, tt := range tests {
+		if got := localFileName(tt.name, "fallback"); got != tt.want {
+			t.Errorf("localFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoveFileCopyFallback(t *testing.T) {
+	defer func(orig func(string, string) error) { rename = orig }(rename)
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	dir := t.TempDir()
+
+	t.Run("copies", func(t *testing.T) {
+		src, dst := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+		if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := moveFile(src, dst); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(dst); string(got) != "hello" {
+			t.Errorf("dst = %q, want hello", got)
+		}
+		if _, err := os.Stat(src); !os.IsNotExist(err) {
+			t.Errorf("src still there: %v", err)
+		}
+	})
+
+	t.Run("failed copy", func(t *testing.T) {
+		// A directory opens fine but can't be read, so the copy fails after
+		// dst was created
+		src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+		if err := os.Mkdir(src, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := moveFile(src, dst); err == nil {
+			t.Fatal("moveFile succeeded")
+		}
+		if _, err := os.Stat(dst); !os.IsNotExist(err) {
+			t.Errorf("partial dst left behind: %v", err)
+		}
+		if _, err := os.Stat(src); err != nil {
+			t.Errorf("src gone: %v", err)
+		}
+	})
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := []struct {
+		arg, want string
+	}{
+		{"/usr/bin/izerop", `"/usr/bin/izerop"`},
+		{"/home/me/My Notes", `"/home/me/My Notes"`},
+		{"100%", `"100%%"`},
+		{"$HOME/notes", `"$$HOME/notes"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\dir`, `"C:\\dir"`},
+		{"tab\there", `"tab\there"`},
+		{"bell\a", `"bell\x07"`},
+		{"café", `"café"`},
+	}
+	for _, tt := range tests {
+		if got := systemdQuote(tt.arg); got != tt.want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+)
+
+// manifestFile is one remote file in 'izerop manifest --json' output.
+type manifestFile struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	ContentHash string `json:"content_hash"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// manifestSnapshot is the 'izerop manifest --json' document.
+type manifestSnapshot struct {
+	Server      string         `json:"server"`
+	GeneratedAt string         `json:"generated_at,omitempty"`
+	Files       []manifestFile `json:"files"`
+}
+
+// cmdManifest prints every remote file with its ID, size, content hash, and
+// update time, sorted by path so snapshots taken at different times diff
+// cleanly. It is read-only and never touches the sync state.
+func cmdManifest(cfg *config.Config) {
+	// Usage: izerop manifest [--json]
+	asJSON := false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown option: %s\n", arg)
+			fmt.Fprintf(os.Stderr, "Usage: izerop manifest [--json]\n")
+			os.Exit(1)
+		}
+	}
+
+	client := newClient(cfg)
+	requireFeature(client, api.FeatureManifest, "The manifest is")
+	manifest, err := client.GetManifest(runCtx, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not fetch manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	snap := manifestSnapshot{Server: cfg.ServerURL, GeneratedAt: manifest.GeneratedAt, Files: []manifestFile{}}
+	for _, f := range manifest.Files {
+		snap.Files = append(snap.Files, manifestFile{
+			ID:          f.ID,
+			Path:        f.Path,
+			Size:        f.Size,
+			ContentHash: f.ContentHash,
+			UpdatedAt:   f.UpdatedAt,
+		})
+	}
+	sort.Slice(snap.Files, func(i, j int) bool { return snap.Files[i].Path < snap.Files[j].Path })
+
+	if asJSON {
+		data, _ := json.MarshalIndent(snap, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	// One tab-separated line per file: path, size, hash, updated, ID
+	var total int64
+	for _, f := range snap.Files {
+		fmt.Printf("%s\t%d\t%s\t%s\t%s\n", f.Path, f.Size, orDash(f.ContentHash), orDash(f.UpdatedAt), f.ID)
+		total += f.Size
+	}
+	fmt.Fprintf(os.Stderr, "%d files (%s)\n", len(snap.Files), formatSize(total))
+}
+
+// orDash keeps empty fields visible in tab-separated output.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+)
+
+// cmdService manages an OS-supervised watcher (systemd user unit on Linux,
+// launchd agent on macOS) as an alternative to `watch --daemon`.
+func cmdService(cfg *config.Config) {
+	// Usage: izerop service [install|uninstall|status]
+	if len(os.Args) < 3 {
+		printCommandHelp("service")
+		return
+	}
+
+	switch os.Args[2] {
+	case "install":
+		cmdServiceInstall(cfg)
+	case "uninstall", "remove", "rm":
+		cmdServiceUninstall()
+	case "status":
+		cmdServiceStatus()
+	case "help", "--help", "-h":
+		printCommandHelp("service")
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown service command: %s\n", os.Args[2])
+		fmt.Fprintf(os.Stderr, "Usage: izerop service [install|uninstall|status]\n")
+		os.Exit(1)
+	}
+}
+
+// serviceName returns the unit/agent name for a profile's watcher.
+func serviceName(profile string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "com.izerop.watch." + profile
+	default:
+		return "izerop-watch-" + profile + ".service"
+	}
+}
+
+// servicePath returns where the unit/agent file for a profile lives.
+func servicePath(profile string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", serviceName(profile)+".plist"), nil
+	case "linux":
+		return filepath.Join(home, ".config", "systemd", "user", serviceName(profile)), nil
+	default:
+		return "", fmt.Errorf("service install is not supported on %s; use 'izerop watch --daemon'", runtime.GOOS)
+	}
+}
+
+func cmdServiceInstall(cfg *config.Config) {
+	if cfg == nil || cfg.SyncDir == "" {
+		fmt.Fprintf(os.Stderr, "No sync directory configured for profile %q.\n", activeProfile)
+		fmt.Fprintf(os.Stderr, "Set one: izerop profile add %s --sync-dir <path>\n", activeProfile)
+		os.Exit(1)
+	}
+
+	path, err := servicePath(activeProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not find executable path: %v\n", err)
+		os.Exit(1)
+	}
+	syncDir, err := filepath.Abs(cfg.SyncDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid sync directory: %v\n", err)
+		os.Exit(1)
+	}
+	logPath := defaultLogPath()
+	args := []string{execPath, "--profile", activeProfile, "watch", syncDir, "--log", logPath}
+
+	var contents string
+	if runtime.GOOS == "darwin" {
+		contents = launchdPlist(serviceName(activeProfile), args)
+	} else {
+		contents = systemdUnit(activeProfile, args)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not create %s: %v\n", filepath.Dir(path), err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write service file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Wrote %s\n", path)
+
+	if runtime.GOOS == "darwin" {
+		runServiceCmd("launchctl", "load", "-w", path)
+	} else {
+		runServiceCmd("systemctl", "--user", "daemon-reload")
+		runServiceCmd("systemctl", "--user", "enable", "--now", serviceName(activeProfile))
+	}
+
+	fmt.Printf("👁 Watcher for %q is now supervised by the OS (starts at login, restarts on crash)\n", activeProfile)
+	fmt.Printf("   Log: %s\n", logPath)
+}
+
+func cmdServiceUninstall() {
+	path, err := servicePath(activeProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "No service installed for profile %q\n", activeProfile)
+		os.Exit(1)
+	}
+
+	if runtime.GOOS == "darwin" {
+		runServiceCmd("launchctl", "unload", "-w", path)
+	} else {
+		runServiceCmd("systemctl", "--user", "disable", "--now", serviceName(activeProfile))
+	}
+
+	if err := os.Remove(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not remove %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	if runtime.GOOS != "darwin" {
+		runServiceCmd("systemctl", "--user", "daemon-reload")
+	}
+	fmt.Printf("🗑 Service for %q uninstalled\n", activeProfile)
+}
+
+func cmdServiceStatus() {
+	path, err := servicePath(activeProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Printf("⏹ No service installed for profile %q\n", activeProfile)
+		return
+	}
+	fmt.Printf("Service: %s\n", path)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("launchctl", "list", serviceName(activeProfile))
+	} else {
+		cmd = exec.Command("systemctl", "--user", "status", "--no-pager", serviceName(activeProfile))
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Run()
+}
+
+// runServiceCmd runs a service manager command, warning on failure.
+func runServiceCmd(name string, args ...string) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ %s %s: %v\n", name, strings.Join(args, " "), err)
+	}
+}
+
+// systemdUnit renders a systemd user unit that runs the watcher in the foreground.
+func systemdUnit(profile string, args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = systemdQuote(a)
+	}
+	return fmt.Sprintf(`[Unit]
+Description=izerop sync watcher (%s)
+After=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=default.target
+`, strings.ReplaceAll(profile, "%", "%%"), strings.Join(quoted, " "))
+}
+
+// systemdQuote quotes one ExecStart argument by systemd's rules, which
+// differ from Go's: only C-style escapes are understood inside the double
+// quotes, and % and $ must be doubled to be taken literally.
+func systemdQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '%':
+			b.WriteString("%%")
+		case '$':
+			b.WriteString("$$")
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\x%02x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// launchdPlist renders a launchd agent that keeps the watcher running.
+func launchdPlist(label string, args []string) string {
+	var b strings.Builder
+	for _, a := range args {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(a))
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+</dict>
+</plist>
+`, xmlEscape(label), b.String())
+}
+
+func xmlEscape(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	return r.Replace(s)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+)
+
+// verifyOutput is the 'izerop verify --json' document.
+type verifyOutput struct {
+	Server  string `json:"server"`
+	SyncDir string `json:"sync_dir"`
+	*sync.VerifyReport
+}
+
+// cmdVerify re-hashes every tracked file and compares it with the server's
+// content hash, reporting mismatches and files missing on either side. It
+// is read-only and exits 1 if anything didn't match.
+func cmdVerify(cfg *config.Config) {
+	// Usage: izerop verify [<directory>] [--download] [--json] [--verbose]
+	syncDir := cfg.SyncDir
+	asJSON := false
+	download := false
+	verbose := false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--json":
+			asJSON = true
+		case "--download":
+			download = true
+		case "--verbose", "-v":
+			verbose = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				fmt.Fprintf(os.Stderr, "Unknown option: %s\n", arg)
+				fmt.Fprintf(os.Stderr, "Usage: izerop verify [<directory>] [--download] [--json] [--verbose]\n")
+				os.Exit(1)
+			}
+			syncDir = arg
+		}
+	}
+	if syncDir == "" {
+		syncDir = "."
+	}
+	absDir, err := filepath.Abs(syncDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid directory: %v\n", err)
+		os.Exit(1)
+	}
+	syncDir = absDir
+	if info, err := os.Stat(syncDir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "Not a directory: %s\n", syncDir)
+		os.Exit(1)
+	}
+
+	client := newClient(cfg)
+	requireFeature(client, api.FeatureManifest, "The manifest is")
+
+	store := openSyncStore(cfg)
+	defer store.Close()
+	engine := sync.NewEngine(client, syncDir, store.State())
+	engine.SetRoot(cfg.SyncRoot)
+	engine.Store = store
+	engine.Ignore = sync.LoadIgnoreRules(syncDir, cfg.DefaultIgnore...)
+	engine.FilterOSJunk = cfg.OSJunkFiltered()
+	engine.Symlinks = symlinkPolicy(cfg)
+	if cfg.ExcludeVCS {
+		engine.ExcludeVCS()
+	}
+
+	if !asJSON {
+		fmt.Printf("🔍 Verifying %s ↔ %s\n", syncDir, cfg.ServerURL)
+	}
+	report, err := engine.Verify(runCtx, download)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Verify failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if asJSON {
+		data, _ := json.MarshalIndent(verifyOutput{Server: cfg.ServerURL, SyncDir: syncDir, VerifyReport: report}, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printVerifyReport(report, download, verbose)
+	}
+	if !report.Clean() {
+		os.Exit(1)
+	}
+}
+
+// printVerifyReport lists the files that didn't verify and a summary.
+func printVerifyReport(report *sync.VerifyReport, download, verbose bool) {
+	unverified := 0
+	for _, f := range report.Files {
+		switch f.Status {
+		case sync.VerifyMismatch:
+			fmt.Printf("  ≠ %s (content differs from the server)\n", f.Path)
+			if verbose {
+				fmt.Printf("      local  %s\n      server %s\n", f.LocalHash, f.RemoteHash)
+			}
+		case sync.VerifyMissingRemote:
+			fmt.Printf("  - %s (tracked, missing on server)\n", f.Path)
+		case sync.VerifyMissingLocal:
+			fmt.Printf("  ? %s (tracked, missing locally)\n", f.Path)
+		case sync.VerifyError:
+			fmt.Printf("  ⚠ %s (%s)\n", f.Path, f.Error)
+		case sync.VerifyUnverified:
+			unverified++
+			if verbose {
+				fmt.Printf("  ~ %s (no server hash)\n", f.Path)
+			}
+		}
+	}
+
+	fmt.Println()
+	if unverified > 0 && !download {
+		fmt.Printf("⚠ %d file(s) have no server hash and weren't checked; --download hashes them from the server\n", unverified)
+	}
+	if report.Clean() {
+		fmt.Printf("✅ %d of %d tracked file(s) match the server\n", report.OK, report.Checked)
+		return
+	}
+	fmt.Printf("❌ %d discrepancies in %d tracked file(s)\n", report.Discrepancies, report.Checked)
+	fmt.Println("   Run 'izerop reconcile' to repair")
+}
@@ -10,7 +10,9 @@ import (
 )
 
 // Login prompts for server URL and API token, then saves the config.
-func Login() error {
+// With useKeyring, the token is stored in the OS keychain instead of
+// config.json, falling back to plaintext if no keychain is available.
+func Login(useKeyring bool) error {
 	reader := bufio.NewReader(os.Stdin)
 
 	fmt.Print("Server URL [https://izerop.com]: ")
@@ -35,6 +37,15 @@ func Login() error {
 	cfg.ServerURL = serverURL
 	cfg.Token = token
 
+	if useKeyring {
+		if err := config.StoreToken(config.GetActiveProfile(), token); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Could not use the OS keyring (%v); saving token in plaintext config.\n", err)
+			cfg.TokenSource = ""
+		} else {
+			cfg.TokenSource = config.TokenSourceKeyring
+		}
+	}
+
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("could not save config: %w", err)
 	}
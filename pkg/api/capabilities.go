@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ErrNotSupported is returned when the server lacks an optional feature,
+// either by saying so in its capabilities or by answering 404 on the
+// feature's endpoint.
+var ErrNotSupported = errors.New("not supported by this server")
+
+// Feature names an optional server feature, as listed by /api/v1/capabilities.
+type Feature string
+
+const (
+	FeatureManifest       Feature = "manifest"
+	FeatureClients        Feature = "clients"
+	FeaturePublicLinks    Feature = "public_links"
+	FeatureRangeDownloads Feature = "range_downloads"
+	FeatureFileModes      Feature = "file_modes"
+)
+
+// versionHeader carries the server version on API responses.
+const versionHeader = "X-Izerop-Version"
+
+// Capabilities describes what the server supports.
+type Capabilities struct {
+	Version  string    `json:"version,omitempty"`
+	Features []Feature `json:"features"`
+	// Known is false when the server predates the capabilities endpoint.
+	// Every feature is then assumed present, and a missing one shows up
+	// as ErrNotSupported from its own endpoint.
+	Known bool `json:"-"`
+}
+
+// Has reports whether the server supports feature.
+func (c *Capabilities) Has(feature Feature) bool {
+	if c == nil || !c.Known {
+		return true
+	}
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities fetches what the server supports from /api/v1/capabilities,
+// falling back to the version header alone on servers without the endpoint.
+// The answer is cached for the life of the client; errors are not, so a
+// later call tries again.
+func (c *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+	if c.caps != nil {
+		return c.caps, nil
+	}
+
+	resp, err := c.do(ctx, "GET", "/api/v1/capabilities", nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	caps := &Capabilities{Version: resp.Header.Get(versionHeader)}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(caps); err != nil {
+			return nil, fmt.Errorf("could not decode response: %w", err)
+		}
+		if caps.Version == "" {
+			caps.Version = resp.Header.Get(versionHeader)
+		}
+		sort.Slice(caps.Features, func(i, j int) bool { return caps.Features[i] < caps.Features[j] })
+		caps.Known = true
+	case http.StatusNotFound:
+	default:
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	c.caps = caps
+	return caps, nil
+}
+
+// Require returns an ErrNotSupported error if the server reports that it
+// lacks feature. If the capabilities can't be fetched it returns nil and
+// leaves the feature's own request to fail with the real error.
+func (c *Client) Require(ctx context.Context, feature Feature) error {
+	caps, err := c.Capabilities(ctx)
+	if err != nil || caps.Has(feature) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", feature, ErrNotSupported)
+}
@@ -2,40 +2,86 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Client communicates with the izerop API.
+// Client communicates with the izerop API. Every method that talks to the
+// server takes a context; canceling it aborts the request, including an
+// upload or download in progress.
 type Client struct {
 	BaseURL    string
 	Token      string
 	ClientKey  string
 	HTTPClient *http.Client
+
+	// OnTransfer, if set, is called as file uploads and downloads progress.
+	OnTransfer TransferFunc
+
+	capsMu sync.Mutex
+	caps   *Capabilities
 }
 
+// Connection pool defaults. Up to DefaultMaxIdleConnsPerHost connections
+// to the server are kept open for reuse (enough for parallel transfers), and
+// an idle one is closed after DefaultIdleConnTimeout, which outlasts the
+// watcher's default 30s poll so each poll reuses the last connection.
+const (
+	DefaultMaxIdleConnsPerHost = 4
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
 // NewClient creates a new API client.
 func NewClient(baseURL, token string) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	transport.IdleConnTimeout = DefaultIdleConnTimeout
 	return &Client{
 		BaseURL: baseURL,
 		Token:   token,
 		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport,
 		},
 	}
 }
 
+// SetConnPool tunes the connection pool: how many idle connections to a
+// host are kept and how long an idle one stays open. Zero leaves a setting
+// at its default.
+func (c *Client) SetConnPool(maxIdlePerHost int, idleTimeout time.Duration) {
+	rt := c.HTTPClient.Transport
+	if d, ok := rt.(*debugTransport); ok {
+		rt = d.next
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return
+	}
+	if maxIdlePerHost > 0 {
+		transport.MaxIdleConnsPerHost = maxIdlePerHost
+	}
+	if idleTimeout > 0 {
+		transport.IdleConnTimeout = idleTimeout
+	}
+}
+
 // do executes an authenticated HTTP request.
-func (c *Client) do(method, path string, body io.Reader) (*http.Response, error) {
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	url := fmt.Sprintf("%s%s", c.BaseURL, path)
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +93,15 @@ func (c *Client) do(method, path string, body io.Reader) (*http.Response, error)
 		req.Header.Set("X-Client-Key", c.ClientKey)
 	}
 
-	return c.HTTPClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, ErrUnauthorized
+	}
+	return resp, nil
 }
 
 // SyncStatus represents the response from /api/v1/sync/status.
@@ -61,13 +115,16 @@ type SyncStatus struct {
 }
 
 // GetSyncStatus fetches the current sync status.
-func (c *Client) GetSyncStatus() (*SyncStatus, error) {
-	resp, err := c.do("GET", "/api/v1/sync/status", nil)
+func (c *Client) GetSyncStatus(ctx context.Context) (*SyncStatus, error) {
+	resp, err := c.do(ctx, "GET", "/api/v1/sync/status", nil)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
 	}
@@ -93,14 +150,16 @@ type FileEntry struct {
 	Public      bool   `json:"public"`
 	HasBinary   bool   `json:"has_binary"`
 	HasText     bool   `json:"has_text"`
+	Mode        uint32 `json:"mode,omitempty"`        // Unix permission bits, 0 if the server stores none
+	ModifiedBy  string `json:"modified_by,omitempty"` // name of the client that last modified the file
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
 
 // GetFile fetches a single file by ID.
-func (c *Client) GetFile(fileID string) (*FileEntry, error) {
+func (c *Client) GetFile(ctx context.Context, fileID string) (*FileEntry, error) {
 	path := fmt.Sprintf("/api/v1/files/%s", fileID)
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -121,13 +180,13 @@ func (c *Client) GetFile(fileID string) (*FileEntry, error) {
 }
 
 // ListFiles fetches the file listing.
-func (c *Client) ListFiles(directoryID string) ([]FileEntry, error) {
+func (c *Client) ListFiles(ctx context.Context, directoryID string) ([]FileEntry, error) {
 	path := "/api/v1/files"
 	if directoryID != "" {
 		path = fmt.Sprintf("/api/v1/files?directory_id=%s", directoryID)
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -160,8 +219,8 @@ type Directory struct {
 }
 
 // ListDirectories fetches the directory listing.
-func (c *Client) ListDirectories() ([]Directory, error) {
-	resp, err := c.do("GET", "/api/v1/directories", nil)
+func (c *Client) ListDirectories(ctx context.Context) ([]Directory, error) {
+	resp, err := c.do(ctx, "GET", "/api/v1/directories", nil)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -192,6 +251,8 @@ type ManifestEntry struct {
 	ContentHash string `json:"content_hash"`
 	HasText     bool   `json:"has_text"`
 	HasBinary   bool   `json:"has_binary"`
+	Mode        uint32 `json:"mode,omitempty"`
+	ModifiedBy  string `json:"modified_by,omitempty"`
 	UpdatedAt   string `json:"updated_at"`
 }
 
@@ -212,18 +273,21 @@ type ManifestResponse struct {
 }
 
 // GetManifest fetches the full file/directory manifest from the server.
-func (c *Client) GetManifest(root string) (*ManifestResponse, error) {
+func (c *Client) GetManifest(ctx context.Context, root string) (*ManifestResponse, error) {
 	path := "/api/v1/sync/manifest"
 	if root != "" {
-		path = fmt.Sprintf("%s?root=%s", path, root)
+		path = fmt.Sprintf("%s?root=%s", path, url.QueryEscape(root))
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("manifest: %w", ErrNotSupported)
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("manifest failed (status %d): %s", resp.StatusCode, string(body))
@@ -249,6 +313,8 @@ type Change struct {
 	Size        int64  `json:"size"`
 	ContentType string `json:"content_type"`
 	ContentHash string `json:"content_hash,omitempty"`
+	Mode        uint32 `json:"mode,omitempty"`
+	ModifiedBy  string `json:"modified_by,omitempty"`
 	UpdatedAt   string `json:"updated_at"`
 }
 
@@ -260,13 +326,13 @@ type ChangesResponse struct {
 }
 
 // GetChanges fetches changes since the given cursor.
-func (c *Client) GetChanges(cursor string) (*ChangesResponse, error) {
+func (c *Client) GetChanges(ctx context.Context, cursor string) (*ChangesResponse, error) {
 	path := "/api/v1/sync/changes"
 	if cursor != "" {
 		path = fmt.Sprintf("%s?since=%s", path, cursor)
 	}
 
-	resp, err := c.do("GET", path, nil)
+	resp, err := c.do(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -285,7 +351,7 @@ func (c *Client) GetChanges(cursor string) (*ChangesResponse, error) {
 }
 
 // UploadFile uploads a local file to the server.
-func (c *Client) UploadFile(localPath, directoryID, name string) (*FileEntry, error) {
+func (c *Client) UploadFile(ctx context.Context, localPath, directoryID, name string) (*FileEntry, error) {
 	f, err := os.Open(localPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open file: %w", err)
@@ -315,10 +381,12 @@ func (c *Client) UploadFile(localPath, directoryID, name string) (*FileEntry, er
 	writer.Close()
 
 	url := fmt.Sprintf("%s/api/v1/files", c.BaseURL)
-	req, err := http.NewRequest("POST", url, &body)
+	size := int64(body.Len())
+	req, err := http.NewRequestWithContext(ctx, "POST", url, c.trackTransfer(&body, 0, size))
 	if err != nil {
 		return nil, err
 	}
+	req.ContentLength = size
 
 	req.Header.Set("Authorization", "Bearer "+c.Token)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -333,6 +401,10 @@ func (c *Client) UploadFile(localPath, directoryID, name string) (*FileEntry, er
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		respBody, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("upload failed (status %d): %s", resp.StatusCode, string(respBody))
@@ -348,12 +420,27 @@ func (c *Client) UploadFile(localPath, directoryID, name string) (*FileEntry, er
 	return &wrapper.File, nil
 }
 
-// DownloadFile downloads a file by ID and writes it to the given writer.
-// Returns the suggested filename from Content-Disposition if available.
-func (c *Client) DownloadFile(fileID string, dest io.Writer) (string, error) {
+// ResumableThreshold is the file size above which downloads should use
+// DownloadFileResumable so a dropped connection doesn't restart from zero.
+const ResumableThreshold = 8 * 1024 * 1024
+
+// downloadRetries is how many times DownloadFileResumable resumes after a failure.
+const downloadRetries = 3
+
+// downloadIdleTimeout is how long a download may wait for a response, or
+// for its next bytes, before it is abandoned. There is no limit on the
+// whole transfer, which a large file on a slow link could never meet.
+const downloadIdleTimeout = 2 * time.Minute
+
+// errDownloadStalled is the error for a download that hit downloadIdleTimeout.
+var errDownloadStalled = errors.New("download stalled: no data received for " + downloadIdleTimeout.String())
+
+// downloadClient returns an HTTP client for file downloads. It shares the
+// API client's connection pool.
+func (c *Client) downloadClient() *http.Client {
 	// Strip auth headers when redirected to S3/external hosts
-	client := &http.Client{
-		Timeout: 120 * time.Second,
+	return &http.Client{
+		Transport: c.HTTPClient.Transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= 10 {
 				return fmt.Errorf("too many redirects")
@@ -365,43 +452,295 @@ func (c *Client) DownloadFile(fileID string, dest io.Writer) (string, error) {
 			return nil
 		},
 	}
+}
+
+// doDownload sends a download request, canceling it if the response or the
+// body stalls for downloadIdleTimeout. The caller must close the body.
+func (c *Client) doDownload(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancelCause(req.Context())
+	timer := time.AfterFunc(downloadIdleTimeout, func() { cancel(errDownloadStalled) })
+	resp, err := c.downloadClient().Do(req.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		if context.Cause(ctx) == errDownloadStalled {
+			err = errDownloadStalled
+		}
+		cancel(nil)
+		return nil, err
+	}
+	resp.Body = &idleBody{ReadCloser: resp.Body, ctx: ctx, timer: timer, cancel: cancel}
+	return resp, nil
+}
+
+// idleBody restarts a download's idle timer whenever bytes arrive.
+type idleBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	timer  *time.Timer
+	cancel context.CancelCauseFunc
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(downloadIdleTimeout)
+	}
+	if err != nil && context.Cause(b.ctx) == errDownloadStalled {
+		err = errDownloadStalled
+	}
+	return n, err
+}
+
+func (b *idleBody) Close() error {
+	b.timer.Stop()
+	b.cancel(nil)
+	return b.ReadCloser.Close()
+}
 
+// newDownloadRequest builds an authenticated download request for a file.
+func (c *Client) newDownloadRequest(ctx context.Context, fileID string) (*http.Request, error) {
 	url := fmt.Sprintf("%s/api/v1/files/%s/download", c.BaseURL, fileID)
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+c.Token)
+	return req, nil
+}
+
+// dispositionFilename extracts the filename from a Content-Disposition header.
+func dispositionFilename(resp *http.Response) string {
+	filename := ""
+	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
+		if i := bytes.Index([]byte(cd), []byte("filename=")); i >= 0 {
+			filename = string([]byte(cd)[i+9:])
+			filename = strings.Trim(filename, `"' `)
+		}
+	}
+	return filename
+}
 
-	resp, err := client.Do(req)
+// DownloadFile downloads a file by ID and writes it to the given writer.
+// Returns the suggested filename from Content-Disposition if available.
+func (c *Client) DownloadFile(ctx context.Context, fileID string, dest io.Writer) (string, error) {
+	req, err := c.newDownloadRequest(ctx, fileID)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.doDownload(req)
 	if err != nil {
 		return "", fmt.Errorf("download request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", ErrUnauthorized
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("download failed (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	// Try to get filename from Content-Disposition header
-	filename := ""
-	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
-		if i := bytes.Index([]byte(cd), []byte("filename=")); i >= 0 {
-			filename = string([]byte(cd)[i+9:])
-			filename = strings.Trim(filename, `"' `)
-		}
-	}
+	filename := dispositionFilename(resp)
 
-	if _, err := io.Copy(dest, resp.Body); err != nil {
+	if _, err := io.Copy(dest, c.trackTransfer(resp.Body, 0, resp.ContentLength)); err != nil {
 		return filename, fmt.Errorf("error writing file: %w", err)
 	}
 
 	return filename, nil
 }
 
+// DownloadFromURL downloads a file straight from a direct asset URL (such
+// as the pre-signed storage URL in FileEntry.URL) and writes it to dest,
+// skipping the server's download endpoint and its redirect. The URL carries
+// its own authorization, so the API token is never sent with it.
+func (c *Client) DownloadFromURL(ctx context.Context, url string, dest io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doDownload(req)
+	if err != nil {
+		return fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("download failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if _, err := io.Copy(dest, c.trackTransfer(resp.Body, 0, resp.ContentLength)); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	return nil
+}
+
+// DownloadFileResumable downloads a file by ID into path, resuming from any
+// bytes already in the file with an HTTP Range request. If the transfer
+// drops it retries, picking up where it left off, until ctx is canceled.
+// size is the file's size on the server (-1 if unknown): a resumed range
+// that belongs to a file of another size means the file changed since the
+// bytes on disk were fetched, so the download starts over. Retries also
+// send If-Range with the first response's ETag or Last-Modified. If the
+// server ignores the range (200 instead of 206) the file is truncated and
+// downloaded in full. A server whose capabilities leave out
+// FeatureRangeDownloads is never sent a range; every attempt starts over.
+// Returns the suggested filename from Content-Disposition if available.
+func (c *Client) DownloadFileResumable(ctx context.Context, fileID, path string, size int64) (string, error) {
+	d := &rangeDownload{fileID: fileID, path: path, size: size}
+	d.noRange = c.Require(ctx, FeatureRangeDownloads) != nil
+	var lastErr error
+	for attempt := 0; attempt <= downloadRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(attempt) * time.Second):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		filename, retry, err := c.downloadRange(ctx, d)
+		if err == nil {
+			return filename, nil
+		}
+		lastErr = err
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// rangeDownload is the state DownloadFileResumable keeps across attempts.
+type rangeDownload struct {
+	fileID string
+	path   string
+	size   int64
+	// validator is the ETag (or Last-Modified) of the first response, sent
+	// as If-Range so a file replaced between attempts is sent whole.
+	validator string
+	// noRange is set when the server says it can't serve ranges.
+	noRange bool
+}
+
+// downloadRange performs one resume attempt. The bool reports whether the
+// error is worth retrying (a dropped connection vs. an HTTP error).
+func (c *Client) downloadRange(ctx context.Context, d *rangeDownload) (string, bool, error) {
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", false, fmt.Errorf("could not open file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", false, err
+	}
+	offset := info.Size()
+	if d.noRange || d.size >= 0 && offset >= d.size {
+		// No range support, left over from another version of the file,
+		// or complete but never verified: fetch it again
+		offset = 0
+	}
+
+	req, err := c.newDownloadRequest(ctx, d.fileID)
+	if err != nil {
+		return "", false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if d.validator != "" {
+			req.Header.Set("If-Range", d.validator)
+		}
+	}
+
+	resp, err := c.doDownload(req)
+	if err != nil {
+		return "", true, fmt.Errorf("download request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return "", false, ErrUnauthorized
+	case http.StatusPartialContent:
+		if !rangeMatches(resp.Header.Get("Content-Range"), offset, d.size) {
+			// Not the bytes asked for, or from a file of another size
+			if err := f.Truncate(0); err != nil {
+				return "", false, err
+			}
+			return "", true, fmt.Errorf("download failed: range %q doesn't continue the partial file; starting over", resp.Header.Get("Content-Range"))
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return "", false, err
+		}
+	case http.StatusOK:
+		// No range support (or nothing to resume) — start over
+		if err := f.Truncate(0); err != nil {
+			return "", false, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", false, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Local file is at least as long as the remote — restart from scratch
+		if err := f.Truncate(0); err != nil {
+			return "", false, err
+		}
+		return "", true, fmt.Errorf("download failed (status %d): range not satisfiable", resp.StatusCode)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return "", false, fmt.Errorf("download failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	if d.validator == "" {
+		d.validator = rangeValidator(resp)
+	}
+	filename := dispositionFilename(resp)
+	start, total := int64(0), resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		start = offset
+		if total >= 0 {
+			total += offset
+		}
+	}
+	if _, err := io.Copy(f, c.trackTransfer(resp.Body, start, total)); err != nil {
+		return filename, true, fmt.Errorf("error writing file: %w", err)
+	}
+	return filename, false, nil
+}
+
+// rangeMatches reports whether a 206's Content-Range ("bytes 100-199/200")
+// starts at offset and, when size is known, belongs to a file of that size.
+func rangeMatches(contentRange string, offset, size int64) bool {
+	var first, last int64
+	var total string
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%s", &first, &last, &total); err != nil {
+		return false
+	}
+	if first != offset {
+		return false
+	}
+	if size >= 0 && total != "*" && total != strconv.FormatInt(size, 10) {
+		return false
+	}
+	return true
+}
+
+// rangeValidator returns the response's strong ETag, or its Last-Modified,
+// for If-Range. Weak ETags can't be used there.
+func rangeValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
 // CreateTextFile creates a text file (stored in DB, not S3).
-func (c *Client) CreateTextFile(name, contents, directoryID, contentType string) (*FileEntry, error) {
+func (c *Client) CreateTextFile(ctx context.Context, name, contents, directoryID, contentType string) (*FileEntry, error) {
 	if contentType == "" {
 		contentType = "text/plain"
 	}
@@ -412,7 +751,7 @@ func (c *Client) CreateTextFile(name, contents, directoryID, contentType string)
 		"content_type": contentType,
 	}
 	data, _ := json.Marshal(payload)
-	resp, err := c.do("POST", "/api/v1/files/text", bytes.NewReader(data))
+	resp, err := c.do(ctx, "POST", "/api/v1/files/text", bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -433,9 +772,27 @@ func (c *Client) CreateTextFile(name, contents, directoryID, contentType string)
 }
 
 // UpdateFile updates a file's contents or metadata.
-func (c *Client) UpdateFile(fileID string, updates map[string]string) (*FileEntry, error) {
+func (c *Client) UpdateFile(ctx context.Context, fileID string, updates map[string]string) (*FileEntry, error) {
+	return c.updateFile(ctx, fileID, updates)
+}
+
+// SetFilePublic turns public sharing of a file on or off and returns the
+// updated file, whose URL is the shareable link when the server sends one.
+func (c *Client) SetFilePublic(ctx context.Context, fileID string, public bool) (*FileEntry, error) {
+	return c.updateFile(ctx, fileID, map[string]bool{"public": public})
+}
+
+// SetFileMode stores a file's Unix permission bits on the server and
+// returns the updated file. A server that doesn't store modes returns the
+// file with Mode 0.
+func (c *Client) SetFileMode(ctx context.Context, fileID string, mode uint32) (*FileEntry, error) {
+	return c.updateFile(ctx, fileID, map[string]uint32{"mode": mode})
+}
+
+// updateFile PATCHes a file with any JSON-encodable set of attributes.
+func (c *Client) updateFile(ctx context.Context, fileID string, updates any) (*FileEntry, error) {
 	data, _ := json.Marshal(updates)
-	resp, err := c.do("PATCH", fmt.Sprintf("/api/v1/files/%s", fileID), bytes.NewReader(data))
+	resp, err := c.do(ctx, "PATCH", fmt.Sprintf("/api/v1/files/%s", fileID), bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -456,8 +813,8 @@ func (c *Client) UpdateFile(fileID string, updates map[string]string) (*FileEntr
 }
 
 // DeleteFile soft-deletes a file by ID.
-func (c *Client) DeleteFile(fileID string) error {
-	resp, err := c.do("DELETE", fmt.Sprintf("/api/v1/files/%s", fileID), nil)
+func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf("/api/v1/files/%s", fileID), nil)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -471,8 +828,8 @@ func (c *Client) DeleteFile(fileID string) error {
 }
 
 // DeleteDirectory soft-deletes a directory by ID.
-func (c *Client) DeleteDirectory(dirID string) error {
-	resp, err := c.do("DELETE", fmt.Sprintf("/api/v1/directories/%s", dirID), nil)
+func (c *Client) DeleteDirectory(ctx context.Context, dirID string) error {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf("/api/v1/directories/%s", dirID), nil)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -486,7 +843,7 @@ func (c *Client) DeleteDirectory(dirID string) error {
 }
 
 // MoveFile moves/renames a file (updates name and/or directory).
-func (c *Client) MoveFile(fileID string, newName string, newDirID string) (*FileEntry, error) {
+func (c *Client) MoveFile(ctx context.Context, fileID string, newName string, newDirID string) (*FileEntry, error) {
 	updates := make(map[string]string)
 	if newName != "" {
 		updates["name"] = newName
@@ -494,18 +851,60 @@ func (c *Client) MoveFile(fileID string, newName string, newDirID string) (*File
 	if newDirID != "" {
 		updates["directory_id"] = newDirID
 	}
-	return c.UpdateFile(fileID, updates)
+	return c.UpdateFile(ctx, fileID, updates)
+}
+
+// MoveDirectory renames a directory and/or moves it under a new parent.
+// Empty newName or newParentID leaves that part unchanged.
+func (c *Client) MoveDirectory(ctx context.Context, dirID, newName, newParentID string) (*Directory, error) {
+	updates := make(map[string]string)
+	if newName != "" {
+		updates["name"] = newName
+	}
+	if newParentID != "" {
+		updates["user_directory_id"] = newParentID
+	}
+	return c.updateDirectory(ctx, dirID, updates)
+}
+
+// SetDirectoryPublic turns public sharing of a directory on or off.
+func (c *Client) SetDirectoryPublic(ctx context.Context, dirID string, public bool) (*Directory, error) {
+	return c.updateDirectory(ctx, dirID, map[string]bool{"public": public})
+}
+
+// updateDirectory PATCHes a directory with any JSON-encodable set of
+// attributes.
+func (c *Client) updateDirectory(ctx context.Context, dirID string, updates any) (*Directory, error) {
+	data, _ := json.Marshal(updates)
+	resp, err := c.do(ctx, "PATCH", fmt.Sprintf("/api/v1/directories/%s", dirID), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("update directory failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var wrapper struct {
+		Directory Directory `json:"directory"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+	return &wrapper.Directory, nil
 }
 
 // CreateDirectory creates a new directory on the server.
-func (c *Client) CreateDirectory(name, parentID string) (*Directory, error) {
+func (c *Client) CreateDirectory(ctx context.Context, name, parentID string) (*Directory, error) {
 	payload := map[string]string{"name": name}
 	if parentID != "" {
 		payload["user_directory_id"] = parentID
 	}
 
 	data, _ := json.Marshal(payload)
-	resp, err := c.do("POST", "/api/v1/directories", bytes.NewReader(data))
+	resp, err := c.do(ctx, "POST", "/api/v1/directories", bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -526,6 +925,42 @@ func (c *Client) CreateDirectory(name, parentID string) (*Directory, error) {
 	return &wrapper.Directory, nil
 }
 
+// ErrUnauthorized is returned when the server rejects the API token.
+var ErrUnauthorized = errors.New("invalid or expired token")
+
+// Account represents the token's owner from /api/v1/me.
+type Account struct {
+	ID    string `json:"id"`
+	Email string `json:"email,omitempty"`
+	Name  string `json:"name,omitempty"`
+}
+
+// GetAccount fetches the account the token belongs to.
+// Returns nil with no error if the server has no identity endpoint.
+func (c *Client) GetAccount(ctx context.Context) (*Account, error) {
+	resp, err := c.do(ctx, "GET", "/api/v1/me", nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthorized
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var account Account
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+	return &account, nil
+}
+
 // SyncClientInfo represents a registered sync client.
 type SyncClientInfo struct {
 	ID        string `json:"id"`
@@ -537,14 +972,14 @@ type SyncClientInfo struct {
 }
 
 // RegisterClient registers or updates a sync client with the server.
-func (c *Client) RegisterClient(clientKey, name, platform, version string) (*SyncClientInfo, error) {
+func (c *Client) RegisterClient(ctx context.Context, clientKey, name, platform, version string) (*SyncClientInfo, error) {
 	data, _ := json.Marshal(map[string]string{
 		"client_key": clientKey,
 		"name":       name,
 		"platform":   platform,
 		"version":    version,
 	})
-	resp, err := c.do("POST", "/api/v1/sync/client", bytes.NewReader(data))
+	resp, err := c.do(ctx, "POST", "/api/v1/sync/client", bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -562,12 +997,12 @@ func (c *Client) RegisterClient(clientKey, name, platform, version string) (*Syn
 }
 
 // UpdateClientName updates the name of the current sync client.
-func (c *Client) UpdateClientName(clientKey, name string) (*SyncClientInfo, error) {
+func (c *Client) UpdateClientName(ctx context.Context, clientKey, name string) (*SyncClientInfo, error) {
 	data, _ := json.Marshal(map[string]string{
 		"client_key": clientKey,
 		"name":       name,
 	})
-	resp, err := c.do("PATCH", "/api/v1/sync/client", bytes.NewReader(data))
+	resp, err := c.do(ctx, "PATCH", "/api/v1/sync/client", bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -583,3 +1018,42 @@ func (c *Client) UpdateClientName(clientKey, name string) (*SyncClientInfo, erro
 	}
 	return &info, nil
 }
+
+// ListClients fetches all sync clients registered for the account.
+func (c *Client) ListClients(ctx context.Context) ([]SyncClientInfo, error) {
+	resp, err := c.do(ctx, "GET", "/api/v1/clients", nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("clients: %w", ErrNotSupported)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var wrapper struct {
+		Clients []SyncClientInfo `json:"clients"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return nil, fmt.Errorf("could not decode response: %w", err)
+	}
+	return wrapper.Clients, nil
+}
+
+// DeleteClient revokes a sync client by its client key.
+func (c *Client) DeleteClient(ctx context.Context, clientKey string) error {
+	resp, err := c.do(ctx, "DELETE", fmt.Sprintf("/api/v1/clients/%s", clientKey), nil)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete client failed (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRangeMatches(t *testing.T) {
+	tests := []struct {
+		contentRange string
+		offset, size int64
+		want         bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 100-199/*", 100, 200, true},
+		{"bytes 100-199/200", 100, -1, true},
+		{"bytes 0-199/200", 100, 200, false},
+		{"bytes 100-299/300", 100, 200, false},
+		{"", 100, 200, false},
+	}
+	for _, tt := range tests {
+		if got := rangeMatches(tt.contentRange, tt.offset, tt.size); got != tt.want {
+			t.Errorf("rangeMatches(%q, %d, %d) = %v, want %v", tt.contentRange, tt.offset, tt.size, got, tt.want)
+		}
+	}
+}
+
+// TestDownloadResumableChangedFile resumes a partial file against a server
+// whose copy has since grown: the range it returns belongs to another
+// file, so the download must start over instead of splicing.
+func TestDownloadResumableChangedFile(t *testing.T) {
+	content := "the new, longer version of the file"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err == nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			fmt.Fprint(w, content[start:])
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "file.part")
+	if err := os.WriteFile(path, []byte("the old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(srv.URL, "token")
+	// The caller expected the old, 12-byte file
+	if _, err := c.DownloadFileResumable(context.Background(), "f1", path, 12); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != content {
+		t.Errorf("downloaded %q, want %q", got, content)
+	}
+}
+
+// TestDownloadResumableNoRangeSupport resumes against a server whose
+// capabilities leave out range_downloads: no Range header is sent, and the
+// partial file is replaced by the whole download.
+func TestDownloadResumableNoRangeSupport(t *testing.T) {
+	content := "the whole file"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/capabilities" {
+			fmt.Fprint(w, `{"features":["manifest"]}`)
+			return
+		}
+		if r.Header.Get("Range") != "" {
+			t.Errorf("sent Range %q to a server without range_downloads", r.Header.Get("Range"))
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "file.part")
+	if err := os.WriteFile(path, []byte("the"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(srv.URL, "token")
+	if _, err := c.DownloadFileResumable(context.Background(), "f1", path, int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != content {
+		t.Errorf("downloaded %q, want %q", got, content)
+	}
+}
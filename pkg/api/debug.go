@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugEnv turns on request logging without the --debug flag: "1" (or any
+// true value) logs every request, "bodies" also dumps text bodies.
+const DebugEnv = "IZEROP_DEBUG"
+
+// debugBodyLimit caps how much of each body is dumped.
+const debugBodyLimit = 4 * 1024
+
+// DebugFromEnv reads DebugEnv.
+func DebugFromEnv() (enabled, bodies bool) {
+	return ParseDebug(os.Getenv(DebugEnv))
+}
+
+// ParseDebug reads a debug setting: "bodies" logs requests with their
+// bodies, and any true value logs requests only.
+func ParseDebug(value string) (enabled, bodies bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "bodies" {
+		return true, true
+	}
+	on, _ := strconv.ParseBool(value)
+	return on, false
+}
+
+// EnableDebug logs every request the client makes to w: method, URL,
+// status, and time taken. With bodies it also dumps the request headers and
+// any JSON, text, or form bodies (not uploads or binary downloads).
+// Credentials are always redacted: the Authorization, X-Client-Key, and
+// cookie headers, and the query string of URLs on other hosts, such as the
+// signature of a pre-signed storage URL.
+func (c *Client) EnableDebug(w io.Writer, bodies bool) {
+	if _, ok := c.HTTPClient.Transport.(*debugTransport); ok {
+		return
+	}
+	next := c.HTTPClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	apiHost := ""
+	if u, err := url.Parse(c.BaseURL); err == nil {
+		apiHost = u.Host
+	}
+	c.HTTPClient.Transport = &debugTransport{next: next, w: w, bodies: bodies, apiHost: apiHost}
+}
+
+// debugTransport is the http.RoundTripper installed by EnableDebug.
+type debugTransport struct {
+	next   http.RoundTripper
+	w      io.Writer
+	bodies bool
+	// apiHost is the server's host; URLs elsewhere have their query hidden
+	apiHost string
+	mu      sync.Mutex // keeps lines from parallel transfers whole
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if t.bodies && req.Body != nil && isTextContent(req.Header.Get("Content-Type")) {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var b strings.Builder
+	if err != nil {
+		// The error usually repeats the URL
+		msg := strings.ReplaceAll(err.Error(), req.URL.String(), t.redactURL(req.URL))
+		fmt.Fprintf(&b, "[debug] %s %s → error: %s (%s)\n", req.Method, t.redactURL(req.URL), msg, elapsed)
+	} else {
+		fmt.Fprintf(&b, "[debug] %s %s → %s (%s)\n", req.Method, t.redactURL(req.URL), resp.Status, elapsed)
+	}
+	if t.bodies {
+		t.writeHeaders(&b, "> ", req.Header)
+		writeBody(&b, "> ", reqBody)
+		if resp != nil {
+			t.writeHeaders(&b, "< ", resp.Header)
+			// A redirect's body just repeats its Location
+			if isTextContent(resp.Header.Get("Content-Type")) && resp.Header.Get("Location") == "" {
+				head, _ := io.ReadAll(io.LimitReader(resp.Body, debugBodyLimit+1))
+				resp.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+				writeBody(&b, "< ", head)
+			}
+		}
+	}
+
+	t.mu.Lock()
+	io.WriteString(t.w, b.String())
+	t.mu.Unlock()
+	return resp, err
+}
+
+// secretHeaders are the headers whose values are never logged.
+var secretHeaders = map[string]bool{
+	"Authorization": true,
+	"X-Client-Key":  true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// redactURL returns u for the log, without its password, and without its
+// query unless it is on the API host: a pre-signed storage URL carries its
+// signature there.
+func (t *debugTransport) redactURL(u *url.URL) string {
+	if u.Host == t.apiHost || u.RawQuery == "" {
+		return u.Redacted()
+	}
+	bare := *u
+	bare.RawQuery = ""
+	return bare.Redacted() + "?[redacted]"
+}
+
+// writeHeaders writes headers in sorted order, hiding credentials.
+func (t *debugTransport) writeHeaders(b *strings.Builder, prefix string, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		switch {
+		case secretHeaders[name]:
+			value = "[redacted]"
+		case name == "Location":
+			if u, err := url.Parse(value); err == nil {
+				value = t.redactURL(u)
+			} else {
+				value = "[redacted]"
+			}
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, name, value)
+	}
+}
+
+// writeBody writes up to debugBodyLimit bytes of a body.
+func writeBody(b *strings.Builder, prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	truncated := len(body) > debugBodyLimit
+	if truncated {
+		body = body[:debugBodyLimit]
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		fmt.Fprintf(b, "%s%s\n", prefix, line)
+	}
+	if truncated {
+		fmt.Fprintf(b, "%s(truncated)\n", prefix)
+	}
+}
+
+// isTextContent reports whether a Content-Type is worth dumping.
+func isTextContent(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "application/json") ||
+		strings.HasPrefix(ct, "text/") ||
+		strings.HasPrefix(ct, "application/x-www-form-urlencoded")
+}
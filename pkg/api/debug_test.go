@@ -0,0 +1,42 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugRedacts(t *testing.T) {
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data"))
+	}))
+	defer storage.Close()
+	signed := storage.URL + "/bucket/file?X-Amz-Signature=secretsig&X-Amz-Credential=secretcred"
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secretcookie"})
+		http.Redirect(w, r, signed, http.StatusFound)
+	}))
+	defer api.Close()
+
+	var log bytes.Buffer
+	c := NewClient(api.URL, "secrettoken")
+	c.ClientKey = "secretkey"
+	c.EnableDebug(&log, true)
+	if _, err := c.DownloadFile(context.Background(), "f1", &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := log.String()
+	for _, secret := range []string{"secrettoken", "secretkey", "secretcookie", "secretsig", "secretcred"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("debug log leaks %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, storage.URL+"/bucket/file?[redacted]") {
+		t.Errorf("debug log doesn't show the redacted storage URL:\n%s", out)
+	}
+}
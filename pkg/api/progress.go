@@ -0,0 +1,36 @@
+package api
+
+import "io"
+
+// TransferFunc receives the running byte count of an upload or download.
+// total is -1 when the size is unknown. A final call with done == total is
+// made when the transfer body has been fully read.
+type TransferFunc func(done, total int64)
+
+// progressReader counts bytes read through it and reports them.
+type progressReader struct {
+	r      io.Reader
+	done   int64
+	total  int64
+	report TransferFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if err == io.EOF {
+		p.report(p.done, p.done)
+	} else if n > 0 {
+		p.report(p.done, p.total)
+	}
+	return n, err
+}
+
+// trackTransfer wraps r so OnTransfer sees its progress. start is the number
+// of bytes already transferred (e.g. when resuming a download).
+func (c *Client) trackTransfer(r io.Reader, start, total int64) io.Reader {
+	if c.OnTransfer == nil {
+		return r
+	}
+	return &progressReader{r: r, done: start, total: total, report: c.OnTransfer}
+}
@@ -8,16 +8,90 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"time"
 )
 
 // Config holds the CLI configuration for a single profile.
 type Config struct {
-	ServerURL    string `json:"server_url"`
-	Token        string `json:"token"`
-	SyncDir      string `json:"sync_dir,omitempty"`
-	SettleTimeMs int    `json:"settle_time_ms,omitempty"` // debounce delay before syncing new/changed files (default 12000)
-	ClientKey    string `json:"client_key,omitempty"`     // unique identifier for this client device
-	ClientName   string `json:"client_name,omitempty"`    // human-readable name for this client
+	ServerURL     string `json:"server_url"`
+	Token         string `json:"token"`
+	SyncDir       string `json:"sync_dir,omitempty"`
+	SettleTimeMs  int    `json:"settle_time_ms,omitempty"`  // debounce delay before syncing new/changed files (default 12000)
+	ClientKey     string `json:"client_key,omitempty"`      // unique identifier for this client device
+	ClientName    string `json:"client_name,omitempty"`     // human-readable name for this client
+	PollIntervalS int    `json:"poll_interval_s,omitempty"` // how often the watcher polls the server (default 30)
+	// ReconcileIntervalS is how often (in seconds) the watcher runs a full
+	// manifest reconcile to catch missed changes. 0 disables it.
+	ReconcileIntervalS int `json:"reconcile_interval_s,omitempty"`
+	// SyncRoot is the remote directory this profile's sync dir maps to,
+	// like "work" or "work/notes" (same as --root). Unset means "root".
+	SyncRoot string `json:"sync_root,omitempty"`
+	// TextExtensions and BinaryExtensions override the built-in guess of
+	// which files sync as text notes vs binary uploads (e.g. ".tex", ".svg").
+	TextExtensions   []string `json:"text_extensions,omitempty"`
+	BinaryExtensions []string `json:"binary_extensions,omitempty"`
+	// NoTextAPI makes sync, reconcile, and watch upload every new file as a
+	// binary instead of a text note (same as --no-text-api).
+	NoTextAPI bool `json:"no_text_api,omitempty"`
+	// MaxTextSize makes sync, reconcile, watch, and import upload text
+	// files larger than this as binaries, e.g. "10MB". Unset means
+	// DefaultMaxTextSize.
+	MaxTextSize ByteSize `json:"max_text_size,omitempty"`
+	// NoDelete makes sync, reconcile, and watch skip deletions in both
+	// directions by default (same as --no-delete).
+	NoDelete bool `json:"no_delete,omitempty"`
+	// QuietHours is a daily local-time window like "09:00-17:00" during which
+	// the watcher defers large binary uploads.
+	QuietHours string `json:"quiet_hours,omitempty"`
+	// SkipGrowing makes sync and watch hold back files that are still being
+	// written, such as logs being appended to (same as --skip-growing).
+	SkipGrowing bool `json:"skip_growing,omitempty"`
+	// PreserveMode makes sync, reconcile, and watch keep Unix permission
+	// bits, such as the executable bit, across sync. The server must store
+	// file modes; if it doesn't, the setting is ignored.
+	PreserveMode bool `json:"preserve_mode,omitempty"`
+	// ExcludeVCS makes sync, reconcile, and watch ignore .git, node_modules,
+	// and other VCS/dependency directories (same as --exclude-vcs).
+	ExcludeVCS bool `json:"exclude_vcs,omitempty"`
+	// DefaultIgnore holds .izeropignore-style patterns applied to every sync
+	// dir of this profile, whether or not it has its own .izeropignore.
+	DefaultIgnore []string `json:"default_ignore,omitempty"`
+	// FilterOSJunk skips .DS_Store, Thumbs.db, desktop.ini, and similar
+	// OS-generated files. Unset means on; see OSJunkFiltered.
+	FilterOSJunk *bool `json:"filter_os_junk,omitempty"`
+	// ConflictStrategy is where conflict copies go: "sibling" (default,
+	// name.conflict.ext), "folder" (.izerop-conflicts/), or "timestamped".
+	ConflictStrategy string `json:"conflict_strategy,omitempty"`
+	// SymlinkPolicy is what sync does with symbolic links: "skip" (default),
+	// "follow" to sync their targets, or "store" to sync the links themselves.
+	SymlinkPolicy string `json:"symlink_policy,omitempty"`
+	// StateBackend is how sync state is stored: "json" (default,
+	// sync-state.json) or "sqlite" (sync-state.db, for very large trees).
+	StateBackend string `json:"state_backend,omitempty"`
+	// TimeFormat is how ls, status, and clients show timestamps: a Go
+	// layout like "2006-01-02 15:04" (the default) or "rfc3339".
+	TimeFormat string `json:"time_format,omitempty"`
+	// LogFormat is the watcher's default log format, "text" or "json"
+	// (same as --log-format).
+	LogFormat string `json:"log_format,omitempty"`
+	// TokenSource is "keyring" when the token is kept in the OS keychain;
+	// Token is then omitted from config.json.
+	TokenSource string `json:"token_source,omitempty"`
+	// MaxUploadAttempts makes sync and watch stop retrying a file after
+	// that many failed uploads in a row, until it changes. 0 retries forever.
+	MaxUploadAttempts int `json:"max_upload_attempts,omitempty"`
+	// MaxFileSize makes sync, reconcile, and watch skip uploading files
+	// larger than this, e.g. "500MB" (same as --max-size). 0 means no limit.
+	MaxFileSize ByteSize `json:"max_file_size,omitempty"`
+	// MaxIdleConnsPerHost and IdleConnTimeoutS tune the HTTP connection
+	// pool (advanced; 0 means the defaults of 4 and 90s).
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+	IdleConnTimeoutS    int `json:"idle_conn_timeout_s,omitempty"`
+}
+
+// IdleConnTimeout returns idle_conn_timeout_s as a duration.
+func (c *Config) IdleConnTimeout() time.Duration {
+	return time.Duration(c.IdleConnTimeoutS) * time.Second
 }
 
 // EnsureClientKey generates a client key if one doesn't exist, saves config, and returns it.
@@ -28,10 +102,30 @@ func (c *Config) EnsureClientKey(profile string) string {
 	b := make([]byte, 16)
 	rand.Read(b)
 	c.ClientKey = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
-	SaveProfile(profile, c)
+	UpdateProfile(profile, func(stored *Config) { stored.ClientKey = c.ClientKey })
 	return c.ClientKey
 }
 
+// DefaultMaxTextSize is the largest file uploaded as a text note when
+// max_text_size isn't set. Notes are read whole into memory and stored in
+// the notes database, so a multi-GB log or CSV goes up as a binary.
+const DefaultMaxTextSize ByteSize = 1 << 20
+
+// TextSizeLimit returns max_text_size in bytes, or DefaultMaxTextSize if
+// it isn't set.
+func (c *Config) TextSizeLimit() int64 {
+	if c.MaxTextSize <= 0 {
+		return int64(DefaultMaxTextSize)
+	}
+	return int64(c.MaxTextSize)
+}
+
+// OSJunkFiltered reports whether OS junk files should be skipped
+// (filter_os_junk, default true).
+func (c *Config) OSJunkFiltered() bool {
+	return c.FilterOSJunk == nil || *c.FilterOSJunk
+}
+
 // Platform returns the OS/arch string for client registration.
 func Platform() string {
 	return fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
@@ -41,6 +135,12 @@ func Platform() string {
 // This gives users time to finish renaming files/folders before sync fires.
 const DefaultSettleTimeMs = 12000
 
+// DefaultPollIntervalS is the default watcher server poll interval in seconds.
+const DefaultPollIntervalS = 30
+
+// MinPollIntervalS is the shortest allowed watcher poll interval in seconds.
+const MinPollIntervalS = 5
+
 const DefaultProfile = "default"
 
 // DefaultConfigDir returns the config directory path (~/.config/izerop).
@@ -88,6 +188,25 @@ func ProfileStatePath(name string) (string, error) {
 	return filepath.Join(dir, "sync-state.json"), nil
 }
 
+// ProfileStateDBPath returns the SQLite sync state path for a profile
+// (state_backend sqlite).
+func ProfileStateDBPath(name string) (string, error) {
+	dir, err := ProfileDir(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync-state.db"), nil
+}
+
+// ProfileLockPath returns the sync lock file path for a profile.
+func ProfileLockPath(name string) (string, error) {
+	dir, err := ProfileDir(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync.lock"), nil
+}
+
 // ProfileLogPath returns the log file path for a profile's watcher.
 func ProfileLogPath(name string) (string, error) {
 	dir, err := ProfileDir(name)
@@ -97,6 +216,15 @@ func ProfileLogPath(name string) (string, error) {
 	return filepath.Join(dir, "watch.log"), nil
 }
 
+// GlobalIgnorePath returns the path to the ignore file applied to every sync dir.
+func GlobalIgnorePath() (string, error) {
+	dir, err := DefaultConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ignore"), nil
+}
+
 // ConfigPath returns the full path to the legacy config file.
 func ConfigPath() (string, error) {
 	dir, err := DefaultConfigDir()
@@ -212,6 +340,14 @@ func LoadProfile(name string) (*Config, error) {
 		}
 	}
 
+	if cfg.TokenSource == TokenSourceKeyring && cfg.Token == "" {
+		token, err := LoadToken(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read token from keyring: %w", err)
+		}
+		cfg.Token = token
+	}
+
 	// Env var overrides (only for active profile)
 	if name == GetActiveProfile() {
 		if v := os.Getenv("IZEROP_SERVER_URL"); v != "" {
@@ -230,6 +366,13 @@ func LoadProfile(name string) (*Config, error) {
 		cfg.SettleTimeMs = DefaultSettleTimeMs
 	}
 
+	// Default poll interval if not set, and never poll faster than the minimum
+	if cfg.PollIntervalS <= 0 {
+		cfg.PollIntervalS = DefaultPollIntervalS
+	} else if cfg.PollIntervalS < MinPollIntervalS {
+		cfg.PollIntervalS = MinPollIntervalS
+	}
+
 	if cfg.ServerURL == "" {
 		cfg.ServerURL = "https://izerop.com"
 	}
@@ -253,7 +396,16 @@ func SaveProfile(name string, cfg *Config) error {
 		return fmt.Errorf("could not create profile dir: %w", err)
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	// Keyring profiles keep the token out of config.json
+	onDisk := *cfg
+	if cfg.TokenSource == TokenSourceKeyring && cfg.Token != "" {
+		if err := StoreToken(name, cfg.Token); err != nil {
+			return fmt.Errorf("could not store token in keyring: %w", err)
+		}
+		onDisk.Token = ""
+	}
+
+	data, err := json.MarshalIndent(&onDisk, "", "  ")
 	if err != nil {
 		return fmt.Errorf("could not marshal config: %w", err)
 	}
@@ -275,5 +427,75 @@ func DeleteProfile(name string) error {
 	if err != nil {
 		return err
 	}
+	DeleteToken(name) // best effort — fails harmlessly if the token was never in the keyring
 	return os.RemoveAll(dir)
 }
+
+// ValidProfileName reports whether name is safe as a profile directory
+// name: letters, digits, '-', '_', and '.', not starting with '.'.
+func ValidProfileName(name string) bool {
+	if name == "" || len(name) > 64 || name[0] == '.' {
+		return false
+	}
+	for _, r := range name {
+		ok := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// RenameProfile moves a profile's directory, and with it its config, sync
+// state, and logs, to a new name. A token kept in the keyring moves too, and
+// the active profile marker follows if it named the old profile. It refuses
+// to replace an existing profile.
+func RenameProfile(oldName, newName string) error {
+	if !ValidProfileName(newName) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_', and '.'", newName)
+	}
+	oldDir, err := ProfileDir(oldName)
+	if err != nil {
+		return err
+	}
+	newDir, err := ProfileDir(newName)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(oldDir, "config.json")); err != nil {
+		return fmt.Errorf("profile %q not found", oldName)
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("profile %q already exists", newName)
+	}
+
+	// Copy the keyring token first, so a failure leaves the old profile whole
+	cfg, err := ReadProfile(oldName)
+	if err != nil {
+		return err
+	}
+	keyring := cfg.TokenSource == TokenSourceKeyring
+	if keyring {
+		token, err := LoadToken(oldName)
+		if err != nil {
+			return fmt.Errorf("could not read token from keyring: %w", err)
+		}
+		if err := StoreToken(newName, token); err != nil {
+			return fmt.Errorf("could not store token in keyring: %w", err)
+		}
+	}
+
+	if err := os.Rename(oldDir, newDir); err != nil {
+		if keyring {
+			DeleteToken(newName)
+		}
+		return fmt.Errorf("could not rename profile: %w", err)
+	}
+	if keyring {
+		DeleteToken(oldName)
+	}
+	if GetActiveProfile() == oldName {
+		return SetActiveProfile(newName)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// TokenSourceKeyring marks a profile whose token lives in the OS keychain
+// rather than in config.json.
+const TokenSourceKeyring = "keyring"
+
+// keyringService is the service name tokens are stored under, keyed by profile.
+const keyringService = "izerop"
+
+// ErrNoKeyring means no supported keychain backend is available.
+var ErrNoKeyring = errors.New("no keyring backend available")
+
+// StoreToken saves a profile's API token in the OS keychain
+// (macOS Keychain via `security`, Secret Service via `secret-tool`,
+// Windows Credential Manager via advapi32).
+func StoreToken(profile, token string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// The command goes through `security -i` on stdin so the token
+		// never appears in the process list. -U updates an existing item
+		// instead of failing.
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			securityQuote(keyringService), securityQuote(profile), securityQuote(token))
+		if err := runKeyring(strings.NewReader(command), "security", "-i"); err != nil {
+			return err
+		}
+		// Interactive mode carries on past a failed command, so read it back
+		if stored, err := LoadToken(profile); err != nil || stored != token {
+			return fmt.Errorf("security could not store the token in the keychain")
+		}
+		return nil
+	case "linux":
+		label := fmt.Sprintf("izerop API token (%s)", profile)
+		return runKeyring(strings.NewReader(token), "secret-tool", "store", "--label", label, "service", keyringService, "profile", profile)
+	case "windows":
+		return wincredStore(profile, token)
+	default:
+		return ErrNoKeyring
+	}
+}
+
+// LoadToken reads a profile's API token from the OS keychain.
+func LoadToken(profile string) (string, error) {
+	var out bytes.Buffer
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		err = runKeyringOut(&out, "security", "find-generic-password", "-s", keyringService, "-a", profile, "-w")
+	case "linux":
+		err = runKeyringOut(&out, "secret-tool", "lookup", "service", keyringService, "profile", profile)
+	case "windows":
+		err = wincredLoad(&out, profile)
+	default:
+		return "", ErrNoKeyring
+	}
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(out.String())
+	if token == "" {
+		return "", fmt.Errorf("no token in keyring for profile %q", profile)
+	}
+	return token, nil
+}
+
+// DeleteToken removes a profile's API token from the OS keychain.
+func DeleteToken(profile string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return runKeyring(nil, "security", "delete-generic-password", "-s", keyringService, "-a", profile)
+	case "linux":
+		return runKeyring(nil, "secret-tool", "clear", "service", keyringService, "profile", profile)
+	case "windows":
+		return wincredDelete(profile)
+	default:
+		return ErrNoKeyring
+	}
+}
+
+// securityQuote quotes an argument for a `security -i` command line.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func runKeyring(stdin *strings.Reader, name string, args ...string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		return ErrNoKeyring
+	}
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func runKeyringOut(out *bytes.Buffer, name string, args ...string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		return ErrNoKeyring
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
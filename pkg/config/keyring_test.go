@@ -0,0 +1,17 @@
+package config
+
+import "testing"
+
+func TestSecurityQuote(t *testing.T) {
+	tests := map[string]string{
+		"abc123":     `"abc123"`,
+		"with space": `"with space"`,
+		`q"uote`:     `"q\"uote"`,
+		`back\slash`: `"back\\slash"`,
+	}
+	for in, want := range tests {
+		if got := securityQuote(in); got != want {
+			t.Errorf("securityQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
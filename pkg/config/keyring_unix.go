@@ -0,0 +1,14 @@
+//go:build !windows
+
+package config
+
+import "bytes"
+
+// Windows Credential Manager only exists on Windows; elsewhere the
+// keyring goes through `security` or `secret-tool`.
+
+func wincredStore(profile, token string) error { return ErrNoKeyring }
+
+func wincredLoad(out *bytes.Buffer, profile string) error { return ErrNoKeyring }
+
+func wincredDelete(profile string) error { return ErrNoKeyring }
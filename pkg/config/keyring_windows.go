@@ -0,0 +1,107 @@
+//go:build windows
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	advapi32        = windows.NewLazySystemDLL("advapi32.dll")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1 // CRED_TYPE_GENERIC
+	credPersistLocalMachine = 2 // CRED_PERSIST_LOCAL_MACHINE
+)
+
+// credential mirrors the Win32 CREDENTIALW struct.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// wincredTarget names a profile's entry in Windows Credential Manager.
+func wincredTarget(profile string) (*uint16, error) {
+	return windows.UTF16PtrFromString(keyringService + ":" + profile)
+}
+
+// wincredStore saves the token as a generic credential, replacing any
+// existing one for the profile.
+func wincredStore(profile, token string) error {
+	target, err := wincredTarget(profile)
+	if err != nil {
+		return err
+	}
+	user, err := windows.UTF16PtrFromString(profile)
+	if err != nil {
+		return err
+	}
+	blob := []byte(token)
+	if len(blob) == 0 {
+		return fmt.Errorf("empty token for profile %q", profile)
+	}
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if r, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return fmt.Errorf("CredWrite failed: %w", err)
+	}
+	return nil
+}
+
+// wincredLoad writes the profile's stored token to out.
+func wincredLoad(out *bytes.Buffer, profile string) error {
+	target, err := wincredTarget(profile)
+	if err != nil {
+		return err
+	}
+	var cred *credential
+	if r, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); r == 0 {
+		if errors.Is(err, windows.ERROR_NOT_FOUND) {
+			return fmt.Errorf("no token in keyring for profile %q", profile)
+		}
+		return fmt.Errorf("CredRead failed: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	if cred.CredentialBlobSize > 0 {
+		out.Write(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize))
+	}
+	return nil
+}
+
+// wincredDelete removes the profile's credential.
+func wincredDelete(profile string) error {
+	target, err := wincredTarget(profile)
+	if err != nil {
+		return err
+	}
+	if r, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		return fmt.Errorf("CredDelete failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// readOnlyKeys are settings 'izerop config set' refuses to change: the
+// token and where it lives are managed by login, and the client key
+// identifies this device to the server.
+var readOnlyKeys = map[string]string{
+	"token":        "use 'izerop login' to change the token",
+	"token_source": "use 'izerop login --keyring' to move the token",
+	"client_key":   "it identifies this device to the server",
+}
+
+// Keys returns the config.json setting names in the order Config declares them.
+func Keys() []string {
+	t := reflect.TypeOf(Config{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonKey(t.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// jsonKey returns a field's config.json name ("" if it isn't stored).
+func jsonKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// field finds the Config field stored under key.
+func (c *Config) field(key string) (reflect.Value, error) {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonKey(v.Type().Field(i)) == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown config key %q (see 'izerop config list')", key)
+}
+
+// Get returns the setting stored under key as text: lists are comma
+// separated, and an unset value is "".
+func (c *Config) Get(key string) (string, error) {
+	f, err := c.field(key)
+	if err != nil {
+		return "", err
+	}
+	switch v := f.Interface().(type) {
+	case string:
+		return v, nil
+	case int:
+		if v == 0 {
+			return "", nil
+		}
+		return strconv.Itoa(v), nil
+	case bool:
+		if !v {
+			return "", nil
+		}
+		return "true", nil
+	case *bool:
+		if v == nil {
+			return "", nil
+		}
+		return strconv.FormatBool(*v), nil
+	case []string:
+		return strings.Join(v, ","), nil
+	case ByteSize:
+		if v == 0 {
+			return "", nil
+		}
+		return strconv.FormatInt(int64(v), 10), nil
+	}
+	return "", fmt.Errorf("config key %q has an unsupported type", key)
+}
+
+// Set parses value for the setting stored under key and stores it. Lists
+// take comma-separated values, sizes take units like "500MB", and "" clears
+// the setting back to its default.
+func (c *Config) Set(key, value string) error {
+	if why, ok := readOnlyKeys[key]; ok {
+		return fmt.Errorf("%s can't be set here: %s", key, why)
+	}
+	f, err := c.field(key)
+	if err != nil {
+		return err
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+
+	switch f.Interface().(type) {
+	case string:
+		f.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s %q: want a whole number", key, value)
+		}
+		f.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: want true or false", key, value)
+		}
+		f.SetBool(b)
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: want true or false", key, value)
+		}
+		f.Set(reflect.ValueOf(&b))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		f.Set(reflect.ValueOf(list))
+	case ByteSize:
+		size, err := ParseByteSize(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		f.Set(reflect.ValueOf(size))
+	default:
+		return fmt.Errorf("config key %q has an unsupported type", key)
+	}
+	return nil
+}
+
+// ReadProfile reads a profile's config.json as stored, without the
+// environment overrides, defaults, and keyring token LoadProfile adds, so it
+// can be edited and saved back without picking those up.
+func ReadProfile(name string) (*Config, error) {
+	path, err := ProfileConfigPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("profile %q not found", name)
+		}
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// UpdateProfile applies update to a profile's config.json as stored and
+// saves it. A loaded Config carries project settings, --server, and
+// environment overrides, which must not be written back, so settings the
+// CLI changes on its own go through here. A profile with no config.json
+// yet gets one.
+func UpdateProfile(name string, update func(*Config)) error {
+	cfg, err := ReadProfile(name)
+	if err != nil {
+		path, perr := ProfileConfigPath(name)
+		if perr != nil {
+			return perr
+		}
+		if _, serr := os.Stat(path); !os.IsNotExist(serr) {
+			return err // don't replace a config.json that can't be read
+		}
+		cfg = &Config{}
+	}
+	update(cfg)
+	return SaveProfile(name, cfg)
+}
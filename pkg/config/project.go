@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectConfigDir is the name of the project-local config directory, like .git.
+const ProjectConfigDir = ".izerop"
+
+// ProjectConfig holds project-local settings from <project>/.izerop/config.
+// They are merged over the user profile so everyone syncing the project gets
+// the same settings.
+type ProjectConfig struct {
+	Profile   string   `json:"profile,omitempty"`
+	ServerURL string   `json:"server_url,omitempty"`
+	RootDir   string   `json:"root_dir,omitempty"` // remote root directory name (default "root")
+	Ignore    []string `json:"ignore,omitempty"`   // .izeropignore-style patterns
+
+	// Root is the project directory (the one containing .izerop/).
+	Root string `json:"-"`
+}
+
+// LoadProjectConfig reads dir/.izerop/config.
+// Returns nil with no error if the directory has no project config.
+func LoadProjectConfig(dir string) (*ProjectConfig, error) {
+	path := filepath.Join(dir, ProjectConfigDir, "config")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pc ProjectConfig
+	if err := json.Unmarshal(data, &pc); err != nil {
+		return nil, fmt.Errorf("invalid project config %s: %w", path, err)
+	}
+	pc.Root = dir
+	return &pc, nil
+}
+
+// FindProjectConfig walks up from dir looking for .izerop/config, like git
+// does for .git. Returns nil with no error if there is no project config.
+func FindProjectConfig(dir string) (*ProjectConfig, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		pc, err := LoadProjectConfig(dir)
+		if pc != nil || err != nil {
+			return pc, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// ErrProjectServer means a project config names a server other than the
+// profile's. A checked-in file doesn't get to choose where the profile's
+// token is sent.
+var ErrProjectServer = errors.New("project config names a different server than the profile")
+
+// Apply merges project settings over a profile config. The project root
+// becomes the sync directory. A project server_url is only a check: if it
+// differs from the profile's server, Apply returns ErrProjectServer and
+// cfg keeps the profile's server.
+func (p *ProjectConfig) Apply(cfg *Config) error {
+	cfg.SyncDir = p.Root
+	if p.ServerURL != "" && strings.TrimRight(p.ServerURL, "/") != strings.TrimRight(cfg.ServerURL, "/") {
+		return fmt.Errorf("%w: %s names %s, the profile uses %s", ErrProjectServer,
+			filepath.Join(p.Root, ProjectConfigDir, "config"), p.ServerURL, cfg.ServerURL)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProjectApplyServer(t *testing.T) {
+	tests := []struct {
+		project string
+		wantErr bool
+	}{
+		{"", false},
+		{"https://izerop.com", false},
+		{"https://izerop.com/", false},
+		{"https://evil.example.com", true},
+	}
+	for _, tt := range tests {
+		cfg := &Config{ServerURL: "https://izerop.com", SyncDir: "/home/me/sync"}
+		p := &ProjectConfig{ServerURL: tt.project, Root: "/src/proj"}
+		err := p.Apply(cfg)
+		if got := errors.Is(err, ErrProjectServer); got != tt.wantErr {
+			t.Errorf("server_url %q: err %v, want ErrProjectServer %t", tt.project, err, tt.wantErr)
+		}
+		if cfg.ServerURL != "https://izerop.com" || cfg.SyncDir != "/src/proj" {
+			t.Errorf("server_url %q: ServerURL %q, SyncDir %q", tt.project, cfg.ServerURL, cfg.SyncDir)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes. In config.json it may be a plain number of
+// bytes or a string with a unit, like "500MB" or "2 GiB" (units are
+// powers of 1024).
+type ByteSize int64
+
+var sizeUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"tb", 1 << 40}, {"t", 1 << 40},
+	{"gb", 1 << 30}, {"g", 1 << 30},
+	{"mb", 1 << 20}, {"m", 1 << 20},
+	{"kb", 1 << 10}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+// ParseByteSize parses a size like "40000", "500MB", "1.5g", or "2 GiB".
+func ParseByteSize(s string) (ByteSize, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	v = strings.Replace(v, "ib", "b", 1)
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			v = strings.TrimSpace(strings.TrimSuffix(v, u.suffix))
+			mult = u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || !(n >= 0) || math.IsInf(n, 1) {
+		return 0, fmt.Errorf("invalid size %q (want bytes or a number with KB, MB, GB, or TB)", s)
+	}
+	return ByteSize(n * float64(mult)), nil
+}
+
+// UnmarshalJSON accepts a number of bytes or a size string ("" is 0).
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid size %s", data)
+		}
+		*b = ByteSize(n)
+		return nil
+	}
+	if strings.TrimSpace(s) == "" {
+		*b = 0
+		return nil
+	}
+	size, err := ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
@@ -0,0 +1,141 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ConflictStrategy decides where the local copy of a file goes when both
+// sides changed and the remote version wins (config conflict_strategy).
+type ConflictStrategy string
+
+const (
+	// ConflictSibling saves name.conflict.ext next to the original (default).
+	ConflictSibling ConflictStrategy = "sibling"
+	// ConflictFolder saves the copy under ConflictsDir at the original's
+	// relative path, keeping the working tree clean.
+	ConflictFolder ConflictStrategy = "folder"
+	// ConflictTimestamped saves name.conflict-20240101-120000.ext next to
+	// the original, so repeated conflicts don't overwrite each other.
+	ConflictTimestamped ConflictStrategy = "timestamped"
+)
+
+// ConflictsDir is the folder at the sync dir root used by ConflictFolder.
+// It is hidden, so push never uploads it.
+const ConflictsDir = ".izerop-conflicts"
+
+// conflictTimeFormat is the timestamp layout used by ConflictTimestamped.
+const conflictTimeFormat = "20060102-150405"
+
+var timestampedConflict = regexp.MustCompile(`\.conflict-\d{8}-\d{6}`)
+
+// ConflictDetail describes one conflict: both sides changed Path since the
+// last sync and the server's version won.
+type ConflictDetail struct {
+	Path       string `json:"path"`
+	LocalHash  string `json:"local_hash,omitempty"`
+	RemoteHash string `json:"remote_hash,omitempty"` // empty if the server sent none
+	// ConflictFile is where the local version was saved, relative to the
+	// sync dir. It is empty in a dry run or if the copy failed.
+	ConflictFile string `json:"conflict_file,omitempty"`
+}
+
+// ParseConflictStrategy validates a conflict_strategy value. Empty means
+// ConflictSibling.
+func ParseConflictStrategy(s string) (ConflictStrategy, error) {
+	switch ConflictStrategy(s) {
+	case "", ConflictSibling:
+		return ConflictSibling, nil
+	case ConflictFolder, ConflictTimestamped:
+		return ConflictStrategy(s), nil
+	}
+	return "", fmt.Errorf("unknown conflict_strategy %q (want sibling, folder, or timestamped)", s)
+}
+
+// conflictPath returns where the conflict copy of localPath (inside SyncDir)
+// is saved under the engine's ConflictStrategy.
+func (e *Engine) conflictPath(localPath string) string {
+	dir, name := filepath.Split(localPath)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	switch e.ConflictStrategy {
+	case ConflictFolder:
+		if rel, err := filepath.Rel(e.SyncDir, localPath); err == nil {
+			return filepath.Join(e.SyncDir, ConflictsDir, rel)
+		}
+	case ConflictTimestamped:
+		return filepath.Join(dir, base+".conflict-"+time.Now().Format(conflictTimeFormat)+ext)
+	}
+	return filepath.Join(dir, base+".conflict"+ext)
+}
+
+// saveConflict copies localPath to its conflict path, creating parent
+// directories as needed, and returns the copy's path. Under SymlinkStore a
+// link is copied as a link.
+func (e *Engine) saveConflict(localPath string) (string, error) {
+	conflictPath := e.conflictPath(localPath)
+	if err := os.MkdirAll(filepath.Dir(conflictPath), 0755); err != nil {
+		return conflictPath, err
+	}
+	if e.Symlinks == SymlinkStore {
+		if target, err := os.Readlink(localPath); err == nil {
+			return conflictPath, os.Symlink(target, conflictPath)
+		}
+	}
+	return conflictPath, copyFile(localPath, conflictPath)
+}
+
+// conflictLabel shortens a conflict path for messages.
+func (e *Engine) conflictLabel(conflictPath string) string {
+	if rel, err := filepath.Rel(e.SyncDir, conflictPath); err == nil {
+		return rel
+	}
+	return filepath.Base(conflictPath)
+}
+
+// ConflictOriginal maps a conflict copy's path, relative to the sync dir,
+// to the path of the file it conflicts with. It understands every
+// ConflictStrategy layout; ok is false if rel is not a conflict copy.
+func ConflictOriginal(rel string) (original string, ok bool) {
+	if prefix := ConflictsDir + string(filepath.Separator); strings.HasPrefix(rel, prefix) {
+		return strings.TrimPrefix(rel, prefix), true
+	}
+	dir, name := filepath.Split(rel)
+	if loc := timestampedConflict.FindStringIndex(name); loc != nil {
+		return dir + name[:loc[0]] + name[loc[1]:], true
+	}
+	if strings.Contains(name, ".conflict") {
+		return dir + strings.Replace(name, ".conflict", "", 1), true
+	}
+	return "", false
+}
+
+// FindConflicts returns the conflict copies under syncDir, relative to it,
+// in any ConflictStrategy layout. Hidden directories other than
+// ConflictsDir are not searched.
+func FindConflicts(syncDir string) []string {
+	conflictsRoot := filepath.Join(syncDir, ConflictsDir)
+	var conflicts []string
+	filepath.Walk(syncDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".") && path != syncDir && path != conflictsRoot {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(syncDir, path)
+		if _, ok := ConflictOriginal(rel); ok {
+			conflicts = append(conflicts, rel)
+		}
+		return nil
+	})
+	return conflicts
+}
@@ -0,0 +1,57 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// DriftReport summarizes where the server manifest and local sync state disagree.
+type DriftReport struct {
+	Untracked     []string // on the server but not tracked locally
+	MissingRemote []string // tracked locally but gone from the server
+	HashMismatch  []string // tracked hash differs from the server's content hash
+}
+
+// Clean reports whether no drift was found.
+func (d *DriftReport) Clean() bool {
+	return len(d.Untracked) == 0 && len(d.MissingRemote) == 0 && len(d.HashMismatch) == 0
+}
+
+// CheckDrift compares the server manifest against the tracked records without
+// downloading, uploading, or changing any state.
+func (e *Engine) CheckDrift(ctx context.Context) (*DriftReport, error) {
+	manifest, err := e.Client.GetManifest(ctx, e.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch manifest: %w", err)
+	}
+	remoteByPath := e.manifestByPath(manifest)
+
+	report := &DriftReport{}
+	for relPath, remote := range remoteByPath {
+		if e.ignoredPath(relPath, false) {
+			continue
+		}
+		rec, tracked := e.Store.GetRecord(relPath)
+		if !tracked {
+			report.Untracked = append(report.Untracked, relPath)
+			continue
+		}
+		if rec.Hash != "" && remote.ContentHash != "" && rec.Hash != remote.ContentHash {
+			report.HashMismatch = append(report.HashMismatch, relPath)
+		}
+	}
+	e.Store.EachRecord(func(relPath string, _ FileRecord) {
+		if _, onRemote := remoteByPath[relPath]; !onRemote {
+			report.MissingRemote = append(report.MissingRemote, relPath)
+		}
+	})
+	if err := e.Store.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(report.Untracked)
+	sort.Strings(report.MissingRemote)
+	sort.Strings(report.HashMismatch)
+	return report, nil
+}
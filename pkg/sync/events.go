@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"context"
+	"errors"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+)
+
+// Action names a file-level sync action reported through Engine.OnEvent.
+type Action string
+
+const (
+	ActionUploaded   Action = "uploaded"
+	ActionDownloaded Action = "downloaded"
+	ActionDeleted    Action = "deleted"
+	ActionMoved      Action = "moved"
+	ActionConflict   Action = "conflict"
+	ActionSkipped    Action = "skipped"
+	ActionFailed     Action = "failed"
+	// ActionCreatedDir is a remote directory created by a push. It has no
+	// SyncResult counter.
+	ActionCreatedDir Action = "created_dir"
+)
+
+// Event describes one file-level action taken (or, in a dry run, planned)
+// during a sync. Apart from ActionCreatedDir, events mirror the SyncResult
+// counters one for one.
+type Event struct {
+	Path     string // relative to the sync dir
+	Action   Action
+	Size     int64  // bytes involved, when known
+	Err      error  // set for ActionFailed
+	Reason   string // why the engine chose this action, e.g. "changed locally"
+	RemoteID string // server file or directory ID, when known
+}
+
+// emit reports an event to OnEvent, if set.
+func (e *Engine) emit(ev Event) {
+	if e.OnEvent != nil {
+		e.OnEvent(ev)
+	}
+}
+
+// fail records a per-file error in result and reports it as ActionFailed.
+// A rejected token marks the run as authExpired so it stops early. Nothing
+// is recorded once the run is canceled: the file didn't fail, the run
+// stopped.
+func (e *Engine) fail(ctx context.Context, result *SyncResult, relPath string, err error, msg string) {
+	if ctx.Err() != nil {
+		return
+	}
+	result.addError(msg)
+	if errors.Is(err, api.ErrUnauthorized) {
+		e.authExpired = true
+	}
+	if err == nil {
+		err = errors.New(msg)
+	}
+	e.emit(Event{Path: relPath, Action: ActionFailed, Err: err})
+}
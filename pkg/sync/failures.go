@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+)
+
+// UploadFailure records a local file whose uploads keep failing, e.g. one
+// the server always rejects. Size and LocalMod are the file's at the last
+// attempt: once it changes the count starts over.
+type UploadFailure struct {
+	Count     int    `json:"count"`
+	LastError string `json:"last_error"`
+	LastTry   string `json:"last_try"` // RFC 3339
+	Size      int64  `json:"size"`
+	LocalMod  int64  `json:"local_mod"` // unix timestamp
+}
+
+// StuckUpload is an UploadFailure with the path it belongs to.
+type StuckUpload struct {
+	Path string
+	UploadFailure
+}
+
+// StuckUploads lists the files with recorded upload failures, most
+// failures first.
+func (s *State) StuckUploads() []StuckUpload {
+	stuck := make([]StuckUpload, 0, len(s.UploadFailures))
+	for path, f := range s.UploadFailures {
+		stuck = append(stuck, StuckUpload{Path: path, UploadFailure: f})
+	}
+	sort.Slice(stuck, func(i, j int) bool {
+		if stuck[i].Count != stuck[j].Count {
+			return stuck[i].Count > stuck[j].Count
+		}
+		return stuck[i].Path < stuck[j].Path
+	})
+	return stuck
+}
+
+// uploadFailed counts a failed upload of relPath. An expired token or a
+// canceled run isn't the file's fault, so it isn't counted.
+func (e *Engine) uploadFailed(ctx context.Context, relPath string, info os.FileInfo, err error) {
+	if e.DryRun || errors.Is(err, api.ErrUnauthorized) || ctx.Err() != nil {
+		return
+	}
+	if e.State.UploadFailures == nil {
+		e.State.UploadFailures = make(map[string]UploadFailure)
+	}
+	f := e.State.UploadFailures[relPath]
+	if f.Size != info.Size() || f.LocalMod != info.ModTime().Unix() {
+		f.Count = 0
+	}
+	f.Count++
+	f.LastError = err.Error()
+	f.LastTry = time.Now().UTC().Format(time.RFC3339)
+	f.Size = info.Size()
+	f.LocalMod = info.ModTime().Unix()
+	e.State.UploadFailures[relPath] = f
+}
+
+// uploadSucceeded forgets the failures recorded for relPath.
+func (e *Engine) uploadSucceeded(relPath string) {
+	if !e.DryRun {
+		delete(e.State.UploadFailures, relPath)
+	}
+}
+
+// stuckUpload reports whether relPath has failed to upload
+// MaxUploadAttempts times in a row and hasn't changed since.
+func (e *Engine) stuckUpload(relPath string, info os.FileInfo) (UploadFailure, bool) {
+	f, ok := e.State.UploadFailures[relPath]
+	if !ok || e.MaxUploadAttempts <= 0 {
+		return f, false
+	}
+	unchanged := f.Size == info.Size() && f.LocalMod == info.ModTime().Unix()
+	return f, unchanged && f.Count >= e.MaxUploadAttempts
+}
+
+// pruneUploadFailures drops failures for files that no longer exist locally.
+func (e *Engine) pruneUploadFailures() {
+	if e.DryRun {
+		return
+	}
+	for relPath := range e.State.UploadFailures {
+		if _, err := os.Stat(filepath.Join(e.SyncDir, relPath)); os.IsNotExist(err) {
+			delete(e.State.UploadFailures, relPath)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileTypes decides whether a file syncs as a text note or a binary upload.
+//
+// Precedence, first match wins:
+//  1. .izeropattributes in the sync dir (last matching line wins)
+//  2. binary_extensions from config
+//  3. text_extensions from config
+//  4. built-in defaults and the null-byte heuristic (IsTextFile)
+type FileTypes struct {
+	textExts   map[string]bool
+	binaryExts map[string]bool
+	attrs      []attrRule
+}
+
+// attrRule maps a glob to text or binary, e.g. "*.svg binary".
+type attrRule struct {
+	pattern string
+	text    bool
+}
+
+// LoadFileTypes builds file type rules from config extension lists and the
+// sync dir's .izeropattributes file.
+func LoadFileTypes(syncDir string, textExts, binaryExts []string) *FileTypes {
+	return &FileTypes{
+		textExts:   extSet(textExts),
+		binaryExts: extSet(binaryExts),
+		attrs:      parseAttributesFile(filepath.Join(syncDir, ".izeropattributes")),
+	}
+}
+
+// IsText reports whether a file should go through the text API.
+// relPath is relative to the sync dir and is used for .izeropattributes globs.
+func (t *FileTypes) IsText(path, relPath string, info os.FileInfo) bool {
+	if t != nil {
+		relPath = filepath.ToSlash(relPath)
+		name := filepath.Base(relPath)
+		for i := len(t.attrs) - 1; i >= 0; i-- {
+			if matchPattern(t.attrs[i].pattern, relPath, name) {
+				return t.attrs[i].text
+			}
+		}
+
+		ext := strings.ToLower(filepath.Ext(info.Name()))
+		if t.binaryExts[ext] {
+			return false
+		}
+		if t.textExts[ext] {
+			return true
+		}
+	}
+	return IsTextFile(path, info)
+}
+
+// extSet normalizes extensions to lowercase with a leading dot.
+func extSet(exts []string) map[string]bool {
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return set
+}
+
+// parseAttributesFile reads "<glob> text|binary" lines. A missing file yields no rules.
+func parseAttributesFile(path string) []attrRule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []attrRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[1] {
+		case "text":
+			rules = append(rules, attrRule{pattern: fields[0], text: true})
+		case "binary":
+			rules = append(rules, attrRule{pattern: fields[0], text: false})
+		}
+	}
+	return rules
+}
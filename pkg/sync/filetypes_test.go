@@ -0,0 +1,41 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsTextSizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.csv")
+	big := filepath.Join(dir, "big.csv")
+	if err := os.WriteFile(small, []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(big, []byte(strings.Repeat("1,2\n", 300000)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEngine(nil, dir, &State{})
+	for _, tt := range []struct {
+		path  string
+		limit int64
+		want  bool
+	}{
+		{small, e.MaxTextSize, true},
+		{big, e.MaxTextSize, false}, // 1.2 MB is over the default ceiling
+		{big, 0, true},              // no limit
+		{small, 4, false},
+	} {
+		e.MaxTextSize = tt.limit
+		info, err := os.Stat(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e.isText(tt.path, filepath.Base(tt.path), info); got != tt.want {
+			t.Errorf("isText(%s) with limit %d = %t, want %t", filepath.Base(tt.path), tt.limit, got, tt.want)
+		}
+	}
+}
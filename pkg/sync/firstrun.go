@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// FirstRun describes both sides of a sync dir that has no sync state yet
+// (a fresh install, or after 'izerop state reset'), when nothing records
+// which files the two sides already share.
+type FirstRun struct {
+	LocalFiles  int
+	LocalBytes  int64
+	RemoteFiles int
+	RemoteBytes int64
+	// Both counts paths present locally and on the server; they are
+	// compared, and ones that differ end up as conflicts.
+	Both int
+}
+
+// BothPopulated reports whether files exist on both sides, the case where
+// a first sync can upload, download, or conflict on a large scale.
+func (f *FirstRun) BothPopulated() bool {
+	return f.LocalFiles > 0 && f.RemoteFiles > 0
+}
+
+// CheckFirstRun returns nil if the engine has sync state (tracked files,
+// notes, or a change cursor). Otherwise it walks the sync dir and fetches
+// the server manifest to describe what a first sync would meet. It changes
+// nothing on either side.
+func (e *Engine) CheckFirstRun(ctx context.Context, cursor string) (*FirstRun, error) {
+	if cursor != "" || len(e.State.Notes) > 0 || e.Store.HasRecords() {
+		return nil, nil
+	}
+
+	checks, err := e.CheckIgnores()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := e.Client.GetManifest(ctx, e.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch manifest: %w", err)
+	}
+
+	fr := &FirstRun{}
+	remoteByPath := e.manifestByPath(manifest)
+	for relPath, f := range remoteByPath {
+		if e.ignoredPath(relPath, false) {
+			continue
+		}
+		fr.RemoteFiles++
+		fr.RemoteBytes += f.Size
+	}
+	for _, c := range checks {
+		if c.Ignored {
+			continue
+		}
+		fr.LocalFiles++
+		fr.LocalBytes += c.Size
+		if _, ok := remoteByPath[filepath.ToSlash(c.Path)]; ok {
+			fr.Both++
+		}
+	}
+	return fr, nil
+}
@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Files modified within growingRecent are checked for ongoing writes;
+// the second stat happens growingProbeDelay after the first.
+const (
+	growingRecent     = 2 * time.Minute
+	growingProbeDelay = 500 * time.Millisecond
+)
+
+// growingFile is a file PushSync held back to check for ongoing writes.
+type growingFile struct {
+	path string
+	info os.FileInfo
+}
+
+// recentlyModified reports whether a file was touched recently enough that
+// it might still be being written. Older files are assumed stable.
+func recentlyModified(info os.FileInfo) bool {
+	return time.Since(info.ModTime()) <= growingRecent
+}
+
+// stillWriting returns the paths of files that look like they are still
+// being written: open for writing by some process (Linux only), or with a
+// size or modification time that changes across two stats a short delay
+// apart. /proc is scanned once and the delay is shared by all the files.
+// Files that settled get their second stat's info.
+func stillWriting(files []growingFile) map[string]bool {
+	writing := make(map[string]bool)
+	open := openForWriting()
+	for _, f := range files {
+		if abs, err := filepath.Abs(f.path); err == nil && open[abs] {
+			writing[f.path] = true
+		}
+	}
+	if len(writing) == len(files) {
+		return writing
+	}
+
+	time.Sleep(growingProbeDelay)
+	for i, f := range files {
+		if writing[f.path] {
+			continue
+		}
+		again, err := os.Stat(f.path)
+		if err != nil || again.Size() != f.info.Size() || !again.ModTime().Equal(f.info.ModTime()) {
+			writing[f.path] = true
+			continue
+		}
+		files[i].info = again
+	}
+	return writing
+}
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// openForWriting scans /proc for file descriptors opened with O_WRONLY or
+// O_RDWR and returns the files they refer to. Processes we may not inspect
+// are skipped, so this is best effort.
+func openForWriting() map[string]bool {
+	open := make(map[string]bool)
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return open
+	}
+	for _, p := range procs {
+		if _, err := strconv.Atoi(p.Name()); err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", p.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !filepath.IsAbs(target) || open[target] {
+				continue
+			}
+			if fdWritable(filepath.Join("/proc", p.Name(), "fdinfo", fd.Name())) {
+				open[target] = true
+			}
+		}
+	}
+	return open
+}
+
+// fdWritable reads the octal "flags:" line of a /proc/<pid>/fdinfo entry.
+func fdWritable(fdinfo string) bool {
+	f, err := os.Open(fdinfo)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "flags:") {
+			continue
+		}
+		flags, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "flags:")), 8, 64)
+		if err != nil {
+			return false
+		}
+		return flags&uint64(os.O_WRONLY|os.O_RDWR) != 0
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package sync
+
+// openForWriting is only implemented on Linux; elsewhere stillWriting relies
+// on the size/mtime probe alone.
+func openForWriting() map[string]bool {
+	return nil
+}
@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestStillWriting(t *testing.T) {
+	dir := t.TempDir()
+	stable := filepath.Join(dir, "stable.log")
+	open := filepath.Join(dir, "open.log")
+	for _, path := range []string{stable, open} {
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.OpenFile(open, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var files []growingFile
+	for _, path := range []string{stable, open} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, growingFile{path: path, info: info})
+	}
+	writing := stillWriting(files)
+	if writing[stable] {
+		t.Error("stable file reported as still writing")
+	}
+	// Only Linux can see who has a file open
+	if !writing[open] && runtime.GOOS == "linux" {
+		t.Error("file open for writing not reported")
+	}
+}
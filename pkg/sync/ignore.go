@@ -3,90 +3,225 @@ package sync
 import (
 	"bufio"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+
+	"github.com/patricksimpson/izerop-cli/pkg/config"
 )
 
 // IgnoreRules holds parsed ignore patterns.
 type IgnoreRules struct {
 	patterns []ignorePattern
+	// defaultIgnore is set in include mode: files are ignored unless a
+	// pattern includes them.
+	defaultIgnore bool
 }
 
 type ignorePattern struct {
 	pattern  string
 	negated  bool
 	dirOnly  bool
+	include  bool // from an include-mode file, where negated means "sync this"
+	source   string // the line as written, for reporting
+}
+
+// includeDirective, as the first line of a sync dir's .izeropignore,
+// switches it to include mode: only files matching its patterns are
+// synced, and a "!" line excludes again.
+const includeDirective = "# mode: include"
+
+// VCSIgnorePatterns is the curated ignore set applied by --exclude-vcs:
+// version control metadata plus dependency and cache directories that are
+// rebuilt locally and rarely belong in a synced folder.
+var VCSIgnorePatterns = []string{
+	".git/",
+	".svn/",
+	".hg/",
+	".bzr/",
+	"CVS/",
+	"_darcs/",
+	"node_modules/",
+	"bower_components/",
+	"__pycache__/",
+	".venv/",
+	".tox/",
+	".gradle/",
+	".terraform/",
+}
+
+// VCSIgnoreRules returns the built-in rules for VCSIgnorePatterns.
+func VCSIgnoreRules() *IgnoreRules {
+	rules := &IgnoreRules{}
+	rules.AddPatterns(VCSIgnorePatterns)
+	return rules
 }
 
 // LoadIgnoreFile reads a .izeropignore file and returns parsed rules.
 func LoadIgnoreFile(syncDir string) *IgnoreRules {
+	patterns, include := parseIgnoreFile(filepath.Join(syncDir, ".izeropignore"))
+	return &IgnoreRules{patterns: patterns, defaultIgnore: include}
+}
+
+// LoadIgnoreRules combines the global ignore file (~/.config/izerop/ignore),
+// the profile's default_ignore patterns, the project config's ignore list
+// (.izerop/config), and the sync dir's .izeropignore. Later rules take
+// precedence, e.g. a local "!pattern" re-includes a globally ignored file.
+// Only the sync dir's .izeropignore can switch the rules to include mode.
+func LoadIgnoreRules(syncDir string, profileDefaults ...string) *IgnoreRules {
 	rules := &IgnoreRules{}
+	if globalPath, err := config.GlobalIgnorePath(); err == nil {
+		global, _ := parseIgnoreFile(globalPath)
+		rules.patterns = append(rules.patterns, global...)
+	}
+	rules.AddPatterns(profileDefaults)
+	if project, _ := config.LoadProjectConfig(syncDir); project != nil {
+		rules.AddPatterns(project.Ignore)
+	}
+	local, include := parseIgnoreFile(filepath.Join(syncDir, ".izeropignore"))
+	rules.patterns = append(rules.patterns, local...)
+	rules.defaultIgnore = include
+	return rules
+}
+
+// IncludeMode reports whether the rules only sync files that a pattern
+// includes.
+func (r *IgnoreRules) IncludeMode() bool {
+	return r.defaultIgnore
+}
+
+// Len returns the number of patterns.
+func (r *IgnoreRules) Len() int {
+	return len(r.patterns)
+}
 
-	path := filepath.Join(syncDir, ".izeropignore")
+// AddPatterns appends .izeropignore-style pattern lines to the rules.
+// Later patterns take precedence over earlier ones.
+func (r *IgnoreRules) AddPatterns(lines []string) {
+	for _, line := range lines {
+		if p, ok := parseIgnoreLine(line); ok {
+			r.patterns = append(r.patterns, p)
+		}
+	}
+}
+
+// Merge returns rules with r's patterns followed by later's, so later's
+// patterns (including "!" negations) take precedence. The result is in
+// include mode if either side is.
+func (r *IgnoreRules) Merge(later *IgnoreRules) *IgnoreRules {
+	merged := &IgnoreRules{defaultIgnore: r.defaultIgnore}
+	merged.patterns = append(merged.patterns, r.patterns...)
+	if later != nil {
+		merged.patterns = append(merged.patterns, later.patterns...)
+		merged.defaultIgnore = merged.defaultIgnore || later.defaultIgnore
+	}
+	return merged
+}
+
+// parseIgnoreFile reads ignore patterns from a file. A missing file yields no patterns.
+// include reports whether the file starts with includeDirective; its
+// patterns are then flipped so that a plain line un-ignores.
+func parseIgnoreFile(path string) (patterns []ignorePattern, include bool) {
 	f, err := os.Open(path)
 	if err != nil {
-		return rules // no ignore file = no rules
+		return nil, false // no ignore file = no rules
 	}
 	defer f.Close()
 
+	first := true
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+		line := scanner.Text()
+		if first && strings.TrimSpace(line) != "" {
+			first = false
+			if strings.Join(strings.Fields(line), " ") == includeDirective {
+				include = true
+				continue
+			}
 		}
+		if p, ok := parseIgnoreLine(line); ok {
+			if include {
+				p.include = true
+				p.negated = !p.negated
+			}
+			patterns = append(patterns, p)
+		}
+	}
+
+	return patterns, include
+}
 
-		p := ignorePattern{}
+// parseIgnoreLine parses one pattern line. Blank lines and comments yield false.
+func parseIgnoreLine(line string) (ignorePattern, bool) {
+	line = strings.TrimSpace(line)
 
-		// Negation
-		if strings.HasPrefix(line, "!") {
-			p.negated = true
-			line = line[1:]
-		}
+	// Skip empty lines and comments
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
 
-		// Directory-only pattern
-		if strings.HasSuffix(line, "/") {
-			p.dirOnly = true
-			line = strings.TrimSuffix(line, "/")
-		}
+	p := ignorePattern{source: line}
 
-		p.pattern = line
-		rules.patterns = append(rules.patterns, p)
+	// Negation
+	if strings.HasPrefix(line, "!") {
+		p.negated = true
+		line = line[1:]
 	}
 
-	return rules
+	// Directory-only pattern
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	p.pattern = line
+	return p, true
 }
 
 // IsIgnored checks if a relative path should be ignored.
 // isDir indicates whether the path is a directory.
 func (r *IgnoreRules) IsIgnored(relPath string, isDir bool) bool {
-	if len(r.patterns) == 0 {
-		return false
+	ignored, _ := r.Match(relPath, isDir)
+	return ignored
+}
+
+// Match is IsIgnored that also returns the pattern line that decided it
+// ("" when no pattern matched, e.g. a file left out by include mode).
+func (r *IgnoreRules) Match(relPath string, isDir bool) (ignored bool, rule string) {
+	if len(r.patterns) == 0 && !r.defaultIgnore {
+		return false, ""
 	}
 
 	// Normalize to forward slashes
 	relPath = filepath.ToSlash(relPath)
 	name := filepath.Base(relPath)
 
-	ignored := false
+	// In include mode directories start out visible, so the walk still
+	// reaches included files inside them; only an explicit "!dir/" hides one.
+	ignored = r.defaultIgnore && !isDir
 	for _, p := range r.patterns {
+		matched := false
 		if p.dirOnly && !isDir {
-			continue
+			// An include-mode "dir/" line covers every file below dir
+			if !p.include {
+				continue
+			}
+			matched = matchAncestor(p.pattern, relPath)
+		} else {
+			matched = matchPattern(p.pattern, relPath, name)
 		}
 
-		matched := matchPattern(p.pattern, relPath, name)
 		if matched {
 			if p.negated {
 				ignored = false
 			} else {
 				ignored = true
 			}
+			rule = p.source
 		}
 	}
 
-	return ignored
+	return ignored, rule
 }
 
 // matchPattern checks if a pattern matches a path.
@@ -114,53 +249,50 @@ func matchPattern(pattern, relPath, name string) bool {
 	return matched
 }
 
-// matchDoublestar handles ** patterns (match any number of directories).
-func matchDoublestar(pattern, path string) bool {
-	if !strings.Contains(pattern, "**") {
-		return false
-	}
-
-	parts := strings.Split(pattern, "**")
-	if len(parts) != 2 {
-		return false // only support single **
+// matchAncestor reports whether pattern matches any directory above relPath.
+func matchAncestor(pattern, relPath string) bool {
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if matchPattern(pattern, dir, path.Base(dir)) {
+			return true
+		}
 	}
+	return false
+}
 
-	prefix := parts[0]
-	suffix := parts[1]
-
-	// Remove leading/trailing slashes from suffix
-	suffix = strings.TrimPrefix(suffix, "/")
-
-	if prefix != "" && !strings.HasPrefix(path, prefix) {
+// matchDoublestar handles ** patterns segment by segment. A "**" segment
+// matches any number of directories: "**/x" matches x at any depth,
+// "a/**/b" matches b anywhere below a (including a/b), and "x/**" matches
+// everything inside x but not x itself.
+func matchDoublestar(pattern, relPath string) bool {
+	if !strings.Contains(pattern, "**") {
 		return false
 	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(relPath, "/"))
+}
 
-	if suffix == "" {
-		return true
-	}
-
-	// Check if any suffix of path matches the suffix pattern
-	remaining := path
-	if prefix != "" {
-		remaining = strings.TrimPrefix(path, prefix)
-	}
-
-	// Walk through path segments looking for suffix match
-	segments := strings.Split(remaining, "/")
-	for i := range segments {
-		subpath := strings.Join(segments[i:], "/")
-		matched, _ := filepath.Match(suffix, subpath)
-		if matched {
-			return true
-		}
-		// Also match just the last segment
-		if i == len(segments)-1 {
-			matched, _ = filepath.Match(suffix, segments[i])
-			if matched {
-				return true
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment consumes zero or more path segments.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			if len(rest) == 0 {
+				return len(segs) > 0
+			}
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(rest, segs[i:]) {
+					return true
+				}
 			}
+			return false
 		}
+		if len(segs) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pat[0], segs[0]); !matched {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
 	}
-
-	return false
+	return len(segs) == 0
 }
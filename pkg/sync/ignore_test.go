@@ -0,0 +1,182 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+)
+
+func TestIgnoreDoublestar(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		// a/**/b: b anywhere below a, including directly inside it
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**/b", "b", false, false},
+		{"a/**/b", "c/a/x/b", false, false},
+		{"a/**/b", "a/x/b/c", false, false},
+
+		// **/x: x at any depth
+		{"**/x", "x", false, true},
+		{"**/x", "a/x", false, true},
+		{"**/x", "a/b/x", true, true},
+		{"**/x", "a/xy", false, false},
+		{"**/x", "x/a", false, false},
+
+		// x/**: everything inside x, but not x itself
+		{"x/**", "x/a", false, true},
+		{"x/**", "x/a/b", false, true},
+		{"x/**", "x", true, false},
+		{"x/**", "y/x/a", false, false},
+
+		// **/node_modules/: the directory at any depth, never a file
+		{"**/node_modules/", "node_modules", true, true},
+		{"**/node_modules/", "web/node_modules", true, true},
+		{"**/node_modules/", "a/b/node_modules", true, true},
+		{"**/node_modules/", "web/node_modules", false, false},
+		{"**/node_modules/", "web/node_modules_old", true, false},
+	}
+	for _, tt := range tests {
+		rules := &IgnoreRules{}
+		rules.AddPatterns([]string{tt.pattern})
+		if got := rules.IsIgnored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("%q ignores %q (dir %v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreIncludeMode(t *testing.T) {
+	type check struct {
+		path  string
+		isDir bool
+		want  bool // ignored
+	}
+	tests := []struct {
+		name     string
+		global   string   // ~/.config/izerop/ignore
+		defaults []string // the profile's default_ignore
+		local    string   // the sync dir's .izeropignore
+		vcs      bool     // --exclude-vcs
+		checks   []check
+	}{
+		{
+			name:  "only matching files sync",
+			local: "# mode: include\n*.md\n",
+			checks: []check{
+				{"notes.md", false, false},
+				{"notes.txt", false, true},
+				{"docs/guide.md", false, false},
+				{"docs/logo.png", false, true},
+			},
+		},
+		{
+			name:  "directories stay visible",
+			local: "# mode: include\n*.md\n",
+			checks: []check{
+				{"docs", true, false},
+				{"docs/deep/er", true, false},
+			},
+		},
+		{
+			name:  "negated directory is excluded",
+			local: "# mode: include\n*.md\n!drafts/\n",
+			checks: []check{
+				{"drafts", true, true},
+				{"docs", true, false},
+				{"notes.md", false, false},
+			},
+		},
+		{
+			name:  "directory line covers files below it",
+			local: "# mode: include\ndocs/\n",
+			checks: []check{
+				{"docs", true, false},
+				{"docs/logo.png", false, false},
+				{"docs/sub/data.bin", false, false},
+				{"logo.png", false, true},
+				{"docsx/logo.png", false, true},
+			},
+		},
+		{
+			name:     "global and default_ignore excludes still apply",
+			global:   "*.log\n",
+			defaults: []string{"tmp/"},
+			local:    "# mode: include\n*.md\n",
+			checks: []check{
+				{"debug.log", false, true},
+				{"tmp", true, true},
+				{"notes.md", false, false},
+			},
+		},
+		{
+			name:   "include line re-includes a global exclude",
+			global: "*.log\n",
+			local:  "# mode: include\n*.md\n*.log\n",
+			checks: []check{
+				{"debug.log", false, false},
+			},
+		},
+		{
+			name:  "exclude-vcs hides VCS directories",
+			local: "# mode: include\n*.md\n",
+			vcs:   true,
+			checks: []check{
+				{".git", true, true},
+				{"node_modules", true, true},
+				{"src", true, false},
+				{"README.md", false, false},
+			},
+		},
+		{
+			name:  "include line brings back a VCS directory",
+			local: "# mode: include\nnode_modules/\n",
+			vcs:   true,
+			checks: []check{
+				{"node_modules", true, false},
+				{"node_modules/pkg/index.js", false, false},
+				{".git", true, true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			if tt.global != "" {
+				path, err := config.GlobalIgnorePath()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.global), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, ".izeropignore"), []byte(tt.local), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			rules := LoadIgnoreRules(dir, tt.defaults...)
+			if tt.vcs {
+				rules = VCSIgnoreRules().Merge(rules)
+			}
+			if !rules.IncludeMode() {
+				t.Fatal("rules not in include mode")
+			}
+			for _, c := range tt.checks {
+				if got := rules.IsIgnored(c.path, c.isDir); got != c.want {
+					t.Errorf("%q (dir %v) ignored = %v, want %v", c.path, c.isDir, got, c.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package sync
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IgnoreCheck is one path examined by CheckIgnores.
+type IgnoreCheck struct {
+	Path    string
+	IsDir   bool
+	Size    int64
+	Ignored bool
+	// Rule is why an ignored path is left out: the ignore pattern line, or
+	// "(hidden)", "(OS junk)", "(not included)" in include mode, or why the
+	// symlink policy left a link out, like "(symlink)".
+	Rule string
+}
+
+// CheckIgnores walks the sync dir the way PushSync does and returns every
+// file it would consider plus every path it would leave out, with the rule
+// responsible. An excluded directory is listed once and not descended into.
+// It only reads the local filesystem: no server calls, no state changes.
+func (e *Engine) CheckIgnores() ([]IgnoreCheck, error) {
+	var checks []IgnoreCheck
+	err := e.walk(func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		relPath, _ := filepath.Rel(e.SyncDir, path)
+		if relPath == "." {
+			return nil
+		}
+
+		c := IgnoreCheck{Path: relPath, IsDir: info.IsDir()}
+		if !info.IsDir() {
+			c.Size = info.Size()
+		}
+		switch {
+		case strings.HasPrefix(info.Name(), "."):
+			c.Ignored, c.Rule = true, "(hidden)"
+		case e.FilterOSJunk && !info.IsDir() && IsOSJunk(info.Name()):
+			c.Ignored, c.Rule = true, "(OS junk)"
+		case e.Ignore != nil:
+			c.Ignored, c.Rule = e.Ignore.Match(relPath, info.IsDir())
+			if c.Ignored && c.Rule == "" {
+				c.Rule = "(not included)"
+			}
+		}
+
+		if c.Ignored {
+			checks = append(checks, c)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			checks = append(checks, c)
+		}
+		return nil
+	}, func(path, reason string) {
+		relPath, _ := filepath.Rel(e.SyncDir, path)
+		if !strings.HasPrefix(filepath.Base(path), ".") {
+			checks = append(checks, IgnoreCheck{Path: relPath, Ignored: true, Rule: "(" + reason + ")"})
+		}
+	})
+	return checks, err
+}
+
+// IgnoreProblem is a line of .izeropignore content that can't work as
+// written.
+type IgnoreProblem struct {
+	Line    int    `json:"line"` // 1-based
+	Text    string `json:"text"`
+	Problem string `json:"problem"`
+}
+
+// ValidateIgnoreRules checks .izeropignore content before it is saved. It
+// reports lines whose glob is malformed, and lines meant to bring paths
+// back ("!" lines, or plain lines in include mode) that match nothing in
+// syncDir, which usually means a typo. syncDir "" skips the second check.
+func ValidateIgnoreRules(content, syncDir string) []IgnoreProblem {
+	var problems []IgnoreProblem
+	var reinclude []ignorePattern
+	var lines []int
+
+	include, first := false, true
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if first && strings.TrimSpace(line) != "" {
+			first = false
+			if strings.Join(strings.Fields(line), " ") == includeDirective {
+				include = true
+				continue
+			}
+		}
+		p, ok := parseIgnoreLine(line)
+		if !ok {
+			continue
+		}
+		if p.pattern == "" {
+			problems = append(problems, IgnoreProblem{Line: i + 1, Text: p.source, Problem: "empty pattern"})
+			continue
+		}
+		if badGlob(p.pattern) {
+			problems = append(problems, IgnoreProblem{Line: i + 1, Text: p.source, Problem: "malformed glob (check [ ] brackets and trailing \\)"})
+			continue
+		}
+		if include {
+			p.include = true
+			p.negated = !p.negated
+		}
+		if p.negated {
+			reinclude = append(reinclude, p)
+			lines = append(lines, i+1)
+		}
+	}
+
+	if syncDir == "" || len(reinclude) == 0 {
+		return problems
+	}
+	matched := make([]bool, len(reinclude))
+	remaining := len(reinclude)
+	filepath.Walk(syncDir, func(walkPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		relPath, _ := filepath.Rel(syncDir, walkPath)
+		if relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+		for i, p := range reinclude {
+			if matched[i] {
+				continue
+			}
+			switch {
+			case p.dirOnly && !info.IsDir():
+				matched[i] = p.include && matchAncestor(p.pattern, relPath)
+			default:
+				matched[i] = matchPattern(p.pattern, relPath, info.Name())
+			}
+			if matched[i] {
+				remaining--
+			}
+		}
+		if remaining == 0 {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	for i, p := range reinclude {
+		if !matched[i] {
+			problems = append(problems, IgnoreProblem{Line: lines[i], Text: p.source, Problem: "matches nothing in the sync directory"})
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Line < problems[j].Line })
+	return problems
+}
+
+// badGlob reports whether pattern has a syntax error in any path segment.
+func badGlob(pattern string) bool {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return true
+		}
+	}
+	return false
+}
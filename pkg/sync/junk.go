@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// osJunkNames are files operating systems drop into folders on their own
+// (lowercased, since Windows treats them case-insensitively).
+var osJunkNames = map[string]bool{
+	".ds_store":   true,
+	".localized":  true,
+	"thumbs.db":   true,
+	"ehthumbs.db": true,
+	"desktop.ini": true,
+}
+
+// IsOSJunk reports whether a file name is OS-generated clutter such as
+// .DS_Store, Thumbs.db, desktop.ini, or a macOS "._" AppleDouble file.
+func IsOSJunk(name string) bool {
+	return osJunkNames[strings.ToLower(name)] || strings.HasPrefix(name, "._")
+}
+
+// isIgnored reports whether relPath is excluded by the ignore rules or, with
+// FilterOSJunk, is an OS junk file.
+func (e *Engine) isIgnored(relPath string, isDir bool) bool {
+	if e.FilterOSJunk && !isDir && IsOSJunk(filepath.Base(relPath)) {
+		return true
+	}
+	return e.Ignore != nil && e.Ignore.IsIgnored(relPath, isDir)
+}
+
+// ignoredPath is isIgnored for a path found outside a walk of the sync dir
+// (a tracked record, a server change): it is also ignored if any directory
+// above it is, as the walk would have skipped that directory.
+func (e *Engine) ignoredPath(relPath string, isDir bool) bool {
+	for dir := filepath.Dir(relPath); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if e.isIgnored(dir, true) {
+			return true
+		}
+	}
+	return e.isIgnored(relPath, isDir)
+}
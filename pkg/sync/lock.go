@@ -0,0 +1,104 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/config"
+)
+
+// ErrLocked means another process holds the profile's sync lock.
+var ErrLocked = errors.New("another sync is in progress")
+
+// lockPoll is how often AcquireLock retries while waiting.
+const lockPoll = 250 * time.Millisecond
+
+// Lock is an advisory per-profile lock held around runs that change sync
+// state, so two syncs never interleave their state saves. It is an OS file
+// lock (flock, or LockFileEx on Windows) on the profile's lock file, so the
+// OS releases it when the holder exits, however it exits.
+type Lock struct {
+	f *os.File
+}
+
+// LockPath returns the path to the sync lock file for a profile.
+func LockPath(profile string) (string, error) {
+	return config.ProfileLockPath(profile)
+}
+
+// AcquireLock takes the profile's sync lock, waiting up to wait for the
+// current holder to finish. If the lock is still held the error wraps
+// ErrLocked. The lock is per open file, so a second AcquireLock in the same
+// process waits like any other.
+func AcquireLock(profile string, wait time.Duration) (*Lock, error) {
+	path, err := LockPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// The file itself is never removed: a waiter could lock the unlinked
+	// file while a newcomer creates and locks a fresh one.
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			if pid := lockHolder(path); pid > 0 {
+				return nil, fmt.Errorf("%w (PID %d holds %s)", ErrLocked, pid, path)
+			}
+			return nil, fmt.Errorf("%w (%s is held)", ErrLocked, path)
+		}
+		time.Sleep(lockPoll)
+	}
+
+	// The PID is only for the error message above; the OS lock is what counts
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// lockHolder returns the PID written in the lock file at path, or 0 if
+// there is none.
+func lockHolder(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+// Release gives up the lock. It is safe to call on a nil Lock and more
+// than once.
+func (l *Lock) Release() {
+	if l == nil || l.f == nil {
+		return
+	}
+	l.f.Truncate(0)
+	unlockFile(l.f)
+	l.f.Close()
+	l.f = nil
+}
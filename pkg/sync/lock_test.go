@@ -0,0 +1,27 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAcquireLock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	lock, err := AcquireLock("test", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock is per open file, so the same process can't take it twice
+	if _, err := AcquireLock("test", 0); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second acquire: got %v, want ErrLocked", err)
+	}
+
+	lock.Release()
+	lock.Release()
+	again, err := AcquireLock("test", 0)
+	if err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+	again.Release()
+}
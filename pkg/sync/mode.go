@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// File modes are synced when Engine.PreserveMode is set and the server
+// stores them (api.FeatureFileModes). Windows has no Unix permission bits,
+// so modes are neither applied nor sent there; sending its 0666 would strip
+// the executable bit other machines set.
+
+// fileMode returns the Unix permission bits of info.
+func fileMode(info os.FileInfo) uint32 {
+	return uint32(info.Mode().Perm())
+}
+
+// syncsModes reports whether this run applies and sends file modes.
+func (e *Engine) syncsModes() bool {
+	return e.PreserveMode && !e.modesUnsupported && runtime.GOOS != "windows"
+}
+
+// applyRemoteMode sets a file just downloaded to the server's permission
+// bits and returns the mode to record (0 when modes aren't synced or the
+// server has none for the file, leaving the default permissions).
+func (e *Engine) applyRemoteMode(localPath string, mode uint32) uint32 {
+	if !e.syncsModes() || mode == 0 || e.DryRun {
+		return 0
+	}
+	// Keep the file readable by its owner so later syncs can hash it
+	perm := os.FileMode(mode)&os.ModePerm | 0400
+	if err := os.Chmod(localPath, perm); err != nil {
+		return 0
+	}
+	return uint32(perm)
+}
+
+// syncMode brings the permission bits of a file whose content is in sync
+// into line with the server and returns the mode to record. A mode changed
+// locally since the last sync is sent; one changed only on the server is
+// applied locally. A server with no mode for a file is left alone unless
+// the local copy is executable.
+func (e *Engine) syncMode(ctx context.Context, result *SyncResult, relPath, path, remoteID string, info os.FileInfo, remoteMode uint32) uint32 {
+	if !e.syncsModes() || remoteID == "" {
+		return 0
+	}
+	local := fileMode(info)
+	rec, _ := e.Store.GetRecord(relPath)
+	switch {
+	case local == remoteMode:
+		return local
+	case remoteMode == 0 && local&0111 == 0:
+		return 0
+	case remoteMode != 0 && rec.Mode == local:
+		return e.applyRemoteMode(path, remoteMode)
+	}
+
+	if e.Verbose || e.DryRun {
+		fmt.Fprintf(e.out(), "  🔐 Mode %04o: %s\n", local, relPath)
+	}
+	if e.DryRun {
+		return local
+	}
+	updated, err := e.Client.SetFileMode(ctx, remoteID, local)
+	if err != nil {
+		e.fail(ctx, result, relPath, err, fmt.Sprintf("set mode %s: %v", relPath, err))
+		return rec.Mode
+	}
+	if updated.Mode == 0 {
+		// The server accepted the request but doesn't store modes
+		e.modesUnsupported = true
+		if e.Verbose {
+			fmt.Fprintf(e.out(), "  ⚠ The server doesn't store file modes; not syncing them\n")
+		}
+		return 0
+	}
+	return local
+}
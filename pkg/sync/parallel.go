@@ -0,0 +1,35 @@
+package sync
+
+import gosync "sync"
+
+// DefaultWorkers is the transfer concurrency used when none is configured.
+const DefaultWorkers = 4
+
+// ForEach calls fn(i) for every i in [0, n) on at most workers goroutines
+// and returns once all calls have finished. fn must be safe to run
+// concurrently; workers < 1 runs the calls one at a time.
+func ForEach(workers, n int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	next := make(chan int)
+	var wg gosync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"context"
+	"io"
+)
+
+// PlannedAction is one entry of a sync plan: what a pull or push would do
+// to a path, and why. Its JSON form is the output of
+// 'izerop sync --dump-plan'.
+type PlannedAction struct {
+	Phase    string `json:"phase"` // "pull" or "push"
+	Path     string `json:"path"`
+	Action   Action `json:"action"`
+	Reason   string `json:"reason,omitempty"`
+	Size     int64  `json:"size"`
+	RemoteID string `json:"remote_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Plan works out what PullSync (from cursor) and PushSync would do, without
+// doing any of it, and returns every planned action in order. It is a dry
+// run with the per-file lines suppressed: local files, the server, and the
+// saved state are untouched, but State is updated in memory, so callers
+// must not save it afterwards.
+func (e *Engine) Plan(ctx context.Context, cursor string, pull, push bool) ([]PlannedAction, error) {
+	dryRun, out, onEvent := e.DryRun, e.Out, e.OnEvent
+	defer func() { e.DryRun, e.Out, e.OnEvent = dryRun, out, onEvent }()
+
+	plan := []PlannedAction{}
+	phase := ""
+	e.DryRun = true
+	e.Out = io.Discard
+	e.OnEvent = func(ev Event) {
+		a := PlannedAction{
+			Phase:    phase,
+			Path:     ev.Path,
+			Action:   ev.Action,
+			Reason:   ev.Reason,
+			Size:     ev.Size,
+			RemoteID: ev.RemoteID,
+		}
+		if ev.Err != nil {
+			a.Error = ev.Err.Error()
+		}
+		plan = append(plan, a)
+	}
+
+	if pull {
+		phase = "pull"
+		if _, _, err := e.PullSync(ctx, cursor); err != nil {
+			return plan, err
+		}
+	}
+	if push {
+		phase = "push"
+		if _, err := e.PushSync(ctx); err != nil {
+			return plan, err
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+)
+
+// Progress reports aggregate transfer progress for a pull or push.
+type Progress struct {
+	FilesDone  int
+	FilesTotal int
+	BytesDone  int64
+	BytesTotal int64
+}
+
+// startProgress resets progress tracking for a planned run and reports it.
+func (e *Engine) startProgress(files int, bytes int64) {
+	e.progress = Progress{FilesTotal: files, BytesTotal: bytes}
+	if e.OnProgress != nil {
+		e.OnProgress(e.progress)
+	}
+}
+
+// advanceProgress marks one planned transfer of the given size as done.
+func (e *Engine) advanceProgress(bytes int64) {
+	if e.OnProgress == nil {
+		return
+	}
+	e.progress.FilesDone++
+	e.progress.BytesDone += bytes
+	// The plan is an estimate — never report more done than total
+	if e.progress.FilesDone > e.progress.FilesTotal {
+		e.progress.FilesTotal = e.progress.FilesDone
+	}
+	if e.progress.BytesDone > e.progress.BytesTotal {
+		e.progress.BytesTotal = e.progress.BytesDone
+	}
+	e.OnProgress(e.progress)
+}
+
+// planPull counts the file downloads a set of changes will trigger.
+func (e *Engine) planPull(changes []api.Change) (int, int64) {
+	files := 0
+	var bytes int64
+	for _, change := range changes {
+		if change.Type != "file" || (change.Action != "created" && change.Action != "modified") {
+			continue
+		}
+		localRel := e.remoteToLocal(change.Path)
+		if localRel == "" {
+			continue
+		}
+		if filepath.Ext(localRel) == "" {
+			localRel = localRel + ".txt"
+		}
+		if e.ignoredPath(localRel, false) {
+			continue
+		}
+		files++
+		bytes += change.Size
+	}
+	return files, bytes
+}
+
+// planPush counts local files that are new or changed since the last sync.
+func (e *Engine) planPush() (int, int64) {
+	files := 0
+	var bytes int64
+	e.walk(func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, _ := filepath.Rel(e.SyncDir, path)
+		if relPath == "." {
+			return nil
+		}
+		if e.isIgnored(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || strings.Contains(info.Name(), ".conflict") || strings.HasSuffix(info.Name(), ".izerop-tmp") {
+			return nil
+		}
+
+		if rec, tracked := e.Store.GetRecord(relPath); tracked {
+			if rec.Size == info.Size() && rec.LocalMod == info.ModTime().Unix() {
+				return nil
+			}
+			if h, err := e.hashPath(path); err == nil && h == rec.Hash {
+				return nil
+			}
+		}
+		files++
+		bytes += info.Size()
+		return nil
+	}, nil)
+	return files, bytes
+}
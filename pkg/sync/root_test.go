@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRootSyncDir(t *testing.T) {
+	dir, other := t.TempDir(), t.TempDir()
+
+	state := &State{}
+	e := NewEngine(nil, dir, state)
+	if err := e.CheckRoot(); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if state.SyncDir != dir {
+		t.Fatalf("SyncDir = %q, want %q", state.SyncDir, dir)
+	}
+
+	// With no records, the state can follow the sync dir elsewhere
+	if err := NewEngine(nil, other, state).CheckRoot(); err != nil {
+		t.Fatalf("empty state, new dir: %v", err)
+	}
+
+	state.Files = map[string]FileRecord{"notes.txt": {RemoteID: "f1"}}
+	if err := NewEngine(nil, dir, state).CheckRoot(); !errors.Is(err, ErrSyncDirMismatch) {
+		t.Fatalf("tracked files, other dir: got %v, want ErrSyncDirMismatch", err)
+	}
+	if err := NewEngine(nil, other, state).CheckRoot(); err != nil {
+		t.Fatalf("tracked files, same dir: %v", err)
+	}
+
+	// State saved before the sync dir was recorded is taken to match
+	legacy := &State{Files: map[string]FileRecord{"notes.txt": {RemoteID: "f1"}}}
+	if err := NewEngine(nil, dir, legacy).CheckRoot(); err != nil || legacy.SyncDir != dir {
+		t.Fatalf("legacy state: err %v, SyncDir %q", err, legacy.SyncDir)
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	tests := []struct {
+		root, wantRoot string
+		wantScope      bool
+	}{
+		{"", DefaultRootDir, false},
+		{"/", DefaultRootDir, false},
+		{"root", DefaultRootDir, false},
+		{"work", "work", true},
+		{"/work/notes/", "work/notes", true},
+	}
+	for _, tt := range tests {
+		e := NewEngine(nil, t.TempDir(), &State{})
+		e.SetRoot(tt.root)
+		if e.RootDir != tt.wantRoot || e.ScopeToRoot != tt.wantScope {
+			t.Errorf("SetRoot(%q): RootDir %q, ScopeToRoot %t; want %q, %t", tt.root, e.RootDir, e.ScopeToRoot, tt.wantRoot, tt.wantScope)
+		}
+	}
+
+	// A project config's root_dir wins over the profile's sync_root
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, ".izerop", "config"), `{"root_dir": "team"}`)
+	e := NewEngine(nil, dir, &State{})
+	e.SetRoot("work")
+	if e.RootDir != "team" || !e.ScopeToRoot {
+		t.Errorf("project root: RootDir %q, ScopeToRoot %t; want team, true", e.RootDir, e.ScopeToRoot)
+	}
+}
+
+// TestProjectRootScoped checks that a project's root_dir keeps the rest of
+// the account out of the project directory, with or without a profile
+// sync_root.
+func TestProjectRootScoped(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, ".izerop", "config"), `{"root_dir": "/proj/"}`)
+	for _, root := range []string{"", "work"} {
+		e := NewEngine(nil, dir, &State{})
+		e.SetRoot(root)
+		for remote, want := range map[string]string{
+			"/proj/a.txt":      "a.txt",
+			"/root/secret.txt": "",
+			"/other/x":         "",
+			"/project/x":       "",
+		} {
+			if got := e.remoteToLocal(remote); got != want {
+				t.Errorf("sync_root %q: remoteToLocal(%q) = %q, want %q", root, remote, got, want)
+			}
+		}
+	}
+}
+
+func TestRootPaths(t *testing.T) {
+	tests := []struct {
+		root, remote, local string
+		scope               bool
+	}{
+		{"root", "/root/a.txt", "a.txt", false},
+		{"root", "/root/sub/a.txt", "sub/a.txt", false},
+		{"root", "/root", "", false},
+		{"root", "/other/a.txt", "other/a.txt", false},
+		{"root", "/rootless/a.txt", "rootless/a.txt", false},
+		{"work/notes", "/work/notes/a.txt", "a.txt", true},
+		{"work/notes", "/work/notes", "", true},
+		{"work/notes", "/work/a.txt", "", true},
+		{"work/notes", "/work/notesy/a.txt", "", true},
+		{"work/notes", "/root/a.txt", "", true},
+		{"work/notes", "/root/a.txt", "root/a.txt", false},
+	}
+	for _, tt := range tests {
+		e := NewEngine(nil, t.TempDir(), &State{})
+		e.RootDir, e.ScopeToRoot = tt.root, tt.scope
+		if got := e.remoteToLocal(tt.remote); got != tt.local {
+			t.Errorf("root %q, scope %t: remoteToLocal(%q) = %q, want %q", tt.root, tt.scope, tt.remote, got, tt.local)
+		}
+		// Paths inside the root map back to where they came from
+		if strings.HasPrefix(tt.remote, "/"+tt.root+"/") {
+			if got := e.localToRemote(filepath.FromSlash(tt.local)); got != tt.remote {
+				t.Errorf("root %q: localToRemote(%q) = %q, want %q", tt.root, tt.local, got, tt.remote)
+			}
+		}
+	}
+}
+
+func TestCheckRootCustomRoot(t *testing.T) {
+	engine := func(state *State) *Engine {
+		e := NewEngine(nil, t.TempDir(), state)
+		e.SetRoot("work")
+		return e
+	}
+
+	state := &State{}
+	if err := engine(state).CheckRoot(); err != nil || state.Root != "work" {
+		t.Fatalf("first run: err %v, Root %q", err, state.Root)
+	}
+
+	// The same state synced under another root is refused
+	other := NewEngine(nil, t.TempDir(), state)
+	other.SetRoot("home")
+	if err := other.CheckRoot(); !errors.Is(err, ErrRootMismatch) {
+		t.Errorf("other root: got %v, want ErrRootMismatch", err)
+	}
+	if err := NewEngine(nil, t.TempDir(), state).CheckRoot(); !errors.Is(err, ErrRootMismatch) {
+		t.Errorf("default root: got %v, want ErrRootMismatch", err)
+	}
+
+	// Legacy state tracking files may hold paths outside a scoped root
+	legacy := &State{Files: map[string]FileRecord{"notes.txt": {RemoteID: "f1"}}}
+	if err := engine(legacy).CheckRoot(); !errors.Is(err, ErrRootMismatch) || legacy.Root != "" {
+		t.Errorf("legacy state with files: err %v, Root %q", err, legacy.Root)
+	}
+	empty := &State{}
+	if err := engine(empty).CheckRoot(); err != nil || empty.Root != "work" {
+		t.Errorf("legacy state without files: err %v, Root %q", err, empty.Root)
+	}
+}
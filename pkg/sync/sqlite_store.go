@@ -0,0 +1,362 @@
+package sync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables of a state database. Records get a row
+// each; everything else in State is small and kept as one JSON blob.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS records (
+	path        TEXT PRIMARY KEY,
+	remote_id   TEXT NOT NULL,
+	size        INTEGER NOT NULL,
+	hash        TEXT NOT NULL,
+	remote_time TEXT NOT NULL,
+	local_mod   INTEGER NOT NULL,
+	mode        INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);`
+
+// SQLiteStore is the Store for state_backend sqlite. Records are read from
+// the database on demand and changes are held in memory until Flush writes
+// them in one transaction, so a sync touching a few files of a huge tree
+// only writes those rows.
+type SQLiteStore struct {
+	db    *sql.DB
+	state *State
+	// pending holds records changed since the last Flush; nil marks a
+	// deletion.
+	pending map[string]*FileRecord
+	// err is the first failed read since the last Flush (see Err).
+	err error
+}
+
+// OpenSQLiteStore opens (creating if needed) a profile's state database.
+// A new database takes over the profile's JSON state, which is then
+// removed.
+func OpenSQLiteStore(profile string) (*SQLiteStore, error) {
+	path, err := StateDBPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(path)
+	fresh := errors.Is(statErr, os.ErrNotExist)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	s, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	if fresh {
+		if err := s.importJSON(profile); err != nil {
+			// Try again next time rather than start from an empty database
+			s.Close()
+			removeSQLite(path)
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func openSQLite(path string) (*SQLiteStore, error) {
+	// The default rollback journal (not WAL) keeps every commit a write to
+	// the database file itself, so its mtime tells a watcher that another
+	// process saved state.
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not open sync state %s: %w", path, err)
+	}
+
+	state := &State{}
+	var blob string
+	err = db.QueryRow(`SELECT value FROM meta WHERE key = 'state'`).Scan(&blob)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		db.Close()
+		return nil, fmt.Errorf("could not read sync state %s: %w", path, err)
+	default:
+		if err := json.Unmarshal([]byte(blob), state); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%w: %s: %v", ErrStateCorrupt, path, err)
+		}
+	}
+	state.Files = nil
+	if state.Notes == nil {
+		state.Notes = make(map[string]string)
+	}
+	return &SQLiteStore{db: db, state: state, pending: make(map[string]*FileRecord)}, nil
+}
+
+// importJSON moves the profile's JSON state, if any, into the database.
+func (s *SQLiteStore) importJSON(profile string) error {
+	jsonPath, err := StatePath(profile)
+	if err != nil {
+		return nil
+	}
+	if _, err := os.Stat(jsonPath); err != nil {
+		return nil
+	}
+	state, err := LoadState(profile)
+	if err != nil {
+		return fmt.Errorf("could not move sync state into SQLite: %w", err)
+	}
+	for relPath, rec := range state.Files {
+		s.SetRecord(relPath, rec)
+	}
+	state.Files = nil
+	if state.Notes == nil {
+		state.Notes = make(map[string]string)
+	}
+	s.state = state
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	os.Remove(jsonPath)
+	os.Remove(jsonPath + ".bak")
+	return nil
+}
+
+// exportSQLite writes a profile's state database out as JSON state and
+// removes it, for switching state_backend back to json.
+func exportSQLite(profile string) error {
+	path, err := StateDBPath(profile)
+	if err != nil {
+		return err
+	}
+	s, err := openSQLite(path)
+	if err != nil {
+		return err
+	}
+	state := s.State()
+	state.Files = make(map[string]FileRecord)
+	s.EachRecord(func(relPath string, rec FileRecord) {
+		state.Files[relPath] = rec
+	})
+	s.Close()
+	if err := SaveState(profile, state); err != nil {
+		return err
+	}
+	return removeSQLite(path)
+}
+
+// removeSQLite deletes a state database and its rollback journal.
+func removeSQLite(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(path + "-journal"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// State returns the non-record state. Its Files map is always empty; use
+// the record methods.
+func (s *SQLiteStore) State() *State {
+	return s.state
+}
+
+func (s *SQLiteStore) GetRecord(relPath string) (FileRecord, bool) {
+	if rec, ok := s.pending[relPath]; ok {
+		if rec == nil {
+			return FileRecord{}, false
+		}
+		return *rec, true
+	}
+	var rec FileRecord
+	err := s.db.QueryRow(`SELECT remote_id, size, hash, remote_time, local_mod, mode FROM records WHERE path = ?`, relPath).
+		Scan(&rec.RemoteID, &rec.Size, &rec.Hash, &rec.RemoteTime, &rec.LocalMod, &rec.Mode)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.readFailed(err)
+		}
+		return FileRecord{}, false
+	}
+	return rec, true
+}
+
+// readFailed keeps the first error from reading records.
+func (s *SQLiteStore) readFailed(err error) {
+	if s.err == nil {
+		s.err = fmt.Errorf("could not read sync state: %w", err)
+	}
+}
+
+// Err returns the first error from reading records since the last Flush.
+func (s *SQLiteStore) Err() error {
+	return s.err
+}
+
+func (s *SQLiteStore) SetRecord(relPath string, rec FileRecord) {
+	s.pending[relPath] = &rec
+}
+
+func (s *SQLiteStore) DeleteRecord(relPath string) {
+	s.pending[relPath] = nil
+}
+
+// eachRecordBatch is how many rows EachRecord reads at a time.
+const eachRecordBatch = 1000
+
+func (s *SQLiteStore) EachRecord(fn func(relPath string, rec FileRecord)) {
+	// fn may flush, which starts a new pending map
+	pending := make(map[string]*FileRecord, len(s.pending))
+	for relPath, rec := range s.pending {
+		pending[relPath] = rec
+	}
+
+	// Rows are read a batch at a time, in path order, and the batch's rows
+	// are closed before fn runs: fn may itself query the store, and there
+	// is only one connection.
+	type row struct {
+		path string
+		rec  FileRecord
+	}
+	after := ""
+	for {
+		batch := make([]row, 0, eachRecordBatch)
+		rows, err := s.db.Query(`SELECT path, remote_id, size, hash, remote_time, local_mod, mode FROM records
+			WHERE path > ? ORDER BY path LIMIT ?`, after, eachRecordBatch)
+		if err != nil {
+			s.readFailed(err)
+			return
+		}
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.path, &r.rec.RemoteID, &r.rec.Size, &r.rec.Hash, &r.rec.RemoteTime, &r.rec.LocalMod, &r.rec.Mode); err != nil {
+				s.readFailed(err)
+				break
+			}
+			batch = append(batch, r)
+		}
+		if err := rows.Err(); err != nil {
+			s.readFailed(err)
+		}
+		rows.Close()
+		if s.err != nil {
+			return
+		}
+		for _, r := range batch {
+			if _, changed := pending[r.path]; !changed {
+				fn(r.path, r.rec)
+			}
+		}
+		if len(batch) < eachRecordBatch {
+			break
+		}
+		after = batch[len(batch)-1].path
+	}
+	for relPath, rec := range pending {
+		if rec != nil {
+			fn(relPath, *rec)
+		}
+	}
+}
+
+func (s *SQLiteStore) HasRecords() bool {
+	for _, rec := range s.pending {
+		if rec != nil {
+			return true
+		}
+	}
+	// Stop at the first row that wasn't deleted since the last Flush. A
+	// failed read counts as records, the safer answer for callers.
+	rows, err := s.db.Query(`SELECT path FROM records`)
+	if err != nil {
+		s.readFailed(err)
+		return true
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			s.readFailed(err)
+			return true
+		}
+		if _, deleted := s.pending[path]; !deleted {
+			return true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.readFailed(err)
+		return true
+	}
+	return false
+}
+
+func (s *SQLiteStore) Cursor() string {
+	return s.state.Cursor
+}
+
+func (s *SQLiteStore) SetCursor(cursor string) {
+	s.state.Cursor = cursor
+}
+
+// Flush writes the pending records and the rest of the state in one
+// transaction. If a read failed since the last Flush, Flush still writes,
+// then returns that error (once).
+func (s *SQLiteStore) Flush() error {
+	blob, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.Prepare(`INSERT INTO records (path, remote_id, size, hash, remote_time, local_mod, mode)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET remote_id = excluded.remote_id, size = excluded.size,
+			hash = excluded.hash, remote_time = excluded.remote_time,
+			local_mod = excluded.local_mod, mode = excluded.mode`)
+	if err != nil {
+		return err
+	}
+	defer upsert.Close()
+	for relPath, rec := range s.pending {
+		if rec == nil {
+			_, err = tx.Exec(`DELETE FROM records WHERE path = ?`, relPath)
+		} else {
+			_, err = upsert.Exec(relPath, rec.RemoteID, rec.Size, rec.Hash, rec.RemoteTime, rec.LocalMod, rec.Mode)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('state', ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value`, string(blob)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.pending = make(map[string]*FileRecord)
+	err, s.err = s.err, nil
+	return err
+}
+
+// Close closes the database. Changes not yet flushed are lost.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
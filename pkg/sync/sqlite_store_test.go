@@ -0,0 +1,189 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStoreRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	s, err := OpenSQLiteStore("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetRecord("a.txt", FileRecord{RemoteID: "f1", Size: 3, Hash: "h1", LocalMod: 10, Mode: 0644})
+	s.SetRecord("b.txt", FileRecord{RemoteID: "f2"})
+	s.SetCursor("c1")
+	s.State().Notes["n.md"] = "n1"
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unflushed changes are visible at once and lost on Close
+	s.DeleteRecord("a.txt")
+	s.SetRecord("c.txt", FileRecord{RemoteID: "f3"})
+	if _, ok := s.GetRecord("a.txt"); ok {
+		t.Error("deleted record still returned")
+	}
+	if got := countRecords(s); got != 2 {
+		t.Errorf("EachRecord saw %d records before flush, want 2", got)
+	}
+	s.Close()
+
+	s, err = OpenSQLiteStore("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	rec, ok := s.GetRecord("a.txt")
+	if !ok || rec != (FileRecord{RemoteID: "f1", Size: 3, Hash: "h1", LocalMod: 10, Mode: 0644}) {
+		t.Errorf("a.txt = %+v, %v after reopen", rec, ok)
+	}
+	if _, ok := s.GetRecord("c.txt"); ok {
+		t.Error("unflushed record was saved")
+	}
+	if s.Cursor() != "c1" || s.State().Notes["n.md"] != "n1" {
+		t.Errorf("cursor %q, notes %v after reopen", s.Cursor(), s.State().Notes)
+	}
+}
+
+func TestOpenStoreMigrates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	jsonPath, _ := StatePath("test")
+	dbPath, _ := StateDBPath("test")
+	if err := os.MkdirAll(filepath.Dir(jsonPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	json, err := OpenStore("test", BackendJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.SetRecord("a.txt", FileRecord{RemoteID: "f1"})
+	json.SetCursor("c1")
+	if err := json.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// json → sqlite moves the state into the database
+	db, err := OpenStore("test", BackendSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, ok := db.GetRecord("a.txt"); !ok || rec.RemoteID != "f1" || db.Cursor() != "c1" {
+		t.Errorf("after json → sqlite: a.txt %+v %v, cursor %q", rec, ok, db.Cursor())
+	}
+	if _, err := os.Stat(jsonPath); !os.IsNotExist(err) {
+		t.Errorf("%s left behind: %v", jsonPath, err)
+	}
+	db.SetRecord("b.txt", FileRecord{RemoteID: "f2"})
+	if err := db.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// sqlite → json moves it back out
+	json, err = OpenStore("test", BackendJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countRecords(json); got != 2 || json.Cursor() != "c1" {
+		t.Errorf("after sqlite → json: %d records, cursor %q", got, json.Cursor())
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("%s left behind: %v", dbPath, err)
+	}
+}
+
+// TestSQLiteStoreEachRecordBatches walks more rows than one batch while fn
+// queries and flushes the store, and checks HasRecords against pending
+// deletions.
+func TestSQLiteStoreEachRecordBatches(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s, err := OpenSQLiteStore("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.HasRecords() {
+		t.Error("new store has records")
+	}
+
+	const n = eachRecordBatch*2 + 10
+	for i := 0; i < n; i++ {
+		s.SetRecord(fmt.Sprintf("f%05d", i), FileRecord{RemoteID: fmt.Sprint(i)})
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s.DeleteRecord("f00000")
+	s.SetRecord("f00001", FileRecord{RemoteID: "changed"})
+
+	seen := make(map[string]int)
+	s.EachRecord(func(relPath string, rec FileRecord) {
+		seen[relPath]++
+		if _, ok := s.GetRecord(relPath); !ok {
+			t.Errorf("GetRecord(%q) inside EachRecord failed", relPath)
+		}
+		if relPath == "f01500" {
+			if err := s.Flush(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	if len(seen) != n-1 || seen["f00000"] != 0 || seen["f00001"] != 1 {
+		t.Errorf("saw %d distinct records (f00000 %d, f00001 %d times), want %d", len(seen), seen["f00000"], seen["f00001"], n-1)
+	}
+	for relPath, times := range seen {
+		if times != 1 {
+			t.Errorf("%s seen %d times", relPath, times)
+		}
+	}
+
+	// Rows all deleted since the last flush don't count
+	s.EachRecord(func(relPath string, _ FileRecord) { s.DeleteRecord(relPath) })
+	if s.HasRecords() {
+		t.Error("HasRecords with every row deleted")
+	}
+	s.SetRecord("new", FileRecord{})
+	if !s.HasRecords() {
+		t.Error("HasRecords missed a pending record")
+	}
+}
+
+func TestSQLiteStoreReadErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s, err := OpenSQLiteStore("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.db.Exec(`DROP TABLE records`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.GetRecord("a.txt"); ok || s.Err() == nil {
+		t.Errorf("GetRecord on a broken database: ok %t, Err %v", ok, s.Err())
+	}
+	s.err = nil
+	s.EachRecord(func(string, FileRecord) {})
+	if s.Err() == nil {
+		t.Error("EachRecord on a broken database left Err nil")
+	}
+	s.err = nil
+	if !s.HasRecords() || s.Err() == nil {
+		t.Errorf("HasRecords on a broken database: Err %v", s.Err())
+	}
+	if s.Flush() == nil {
+		t.Error("Flush returned nil after a failed read")
+	}
+}
+
+func countRecords(s Store) int {
+	n := 0
+	s.EachRecord(func(string, FileRecord) { n++ })
+	return n
+}
@@ -2,6 +2,8 @@ package sync
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -15,6 +17,7 @@ type FileRecord struct {
 	Hash       string `json:"hash,omitempty"`
 	RemoteTime string `json:"remote_time,omitempty"`
 	LocalMod   int64  `json:"local_mod,omitempty"` // unix timestamp
+	Mode       uint32 `json:"mode,omitempty"`      // permission bits last synced (Engine.PreserveMode)
 }
 
 // State tracks sync state between runs.
@@ -24,6 +27,19 @@ type State struct {
 	Notes  map[string]string `json:"notes,omitempty"`
 	// Files maps local relative paths to their last-synced state.
 	Files  map[string]FileRecord `json:"files,omitempty"`
+	// Conflicts maps local conflict file paths to the client that last modified the remote version.
+	Conflicts map[string]string `json:"conflicts,omitempty"`
+	// ReconcileDone records entries finished by an in-progress reconcile
+	// (local path → remote updated_at). Nil when no reconcile is pending.
+	ReconcileDone map[string]string `json:"reconcile_done,omitempty"`
+	// UploadFailures tracks local files whose uploads keep failing, by path.
+	UploadFailures map[string]UploadFailure `json:"upload_failures,omitempty"`
+	// Root is the remote root (Engine.RootDir) the records were synced
+	// against. Empty in state saved before it was recorded.
+	Root string `json:"root,omitempty"`
+	// SyncDir is the absolute local directory the records were synced
+	// from. Empty in state saved before it was recorded.
+	SyncDir string `json:"sync_dir,omitempty"`
 }
 
 // StatePath returns the path to the sync state file for a profile.
@@ -32,6 +48,12 @@ func StatePath(profile string) (string, error) {
 	return config.ProfileStatePath(profile)
 }
 
+// StateDBPath returns the path to the SQLite sync state for a profile
+// (BackendSQLite).
+func StateDBPath(profile string) (string, error) {
+	return config.ProfileStateDBPath(profile)
+}
+
 // MigrateState moves the legacy .izerop-sync.json from the sync dir to the profile config dir.
 func MigrateState(profile string, syncDir string) {
 	if syncDir == "" {
@@ -63,20 +85,43 @@ func MigrateState(profile string, syncDir string) {
 	os.Remove(legacyPath)
 }
 
-// LoadState reads the sync state from the profile config dir.
+// ErrStateCorrupt means neither the state file nor its backup could be
+// parsed. Syncing from the empty state LoadState returns would re-upload
+// and re-download everything, so callers should stop and let the user
+// decide (e.g. 'izerop state reset').
+var ErrStateCorrupt = errors.New("sync state file is corrupt")
+
+// LoadState reads the sync state from the profile config dir. A missing file
+// is an empty state. If the file can't be parsed (e.g. it was truncated),
+// the backup kept by SaveState is used instead; if that fails too, the
+// error wraps ErrStateCorrupt.
 func LoadState(profile string) (*State, error) {
 	path, err := StatePath(profile)
 	if err != nil {
 		return &State{Files: make(map[string]FileRecord)}, nil
 	}
-	data, err := os.ReadFile(path)
-	if err != nil {
+	state, err := readState(path)
+	if err == nil {
+		return state, nil
+	}
+	if os.IsNotExist(err) {
 		return &State{Files: make(map[string]FileRecord)}, nil
 	}
+	if backup, bakErr := readState(path + ".bak"); bakErr == nil {
+		return backup, nil
+	}
+	return &State{Files: make(map[string]FileRecord)}, fmt.Errorf("%w: %s: %v", ErrStateCorrupt, path, err)
+}
 
+// readState parses one state file.
+func readState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
-		return &State{Files: make(map[string]FileRecord)}, nil
+		return nil, err
 	}
 	if state.Files == nil {
 		state.Files = make(map[string]FileRecord)
@@ -84,7 +129,10 @@ func LoadState(profile string) (*State, error) {
 	return &state, nil
 }
 
-// SaveState writes the sync state to the profile config dir.
+// SaveState writes the sync state to the profile config dir. The file is
+// written to a temp file and renamed into place, so a crash mid-save never
+// leaves it truncated, and the previous state is kept as sync-state.json.bak
+// for LoadState to fall back on.
 func SaveState(profile string, state *State) error {
 	path, err := StatePath(profile)
 	if err != nil {
@@ -94,5 +142,52 @@ func SaveState(profile string, state *State) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0600)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sync-state-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	// Only a state that still parses replaces the backup
+	if prev, err := os.ReadFile(path); err == nil && json.Valid(prev) {
+		os.Rename(path, path+".bak")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// ResetState deletes a profile's sync state so the next sync starts fresh.
+func ResetState(profile string) error {
+	path, err := StatePath(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(path + ".bak"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	dbPath, err := StateDBPath(profile)
+	if err != nil {
+		return err
+	}
+	return removeSQLite(dbPath)
 }
@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stateFiles points HOME at a temp dir and returns the paths of the test
+// profile's state file and its backup.
+func stateFiles(t *testing.T) (string, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	path, err := StatePath("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return path, path + ".bak"
+}
+
+func TestLoadStateRecovery(t *testing.T) {
+	good := `{"cursor": "c1", "files": {"a.txt": {"remote_id": "f1", "size": 3}}}`
+	truncated := good[:len(good)/2]
+
+	tests := []struct {
+		name          string
+		primary, bak  string // "" leaves the file out
+		wantCursor    string
+		wantCorrupted bool
+	}{
+		{name: "missing"},
+		{name: "good", primary: good, wantCursor: "c1"},
+		{name: "truncated, no backup", primary: truncated, wantCorrupted: true},
+		{name: "truncated, good backup", primary: truncated, bak: good, wantCursor: "c1"},
+		{name: "corrupt, good backup", primary: "not json", bak: good, wantCursor: "c1"},
+		{name: "both corrupt", primary: "not json", bak: truncated, wantCorrupted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, bak := stateFiles(t)
+			if tt.primary != "" {
+				mustWrite(t, path, tt.primary)
+			}
+			if tt.bak != "" {
+				mustWrite(t, bak, tt.bak)
+			}
+
+			state, err := LoadState("test")
+			if tt.wantCorrupted {
+				if !errors.Is(err, ErrStateCorrupt) {
+					t.Fatalf("err = %v, want ErrStateCorrupt", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if state == nil || state.Files == nil {
+				t.Fatal("LoadState returned no usable state")
+			}
+			if state.Cursor != tt.wantCursor {
+				t.Errorf("cursor = %q, want %q", state.Cursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestSaveStateKeepsGoodBackup(t *testing.T) {
+	path, bak := stateFiles(t)
+
+	if err := SaveState("test", &State{Cursor: "c1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveState("test", &State{Cursor: "c2"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readCursor(t, bak); got != "c1" {
+		t.Errorf("backup cursor = %q, want c1", got)
+	}
+
+	// A corrupt primary never replaces the good backup
+	mustWrite(t, path, "not json")
+	if err := SaveState("test", &State{Cursor: "c3"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readCursor(t, bak); got != "c1" {
+		t.Errorf("backup cursor after corrupt save = %q, want c1", got)
+	}
+	if got := readCursor(t, path); got != "c3" {
+		t.Errorf("state cursor = %q, want c3", got)
+	}
+}
+
+func readCursor(t *testing.T, path string) string {
+	t.Helper()
+	state, err := readState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state.Cursor
+}
+
+// mustWrite writes data to path, creating its directory.
+func mustWrite(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// StateBackend selects how a profile's sync state is kept on disk (config
+// state_backend).
+type StateBackend string
+
+const (
+	// BackendJSON keeps state in sync-state.json, read and written whole
+	// (default).
+	BackendJSON StateBackend = "json"
+	// BackendSQLite keeps state in sync-state.db, one row per record, so a
+	// save only writes the records that changed. For sync dirs with
+	// hundreds of thousands of files.
+	BackendSQLite StateBackend = "sqlite"
+)
+
+// ParseStateBackend validates a state_backend value. Empty means
+// BackendJSON.
+func ParseStateBackend(s string) (StateBackend, error) {
+	switch StateBackend(s) {
+	case "", BackendJSON:
+		return BackendJSON, nil
+	case BackendSQLite:
+		return BackendSQLite, nil
+	}
+	return "", fmt.Errorf("unknown state_backend %q (want json or sqlite)", s)
+}
+
+// StoreFile returns the file a profile's state is kept in with backend.
+func StoreFile(profile string, backend StateBackend) (string, error) {
+	if backend == BackendSQLite {
+		return StateDBPath(profile)
+	}
+	return StatePath(profile)
+}
+
+// OpenStore opens a profile's sync state with backend. State kept by the
+// other backend is moved over on first open, so switching state_backend
+// keeps it and it never lives in both places. As with LoadState, state
+// that can't be read returns an empty store along with the error (wrapping
+// ErrStateCorrupt for a corrupt file), so callers that only peek at the
+// state can ignore it.
+func OpenStore(profile string, backend StateBackend) (Store, error) {
+	if backend == BackendSQLite {
+		s, err := OpenSQLiteStore(profile)
+		if err != nil {
+			return NewJSONStore("", &State{}), err
+		}
+		return s, nil
+	}
+
+	jsonPath, err := StatePath(profile)
+	if err != nil {
+		return NewJSONStore(profile, &State{}), nil
+	}
+	dbPath, _ := StateDBPath(profile)
+	if _, err := os.Stat(jsonPath); errors.Is(err, os.ErrNotExist) && dbPath != "" {
+		if _, err := os.Stat(dbPath); err == nil {
+			if err := exportSQLite(profile); err != nil {
+				return NewJSONStore(profile, &State{}), fmt.Errorf("could not move sync state out of %s: %w", dbPath, err)
+			}
+		}
+	}
+	state, err := LoadState(profile)
+	return NewJSONStore(profile, state), err
+}
+
+// Store is the engine's view of persistent sync state: one FileRecord per
+// synced path plus the remote change cursor. The engine reads and updates
+// records one at a time; when they reach disk is up to the implementation,
+// and Flush persists everything set so far.
+type Store interface {
+	// GetRecord returns the record for a local relative path.
+	GetRecord(relPath string) (FileRecord, bool)
+	// SetRecord adds or replaces the record for relPath.
+	SetRecord(relPath string, rec FileRecord)
+	// DeleteRecord forgets relPath. Deleting an unknown path is a no-op.
+	DeleteRecord(relPath string)
+	// EachRecord calls fn for every record, in no particular order. fn may
+	// use the store; records it sets or deletes may or may not be visited.
+	EachRecord(fn func(relPath string, rec FileRecord))
+	// HasRecords reports whether the store holds any record, without
+	// reading them all.
+	HasRecords() bool
+	// Err returns the first error reading records since the last Flush.
+	// A record that couldn't be read looks missing, so a run must stop
+	// once Err is set rather than act on that.
+	Err() error
+	// Cursor returns the cursor for the next incremental pull.
+	Cursor() string
+	// SetCursor records the cursor returned by a pull.
+	SetCursor(cursor string)
+	// Flush persists all changes made since the last Flush.
+	Flush() error
+	// State returns the rest of the sync state (notes, conflicts, ...),
+	// which callers read and change directly; Flush saves it too. Its Files
+	// map is only meaningful for a JSONStore.
+	State() *State
+	// Close releases the store without flushing.
+	Close() error
+}
+
+// JSONStore is the Store for the JSON state file. Records live in State's
+// maps and are only marshaled when Flush writes the whole file.
+type JSONStore struct {
+	state   *State
+	profile string
+}
+
+// NewJSONStore wraps state in a Store whose Flush saves it to the profile's
+// state file. With an empty profile the store is in-memory and Flush is a
+// no-op.
+func NewJSONStore(profile string, state *State) *JSONStore {
+	if state.Files == nil {
+		state.Files = make(map[string]FileRecord)
+	}
+	return &JSONStore{state: state, profile: profile}
+}
+
+// State returns the State the store reads and writes.
+func (s *JSONStore) State() *State {
+	return s.state
+}
+
+func (s *JSONStore) GetRecord(relPath string) (FileRecord, bool) {
+	rec, ok := s.state.Files[relPath]
+	return rec, ok
+}
+
+func (s *JSONStore) SetRecord(relPath string, rec FileRecord) {
+	s.state.Files[relPath] = rec
+}
+
+func (s *JSONStore) DeleteRecord(relPath string) {
+	delete(s.state.Files, relPath)
+}
+
+func (s *JSONStore) EachRecord(fn func(relPath string, rec FileRecord)) {
+	for relPath, rec := range s.state.Files {
+		fn(relPath, rec)
+	}
+}
+
+func (s *JSONStore) HasRecords() bool {
+	return len(s.state.Files) > 0
+}
+
+// Err is always nil: a JSONStore's records are all in memory.
+func (s *JSONStore) Err() error {
+	return nil
+}
+
+func (s *JSONStore) Cursor() string {
+	return s.state.Cursor
+}
+
+func (s *JSONStore) SetCursor(cursor string) {
+	s.state.Cursor = cursor
+}
+
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+func (s *JSONStore) Flush() error {
+	if s.profile == "" {
+		return nil
+	}
+	return SaveState(s.profile, s.state)
+}
@@ -0,0 +1,418 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+)
+
+// SymlinkPolicy decides what sync does with symbolic links in the sync dir
+// (config symlink_policy).
+type SymlinkPolicy string
+
+const (
+	// SymlinkSkip leaves symlinks out of the sync in both directions
+	// (default).
+	SymlinkSkip SymlinkPolicy = "skip"
+	// SymlinkFollow syncs what a link points at: a linked file's contents,
+	// or a linked directory's tree as if it were in place. A link back to a
+	// directory the walk is already inside (a loop) is skipped.
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkStore syncs the link itself: it is uploaded as a small text
+	// file holding the link target, and downloading such a file recreates
+	// the link. A downloaded link that is absolute or leads out of the sync
+	// dir is kept as the text file instead.
+	SymlinkStore SymlinkPolicy = "store"
+)
+
+// symlinkStubPrefix starts the contents of the text file that stands for a
+// link under SymlinkStore; the target follows on the same line.
+const symlinkStubPrefix = "izerop-symlink: "
+
+// maxSymlinkStub is the largest download checked for being a link stub.
+const maxSymlinkStub = 4096
+
+// ParseSymlinkPolicy validates a symlink_policy value. Empty means
+// SymlinkSkip.
+func ParseSymlinkPolicy(s string) (SymlinkPolicy, error) {
+	switch SymlinkPolicy(s) {
+	case "", SymlinkSkip:
+		return SymlinkSkip, nil
+	case SymlinkFollow, SymlinkStore:
+		return SymlinkPolicy(s), nil
+	}
+	return "", fmt.Errorf("unknown symlink_policy %q (want skip, follow, or store)", s)
+}
+
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// symlinkStub returns the stored contents for a link to target.
+func symlinkStub(target string) string {
+	return symlinkStubPrefix + target + "\n"
+}
+
+// stubHash returns the SHA256 of the stub for a link to target, which is
+// the content hash the server keeps for it.
+func stubHash(target string) string {
+	sum := sha256.Sum256([]byte(symlinkStub(target)))
+	return hex.EncodeToString(sum[:])
+}
+
+// readSymlinkStub returns the link target if the file at path is a link
+// stub.
+func readSymlinkStub(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxSymlinkStub {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	s := string(data)
+	if !strings.HasPrefix(s, symlinkStubPrefix) || !strings.HasSuffix(s, "\n") {
+		return "", false
+	}
+	target := strings.TrimSuffix(strings.TrimPrefix(s, symlinkStubPrefix), "\n")
+	if target == "" || strings.ContainsAny(target, "\n\x00") {
+		return "", false
+	}
+	return target, true
+}
+
+// statLocal is os.Stat, except that under SymlinkStore a link describes
+// itself (os.Lstat) rather than its target.
+func (e *Engine) statLocal(path string) (os.FileInfo, error) {
+	if e.Symlinks == SymlinkStore {
+		return os.Lstat(path)
+	}
+	return os.Stat(path)
+}
+
+// hashPath is HashFile, except that under SymlinkStore a link hashes as
+// its stub.
+func (e *Engine) hashPath(path string) (string, error) {
+	if e.Symlinks == SymlinkStore {
+		if target, err := os.Readlink(path); err == nil {
+			return stubHash(target), nil
+		}
+	}
+	return e.hashFile(path)
+}
+
+// skipsLink reports whether localPath is a symlink that the policy leaves
+// out of the sync, so pulls must not replace it.
+func (e *Engine) skipsLink(localPath string) bool {
+	if e.Symlinks == SymlinkFollow || e.Symlinks == SymlinkStore {
+		return false
+	}
+	info, err := os.Lstat(localPath)
+	return err == nil && isSymlink(info)
+}
+
+// installDownload moves a finished download from tmpPath into place at
+// localPath and returns the record of what is now on disk; the caller
+// fills in RemoteID and RemoteTime. Under SymlinkStore a link stub becomes
+// a symlink again. Under SymlinkFollow a file reached through a link is
+// written to the link's target, so the link survives.
+func (e *Engine) installDownload(tmpPath, localPath string, remoteMode uint32) (FileRecord, error) {
+	switch e.Symlinks {
+	case SymlinkStore:
+		target, ok := readSymlinkStub(tmpPath)
+		if ok && !e.linkStaysInside(localPath, target) {
+			rel, _ := filepath.Rel(e.SyncDir, localPath)
+			fmt.Fprintf(e.out(), "  ⚠ Not recreating symlink %s → %s: it leads outside the sync directory\n", rel, target)
+			ok = false
+		}
+		if ok {
+			os.Remove(tmpPath)
+			if err := os.Symlink(target, tmpPath); err != nil {
+				return FileRecord{}, err
+			}
+			if err := os.Rename(tmpPath, localPath); err != nil {
+				os.Remove(tmpPath)
+				return FileRecord{}, err
+			}
+			info, err := os.Lstat(localPath)
+			if err != nil {
+				return FileRecord{}, err
+			}
+			return FileRecord{Size: info.Size(), Hash: stubHash(target), LocalMod: info.ModTime().Unix()}, nil
+		}
+	case SymlinkFollow:
+		if info, err := os.Lstat(localPath); err == nil && isSymlink(info) {
+			if target, err := filepath.EvalSymlinks(localPath); err == nil {
+				localPath = target
+			}
+		}
+	}
+
+	if err := os.Rename(tmpPath, localPath); err != nil {
+		os.Remove(tmpPath)
+		return FileRecord{}, err
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	hash, _ := HashFile(localPath)
+	return FileRecord{
+		Size:     info.Size(),
+		Hash:     hash,
+		LocalMod: info.ModTime().Unix(),
+		Mode:     e.applyRemoteMode(localPath, remoteMode),
+	}, nil
+}
+
+// linkStaysInside reports whether a link at localPath to target resolves
+// inside SyncDir. Links from the server are only recreated when it does:
+// one to, say, ~/.ssh would let later downloads through it write outside
+// the sync dir.
+func (e *Engine) linkStaysInside(localPath, target string) bool {
+	if filepath.IsAbs(target) || filepath.VolumeName(target) != "" {
+		return false
+	}
+	return within(filepath.Join(filepath.Dir(localPath), target), filepath.Clean(e.SyncDir))
+}
+
+// parentStaysInside reports whether the deepest existing directory above
+// localPath resolves, through any symlinks, inside SyncDir, so writing or
+// deleting localPath can't reach outside it.
+func (e *Engine) parentStaysInside(localPath string) bool {
+	root, err := filepath.EvalSymlinks(e.SyncDir)
+	if err != nil {
+		return true // no sync dir yet, so no links in it either
+	}
+	dir := filepath.Dir(localPath)
+	for {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return within(resolved, root)
+		}
+		if _, lerr := os.Lstat(dir); lerr == nil || !os.IsNotExist(err) {
+			return false // a broken link, or unreadable
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// pushSymlink is PushSync for a link under SymlinkStore. A link whose stub
+// matches the server, or that hasn't changed since the last sync, is left
+// alone (a change on the server comes down with the pull); otherwise its
+// stub is uploaded. Links get no conflict copies: a changed local link wins.
+func (e *Engine) pushSymlink(ctx context.Context, result *SyncResult, relPath, path string, info os.FileInfo, remoteFiles map[string]api.FileEntry, remoteDirs map[string]api.Directory) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		e.fail(ctx, result, relPath, err, fmt.Sprintf("read link %s: %v", relPath, err))
+		return
+	}
+	hash := stubHash(target)
+	remotePath := e.localToRemote(relPath)
+
+	if remote, exists := remoteFiles[remotePath]; exists {
+		rec, tracked := e.Store.GetRecord(relPath)
+		switch {
+		case e.OnlyNew:
+			result.inc(&result.Existing)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "already on server", RemoteID: remote.ID})
+		case remote.ContentHash == hash:
+			e.Store.SetRecord(relPath, FileRecord{
+				RemoteID:   remote.ID,
+				Size:       info.Size(),
+				Hash:       hash,
+				RemoteTime: remote.UpdatedAt,
+				LocalMod:   info.ModTime().Unix(),
+			})
+			result.inc(&result.Skipped)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "content matches server", RemoteID: remote.ID})
+		case tracked && rec.Hash == hash:
+			result.inc(&result.Skipped)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "unchanged since last sync", RemoteID: remote.ID})
+		default:
+			e.uploadSymlink(ctx, result, relPath, path, info, &remote, "", "changed locally")
+		}
+		return
+	}
+
+	dirRemotePath := filepath.ToSlash(filepath.Dir(remotePath))
+	dir, ok := remoteDirs[dirRemotePath]
+	if !ok && !e.DryRun {
+		e.fail(ctx, result, relPath, nil, fmt.Sprintf("no remote directory for %s (dir: %s)", remotePath, dirRemotePath))
+		return
+	}
+	e.uploadSymlink(ctx, result, relPath, path, info, nil, dir.ID, "new local link")
+}
+
+// uploadSymlink uploads the stub for the link at path: over remote if it
+// is non-nil, otherwise as a new text file in dirID.
+func (e *Engine) uploadSymlink(ctx context.Context, result *SyncResult, relPath, path string, info os.FileInfo, remote *api.FileEntry, dirID, reason string) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		e.fail(ctx, result, relPath, err, fmt.Sprintf("read link %s: %v", relPath, err))
+		return
+	}
+	if e.Verbose || e.DryRun {
+		fmt.Fprintf(e.out(), "  🔗 Uploading link: %s → %s\n", relPath, target)
+	}
+
+	var entry *api.FileEntry
+	if !e.DryRun {
+		if remote != nil {
+			entry, err = e.Client.UpdateFile(ctx, remote.ID, map[string]string{"contents": symlinkStub(target)})
+		} else {
+			entry, err = e.Client.CreateTextFile(ctx, info.Name(), symlinkStub(target), dirID, "")
+		}
+	}
+	if err != nil {
+		e.fail(ctx, result, relPath, err, fmt.Sprintf("upload link %s: %v", relPath, err))
+		e.uploadFailed(ctx, relPath, info, err)
+		return
+	}
+	e.uploadSucceeded(relPath)
+
+	rec := FileRecord{Size: info.Size(), Hash: stubHash(target), LocalMod: info.ModTime().Unix()}
+	if remote != nil {
+		rec.RemoteID, rec.RemoteTime = remote.ID, remote.UpdatedAt
+	}
+	if entry != nil {
+		rec.RemoteID, rec.RemoteTime = entry.ID, entry.UpdatedAt
+	}
+	e.Store.SetRecord(relPath, rec)
+	result.inc(&result.Uploaded)
+	e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: reason, RemoteID: rec.RemoteID})
+	e.checkpoint(result)
+	e.advanceProgress(info.Size())
+}
+
+// walk is filepath.Walk over the sync dir with the symlink policy applied.
+// Under SymlinkStore a link reaches fn with its own os.Lstat info. Under
+// SymlinkFollow it reaches fn at the link's path with its target's info
+// (named after the link), and a linked directory is walked as if it were
+// in place. Links left out (every link under SymlinkSkip, and broken links
+// and loops under SymlinkFollow) go to skipped, if non-nil, with the
+// reason.
+func (e *Engine) walk(fn filepath.WalkFunc, skipped func(path, reason string)) error {
+	w := &symlinkWalk{policy: e.Symlinks, fn: fn, skipped: skipped, visited: make(map[string]bool)}
+	real, err := filepath.EvalSymlinks(e.SyncDir)
+	if err != nil {
+		real = e.SyncDir
+	}
+	return w.tree(e.SyncDir, e.SyncDir, real)
+}
+
+// symlinkWalk is the state of one Engine.walk.
+type symlinkWalk struct {
+	policy  SymlinkPolicy
+	fn      filepath.WalkFunc
+	skipped func(path, reason string)
+	// visited holds the resolved roots of the trees being walked, from
+	// the sync dir down through each followed directory link. A link to
+	// one of them, or to a directory above one, is a loop.
+	visited map[string]bool
+	stop    bool // fn returned filepath.SkipAll inside a followed directory
+}
+
+// linkInfo reports a link's target under the link's own name.
+type linkInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (i linkInfo) Name() string { return i.name }
+
+// tree walks root, whose resolved path is real, reporting its entries
+// under logical (the sync dir, or the path of the link that leads to it).
+func (w *symlinkWalk) tree(root, logical, real string) error {
+	w.visited[real] = true
+	defer delete(w.visited, real)
+
+	return filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
+		rel, _ := filepath.Rel(root, path)
+		if root != logical {
+			path = filepath.Join(logical, rel)
+			if rel == "." && info != nil {
+				info = linkInfo{info, filepath.Base(logical)}
+			}
+		}
+		if walkErr != nil || rel == "." || !isSymlink(info) {
+			return w.call(path, info, walkErr)
+		}
+
+		switch w.policy {
+		case SymlinkStore:
+			return w.call(path, info, nil)
+		case SymlinkFollow:
+			return w.follow(path, filepath.Join(real, filepath.Dir(rel)))
+		}
+		w.skip(path, "symlink")
+		return nil
+	})
+}
+
+// follow reports the link at path, whose resolved parent directory is
+// parent, as its target.
+func (w *symlinkWalk) follow(path, parent string) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		w.skip(path, "broken symlink")
+		return nil
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		w.skip(path, "broken symlink")
+		return nil
+	}
+	if !info.IsDir() {
+		return w.call(path, linkInfo{info, filepath.Base(path)}, nil)
+	}
+
+	if within(parent, target) {
+		w.skip(path, "symlink loop")
+		return nil
+	}
+	for dir := range w.visited {
+		if within(dir, target) {
+			w.skip(path, "symlink loop")
+			return nil
+		}
+	}
+	if err := w.tree(target, path, target); err != nil {
+		return err
+	}
+	if w.stop {
+		return filepath.SkipAll
+	}
+	return nil
+}
+
+func (w *symlinkWalk) call(path string, info os.FileInfo, err error) error {
+	err = w.fn(path, info, err)
+	if err == filepath.SkipAll {
+		w.stop = true
+	}
+	return err
+}
+
+func (w *symlinkWalk) skip(path, reason string) {
+	if w.skipped != nil {
+		w.skipped(path, reason)
+	}
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,229 @@
+//go:build !windows
+
+package sync
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseSymlinkPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SymlinkPolicy
+		wantErr bool
+	}{
+		{"", SymlinkSkip, false},
+		{"skip", SymlinkSkip, false},
+		{"follow", SymlinkFollow, false},
+		{"store", SymlinkStore, false},
+		{"copy", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSymlinkPolicy(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseSymlinkPolicy(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+// linkTree builds a sync dir with a linked file, a linked directory, a link
+// looping back to its parent, and a broken link.
+func linkTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, "a.txt"), "a")
+	mustWrite(t, filepath.Join(dir, "sub", "b.txt"), "b")
+	for link, target := range map[string]string{
+		"lfile":  "a.txt",
+		"ldir":   "sub",
+		"sub/up": "..",
+		"broken": "missing.txt",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// walkAll runs Engine.walk and returns the visited paths and the skipped
+// paths with their reasons, relative to dir.
+func walkAll(t *testing.T, dir string, policy SymlinkPolicy) (visited []string, skipped map[string]string) {
+	t.Helper()
+	e := NewEngine(nil, dir, &State{})
+	e.Symlinks = policy
+	skipped = make(map[string]string)
+	err := e.walk(func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if rel == "." {
+			return nil
+		}
+		kind := "file"
+		switch {
+		case isSymlink(info):
+			kind = "link"
+		case info.IsDir():
+			kind = "dir"
+		}
+		visited = append(visited, filepath.ToSlash(rel)+" "+kind)
+		return nil
+	}, func(path, reason string) {
+		rel, _ := filepath.Rel(dir, path)
+		skipped[filepath.ToSlash(rel)] = reason
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	sort.Strings(visited)
+	return visited, skipped
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	tests := []struct {
+		policy      SymlinkPolicy
+		wantVisited []string
+		wantSkipped map[string]string
+	}{
+		{
+			policy:      SymlinkSkip,
+			wantVisited: []string{"a.txt file", "sub dir", "sub/b.txt file"},
+			wantSkipped: map[string]string{"lfile": "symlink", "ldir": "symlink", "sub/up": "symlink", "broken": "symlink"},
+		},
+		{
+			policy: SymlinkFollow,
+			wantVisited: []string{"a.txt file", "ldir dir", "ldir/b.txt file", "lfile file",
+				"sub dir", "sub/b.txt file"},
+			wantSkipped: map[string]string{"broken": "broken symlink", "sub/up": "symlink loop", "ldir/up": "symlink loop"},
+		},
+		{
+			policy: SymlinkStore,
+			wantVisited: []string{"a.txt file", "broken link", "ldir link", "lfile link",
+				"sub dir", "sub/b.txt file", "sub/up link"},
+			wantSkipped: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			visited, skipped := walkAll(t, linkTree(t), tt.policy)
+			if !reflect.DeepEqual(visited, tt.wantVisited) {
+				t.Errorf("visited %q, want %q", visited, tt.wantVisited)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("skipped %v, want %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestWalkFollowLoopOutside(t *testing.T) {
+	// sync/ext → outside, outside/back → sync: following ext and then back
+	// would walk the sync dir forever
+	dir, outside := t.TempDir(), t.TempDir()
+	mustWrite(t, filepath.Join(outside, "x.txt"), "x")
+	if err := os.Symlink(outside, filepath.Join(dir, "ext")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, filepath.Join(outside, "back")); err != nil {
+		t.Fatal(err)
+	}
+	visited, skipped := walkAll(t, dir, SymlinkFollow)
+	want := []string{"ext dir", "ext/x.txt file"}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("visited %q, want %q", visited, want)
+	}
+	if skipped["ext/back"] != "symlink loop" {
+		t.Errorf("ext/back skipped as %q, want symlink loop", skipped["ext/back"])
+	}
+}
+
+func TestInstallDownloadStub(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantLink bool
+	}{
+		{"a.txt", true},
+		{"sub/b.txt", true},
+		{"../a.txt", false}, // from the top of the sync dir, out of it
+		{"/etc/passwd", false},
+		{"../../.ssh", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			dir := t.TempDir()
+			e := NewEngine(nil, dir, &State{})
+			e.Symlinks = SymlinkStore
+			e.Out = io.Discard
+
+			tmp := filepath.Join(dir, ".dl.tmp")
+			mustWrite(t, tmp, symlinkStub(tt.target))
+			localPath := filepath.Join(dir, "link")
+			rec, err := e.installDownload(tmp, localPath, 0)
+			if err != nil {
+				t.Fatalf("installDownload: %v", err)
+			}
+			info, err := os.Lstat(localPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isSymlink(info) != tt.wantLink {
+				t.Fatalf("symlink = %t, want %t", isSymlink(info), tt.wantLink)
+			}
+			// Either way the record matches the stub on the server, so the
+			// next push leaves it alone
+			if rec.Hash != stubHash(tt.target) {
+				t.Errorf("hash %s, want the stub's %s", rec.Hash, stubHash(tt.target))
+			}
+			if !tt.wantLink {
+				if target, ok := readSymlinkStub(localPath); !ok || target != tt.target {
+					t.Errorf("kept file isn't the stub for %q", tt.target)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalPathForThroughSymlink(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "out")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("missing", filepath.Join(dir, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "in"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy SymlinkPolicy
+		rel    string
+		unsafe bool
+	}{
+		{SymlinkStore, "out/authorized_keys", true},
+		{SymlinkStore, "out/new/dir/file", true},
+		{SymlinkSkip, "out/authorized_keys", true},
+		{SymlinkStore, "dangling/file", true},
+		{SymlinkStore, "out", false}, // the link itself, replaced by rename
+		{SymlinkStore, "in/file", false},
+		{SymlinkStore, "new/dir/file", false},
+		{SymlinkFollow, "out/authorized_keys", false}, // the user's own link
+		{SymlinkStore, "../x", true},
+	}
+	for _, tt := range tests {
+		e := NewEngine(nil, dir, &State{})
+		e.Symlinks = tt.policy
+		_, err := e.localPathFor(tt.rel)
+		if errors.Is(err, ErrUnsafePath) != tt.unsafe {
+			t.Errorf("%s %s: err %v, want unsafe %t", tt.policy, tt.rel, err, tt.unsafe)
+		}
+	}
+}
@@ -1,16 +1,21 @@
 package sync
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	gosync "sync"
 	"time"
 
 	"github.com/patricksimpson/izerop-cli/pkg/api"
+	"github.com/patricksimpson/izerop-cli/pkg/config"
 )
 
 // Engine handles file synchronization between local and remote.
@@ -18,26 +23,301 @@ type Engine struct {
 	Client  *api.Client
 	SyncDir string
 	Verbose bool
-	// RootDir is the name of the remote root directory (e.g. "root").
+	// RootDir is the remote root directory the sync dir maps to, without
+	// the leading slash: a name like "root" or a nested path like
+	// "work/notes" (created on first sync if missing).
 	RootDir string
-	// State tracks notes and cursor between syncs.
-	State  *State
-	// Ignore holds the parsed .izeropignore rules.
+	// projectRoot is set when a project config at the sync dir chose
+	// RootDir; SetRoot leaves it alone then.
+	projectRoot bool
+	// ScopeToRoot makes pulls and reconciles skip server paths outside
+	// RootDir instead of mapping them into the sync dir relative to "/".
+	ScopeToRoot bool
+	// State tracks notes and reconcile progress between syncs.
+	State *State
+	// Store holds per-file records and the change cursor. NewEngine wraps
+	// State in an in-memory JSONStore; callers that want Checkpoint or
+	// their own saves to go through the Store can replace it.
+	Store Store
+	// Ignore holds the parsed global and .izeropignore rules.
 	Ignore *IgnoreRules
+	// Types decides text vs binary uploads (nil uses the built-in defaults).
+	Types *FileTypes
+	// NoTextAPI uploads every new file as a binary instead of creating text
+	// notes, whatever Types says. Files already stored as notes on the
+	// server are still updated in place.
+	NoTextAPI bool
+	// MaxTextSize, if positive, uploads new files larger than this many
+	// bytes as binaries even when Types says they are text, keeping big
+	// files out of the notes database. NewEngine sets it to
+	// config.DefaultMaxTextSize; 0 means no limit.
+	MaxTextSize int64
+	// OnProgress, if set, receives aggregate progress as planned transfers complete.
+	OnProgress func(Progress)
+	// OnEvent, if set, receives an Event for each file-level action
+	// (uploaded, downloaded, deleted, moved, conflict, skipped, failed).
+	OnEvent func(Event)
+	// PropagateDeletes controls whether deletions are synced in either
+	// direction. When false, deletions are counted in DeletesSkipped instead.
+	PropagateDeletes bool
+	// Resume makes Reconcile skip manifest entries an interrupted run already
+	// finished (see State.ReconcileDone).
+	Resume bool
+	// Checkpoint, if set, is called after every CheckpointEvery files a
+	// push, pull, or reconcile finishes, so callers can persist State mid-run.
+	Checkpoint func() error
+	// CheckpointEvery is the number of files between Checkpoint calls
+	// (0 means defaultCheckpointEvery).
+	CheckpointEvery int
+	// DeferUpload, if set, is asked before each binary upload in PushSync.
+	// Returning true skips the file for this run (counted in Deferred).
+	DeferUpload func(relPath string, size int64) bool
+	// DeferDownload, if set, is asked before each download in PullSync
+	// other than a note. Returning true skips the file for this run
+	// (counted in Deferred), and the cursor isn't advanced past it, so a
+	// later pull fetches it again.
+	DeferDownload func(relPath string, size int64) bool
+	// Force skips the stale-state check in PushSync.
+	Force bool
+	// OnlyNew makes PushSync add-only: it creates files that don't exist on
+	// the server and never updates, moves, or deletes remote files. Files
+	// already on the server are counted in Existing.
+	OnlyNew bool
+	// MaxUploadAttempts, if positive, makes PushSync skip a file whose upload
+	// failed that many times in a row (counted in Stuck) until it changes.
+	// Failures are kept in State.UploadFailures either way.
+	MaxUploadAttempts int
+	// MaxFileSize, if positive, makes PushSync and Reconcile skip uploading
+	// files larger than this many bytes (counted in Skipped and listed in
+	// TooLarge). They aren't recorded in State, so a later run with a
+	// higher limit picks them up.
+	MaxFileSize int64
+	// SkipGrowing makes PushSync skip recently modified files that are still
+	// being written (counted in StillWriting) so a torn snapshot is never
+	// uploaded. They are picked up by a later push once they stop changing.
+	SkipGrowing bool
+	// FilterOSJunk skips .DS_Store, Thumbs.db, desktop.ini, and similar
+	// OS-generated files in both directions (see IsOSJunk).
+	FilterOSJunk bool
+	// PreserveMode syncs Unix permission bits (such as the executable bit):
+	// downloads are chmodded to the server's mode, and PushSync sends local
+	// mode changes. It turns itself off for the run if the server turns out
+	// not to store modes.
+	PreserveMode bool
+	// Symlinks decides what happens to symbolic links in the sync dir
+	// (default SymlinkSkip).
+	Symlinks SymlinkPolicy
+	// ConflictStrategy decides where the local copy of a conflicting file is
+	// saved (default ConflictSibling).
+	ConflictStrategy ConflictStrategy
+	// DryRun makes PullSync and PushSync report what they would do without
+	// writing local files or calling mutating APIs. State is still updated in
+	// memory so the counts add up; callers must not save it.
+	DryRun bool
+	// Out receives verbose and dry-run progress lines (nil means os.Stdout).
+	Out io.Writer
+
+	progress         Progress
+	sinceCheckpoint  int
+	authExpired      bool              // set once the server rejects the token mid-run
+	modesUnsupported bool              // set once the server ignores a file mode
+	remoteIndex      map[string]string // remote ID → tracked local path, during PullSync
+
+	// hashFile is HashFile; tests swap it to count the files read in full.
+	hashFile func(path string) (string, error)
 }
 
+// ErrAuthExpired means the server started rejecting the token partway
+// through a run. The run stops at that point; State keeps everything that
+// finished, so syncing again after logging in picks up where it left off.
+var ErrAuthExpired = fmt.Errorf("authentication expired mid-sync — re-login and resume: %w", api.ErrUnauthorized)
+
+// stopped returns why the current run must stop early: ErrAuthExpired once
+// the server has rejected the token, the Store's error once it failed to
+// read a record, or the context's error once the run is canceled. Like an
+// expired token, a cancel keeps everything that finished in State.
+func (e *Engine) stopped(ctx context.Context) error {
+	if e.authExpired {
+		return ErrAuthExpired
+	}
+	if err := e.Store.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// ErrStaleState means most tracked files are unknown to the server, e.g. the
+// profile points at the wrong server or the account was reset.
+var ErrStaleState = errors.New("sync state does not match this server")
+
+// ErrRootMismatch means the sync state was built against a different remote
+// root than the one this run syncs, so its records point at the wrong files.
+var ErrRootMismatch = errors.New("sync state belongs to a different remote root")
+
+// ErrSyncDirMismatch means the sync state was built for a different local
+// directory than SyncDir. Its records would all look deleted locally, and a
+// push would delete them on the server.
+var ErrSyncDirMismatch = errors.New("sync state belongs to a different local directory")
+
+// CheckRoot returns ErrRootMismatch if the state's records were synced
+// against a remote root other than RootDir, or ErrSyncDirMismatch if they
+// were synced from a local directory other than SyncDir. Otherwise it
+// records both in the state. State saved before roots were recorded is
+// taken to match, unless ScopeToRoot is set and it tracks files.
+func (e *Engine) CheckRoot() error {
+	if err := e.checkSyncDir(); err != nil {
+		return err
+	}
+	switch {
+	case e.State.Root == e.RootDir:
+	case e.State.Root == "" && (!e.ScopeToRoot || !e.Store.HasRecords()):
+		e.State.Root = e.RootDir
+	case e.State.Root == "":
+		return fmt.Errorf("%w: it tracks files outside /%s", ErrRootMismatch, e.RootDir)
+	default:
+		return fmt.Errorf("%w: it tracks /%s, not /%s", ErrRootMismatch, e.State.Root, e.RootDir)
+	}
+	return nil
+}
+
+// checkSyncDir returns ErrSyncDirMismatch if the state tracks files synced
+// from a local directory other than SyncDir, and otherwise records SyncDir
+// in the state. State without records can move freely.
+func (e *Engine) checkSyncDir() error {
+	dir, err := filepath.Abs(e.SyncDir)
+	if err != nil {
+		return err
+	}
+	switch {
+	case e.State.SyncDir == dir:
+	case e.State.SyncDir == "" || (!e.Store.HasRecords() && len(e.State.Notes) == 0):
+		e.State.SyncDir = dir
+	default:
+		return fmt.Errorf("%w: it tracks %s, not %s", ErrSyncDirMismatch, e.State.SyncDir, dir)
+	}
+	return nil
+}
+
+// A push is refused when at least staleMissingRatio of at least
+// staleMinTracked tracked remote IDs are missing on the server.
+const (
+	staleMinTracked   = 10
+	staleMissingRatio = 0.8
+)
+
+// checkStaleState returns ErrStaleState if a high fraction of tracked
+// RemoteIDs no longer exist on the server.
+func (e *Engine) checkStaleState(remoteFilesByPath map[string]api.FileEntry) error {
+	remoteIDs := make(map[string]bool, len(remoteFilesByPath))
+	for _, f := range remoteFilesByPath {
+		remoteIDs[f.ID] = true
+	}
+
+	tracked, missing := 0, 0
+	e.Store.EachRecord(func(_ string, rec FileRecord) {
+		if rec.RemoteID == "" {
+			return
+		}
+		tracked++
+		if !remoteIDs[rec.RemoteID] {
+			missing++
+		}
+	})
+
+	if tracked >= staleMinTracked && float64(missing) >= staleMissingRatio*float64(tracked) {
+		return fmt.Errorf("%w: %d of %d tracked files are missing on the server", ErrStaleState, missing, tracked)
+	}
+	return nil
+}
+
+// defaultCheckpointEvery is how many finished files pass between Checkpoint
+// calls when CheckpointEvery is unset.
+const defaultCheckpointEvery = 50
+
+// checkpoint counts one finished file and calls Checkpoint once every
+// CheckpointEvery of them.
+func (e *Engine) checkpoint(result *SyncResult) {
+	if e.Checkpoint == nil || e.DryRun {
+		return
+	}
+	every := e.CheckpointEvery
+	if every <= 0 {
+		every = defaultCheckpointEvery
+	}
+	e.sinceCheckpoint++
+	if e.sinceCheckpoint < every {
+		return
+	}
+	e.sinceCheckpoint = 0
+	if err := e.Checkpoint(); err != nil {
+		result.addError(fmt.Sprintf("checkpoint: %v", err))
+	}
+}
+
+// out returns where progress lines are written.
+func (e *Engine) out() io.Writer {
+	if e.Out == nil {
+		return os.Stdout
+	}
+	return e.Out
+}
+
+// records returns a snapshot of every tracked record, so callers can update
+// the Store while looping over it.
+
+// DefaultRootDir is the remote root a sync dir maps to unless a profile's
+// sync_root or a project's root_dir says otherwise.
+const DefaultRootDir = "root"
+
 // NewEngine creates a sync engine.
 func NewEngine(client *api.Client, syncDir string, state *State) *Engine {
 	if state.Notes == nil {
 		state.Notes = make(map[string]string)
 	}
-	return &Engine{
+	e := &Engine{
 		Client:  client,
 		SyncDir: syncDir,
-		RootDir: "root",
+		RootDir: DefaultRootDir,
 		State:   state,
-		Ignore:  LoadIgnoreFile(syncDir),
+		Store:   NewJSONStore("", state),
+		Ignore:  LoadIgnoreRules(syncDir),
+		Types:   LoadFileTypes(syncDir, nil, nil),
+
+		PropagateDeletes: true,
+		FilterOSJunk:     true,
+		MaxTextSize:      int64(config.DefaultMaxTextSize),
+
+		hashFile: HashFile,
 	}
+	// A project config at the sync dir root can override the remote root,
+	// scoped like a profile's sync_root
+	if project, _ := config.LoadProjectConfig(syncDir); project != nil && project.RootDir != "" {
+		e.RootDir = strings.Trim(project.RootDir, "/")
+		e.ScopeToRoot = e.RootDir != DefaultRootDir
+		e.projectRoot = true
+	}
+	return e
+}
+
+// SetRoot maps the sync dir to the remote directory root, such as "work" or
+// "work/notes", for a profile's sync_root. A root other than DefaultRootDir
+// also sets ScopeToRoot, so profiles with different roots on one account
+// never pull each other's files. "" keeps the current root, and so does a
+// root_dir from a project config at the sync dir, which takes precedence.
+func (e *Engine) SetRoot(root string) {
+	root = strings.Trim(root, "/")
+	if root == "" || e.projectRoot {
+		return
+	}
+	e.RootDir = root
+	e.ScopeToRoot = root != DefaultRootDir
+}
+
+// ExcludeVCS layers the built-in VCS and dependency-directory ignore set
+// under the engine's rules. The user's own rules still win, so a "!" line
+// in .izeropignore can bring one of them back.
+func (e *Engine) ExcludeVCS() {
+	e.Ignore = VCSIgnoreRules().Merge(e.Ignore)
 }
 
 // SyncResult tracks what happened during a sync.
@@ -45,13 +325,58 @@ type SyncResult struct {
 	Downloaded int
 	Uploaded   int
 	Deleted    int
+	Moved      int
 	Skipped    int
 	Conflicts  int
 	Errors     []string
+
+	// ConflictDetails describes each conflict counted in Conflicts, in order.
+	ConflictDetails []ConflictDetail
+
+	// DeletesSkipped counts deletions not propagated because PropagateDeletes is off.
+	DeletesSkipped int
+	// Deferred counts uploads held back by DeferUpload and downloads held
+	// back by DeferDownload.
+	Deferred int
+	// StillWriting counts files skipped because SkipGrowing saw them changing.
+	StillWriting int
+	// Stuck counts files skipped after MaxUploadAttempts failed uploads.
+	Stuck int
+	// Existing counts files OnlyNew left alone because the server has them.
+	Existing int
+	// TooLarge lists files not uploaded because they exceed MaxFileSize.
+	TooLarge []string
+
+	mu gosync.Mutex
+}
+
+// inc adds one to counter, which must be one of r's own fields. Workers
+// transferring files in parallel share one SyncResult, so every update
+// goes through inc or addError rather than touching the fields directly.
+func (r *SyncResult) inc(counter *int) {
+	r.mu.Lock()
+	*counter++
+	r.mu.Unlock()
+}
+
+// addConflict counts a conflict and records its details.
+func (r *SyncResult) addConflict(d ConflictDetail) {
+	r.mu.Lock()
+	r.Conflicts++
+	r.ConflictDetails = append(r.ConflictDetails, d)
+	r.mu.Unlock()
+}
+
+// addError appends msg to Errors.
+func (r *SyncResult) addError(msg string) {
+	r.mu.Lock()
+	r.Errors = append(r.Errors, msg)
+	r.mu.Unlock()
 }
 
 // remoteToLocal converts a remote path to a local path.
-// Strips the sync directory prefix so /sync/foo.txt → foo.txt
+// Strips the sync directory prefix so /sync/foo.txt → foo.txt. It returns
+// "" for the root itself and, with ScopeToRoot, for paths outside it.
 func (e *Engine) remoteToLocal(remotePath string) string {
 	prefix := "/" + e.RootDir
 	if strings.HasPrefix(remotePath, prefix+"/") {
@@ -60,6 +385,9 @@ func (e *Engine) remoteToLocal(remotePath string) string {
 	if strings.HasPrefix(remotePath, prefix) && len(remotePath) == len(prefix) {
 		return ""
 	}
+	if e.ScopeToRoot {
+		return ""
+	}
 	// For paths not under the sync dir, strip leading slash
 	if strings.HasPrefix(remotePath, "/") {
 		return remotePath[1:]
@@ -67,6 +395,32 @@ func (e *Engine) remoteToLocal(remotePath string) string {
 	return remotePath
 }
 
+// ErrUnsafePath is returned for a server path that would resolve outside
+// the sync directory.
+var ErrUnsafePath = errors.New("path escapes the sync directory")
+
+// localPathFor joins a relative path derived from a server path onto
+// SyncDir. Server paths aren't trusted: one that is absolute or climbs out
+// of the sync dir (e.g. "../../etc/cron.d/x") is refused with ErrUnsafePath,
+// as is one whose parent directory is a symlink leading out of it. Under
+// SymlinkFollow links are the user's own and writing through them is the
+// point, so they aren't checked.
+func (e *Engine) localPathFor(localRel string) (string, error) {
+	if filepath.IsAbs(localRel) || filepath.VolumeName(localRel) != "" {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, localRel)
+	}
+	root := filepath.Clean(e.SyncDir)
+	localPath := filepath.Join(root, localRel)
+	rel, err := filepath.Rel(root, localPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, localRel)
+	}
+	if e.Symlinks != SymlinkFollow && !e.parentStaysInside(localPath) {
+		return "", fmt.Errorf("%w: %s (through a symlink)", ErrUnsafePath, localRel)
+	}
+	return localPath, nil
+}
+
 // localToRemote converts a local relative path to a remote path.
 func (e *Engine) localToRemote(localRel string) string {
 	return "/" + e.RootDir + "/" + filepath.ToSlash(localRel)
@@ -74,8 +428,8 @@ func (e *Engine) localToRemote(localRel string) string {
 
 // initRootDir discovers or creates the sync root directory on the server.
 // Returns the directory ID.
-func (e *Engine) initRootDir() (string, map[string]api.Directory, error) {
-	dirs, err := e.Client.ListDirectories()
+func (e *Engine) initRootDir(ctx context.Context) (string, map[string]api.Directory, error) {
+	dirs, err := e.Client.ListDirectories(ctx)
 	if err != nil {
 		return "", nil, err
 	}
@@ -89,56 +443,162 @@ func (e *Engine) initRootDir() (string, map[string]api.Directory, error) {
 	if rootDir, exists := remoteDirsByPath[rootPath]; exists {
 		return rootDir.ID, remoteDirsByPath, nil
 	}
+	if e.DryRun {
+		fmt.Fprintf(e.out(), "  📁 Creating: %s\n", rootPath)
+		return "", remoteDirsByPath, nil
+	}
+
+	// Create the sync root directory, and any missing parents of a nested root
+	parentID, path := "", ""
+	for _, name := range strings.Split(e.RootDir, "/") {
+		path += "/" + name
+		if dir, exists := remoteDirsByPath[path]; exists {
+			parentID = dir.ID
+			continue
+		}
+		dir, err := e.Client.CreateDirectory(ctx, name, parentID)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not create sync directory %q: %w", path, err)
+		}
+		remoteDirsByPath[path] = *dir
+		parentID = dir.ID
+	}
+	return parentID, remoteDirsByPath, nil
+}
+
+// ensureRemoteDir returns the ID of the server directory at remotePath
+// (e.g. /root/a/b), creating it and any missing parents below the root
+// rootID. Directories it creates are added to dirs, so later calls for the
+// same path don't go back to the server.
+func (e *Engine) ensureRemoteDir(ctx context.Context, remotePath, rootID string, dirs map[string]api.Directory) (string, error) {
+	if dir, ok := dirs[remotePath]; ok {
+		return dir.ID, nil
+	}
+	if remotePath == "/"+e.RootDir || remotePath == "/" {
+		if rootID == "" {
+			return "", fmt.Errorf("sync root /%s not found on server", e.RootDir)
+		}
+		return rootID, nil
+	}
 
-	// Create the sync root directory
-	dir, err := e.Client.CreateDirectory(e.RootDir, "")
+	parentID, err := e.ensureRemoteDir(ctx, filepath.ToSlash(filepath.Dir(remotePath)), rootID, dirs)
+	if err != nil {
+		return "", err
+	}
+	if e.Verbose {
+		fmt.Fprintf(e.out(), "  📁 Creating: %s\n", remotePath)
+	}
+	dir, err := e.Client.CreateDirectory(ctx, filepath.Base(remotePath), parentID)
 	if err != nil {
-		return "", nil, fmt.Errorf("could not create sync directory %q: %w", e.RootDir, err)
+		return "", fmt.Errorf("mkdir %s: %w", remotePath, err)
 	}
-	remoteDirsByPath[rootPath] = *dir
-	return dir.ID, remoteDirsByPath, nil
+	dirs[remotePath] = *dir
+	e.emit(Event{Path: e.remoteToLocal(remotePath), Action: ActionCreatedDir, Reason: "new local directory", RemoteID: dir.ID})
+	return dir.ID, nil
 }
 
 // PullSync downloads remote changes to the local sync directory.
-func (e *Engine) PullSync(cursor string) (*SyncResult, string, error) {
+// Changes are applied a page at a time, and once a page is applied the
+// cursor moves past it (in Store too, unless DryRun), so an interrupted
+// pull picks up after the last finished page. A page with a download held
+// back by DeferDownload stops the cursor there. With OnProgress set, all
+// pages are fetched first so the work is known before any download starts.
+func (e *Engine) PullSync(ctx context.Context, cursor string) (*SyncResult, string, error) {
+	if err := e.CheckRoot(); err != nil {
+		return nil, cursor, err
+	}
 	result := &SyncResult{}
 
-	changes, err := e.Client.GetChanges(cursor)
-	if err != nil {
-		return nil, cursor, fmt.Errorf("could not fetch changes: %w", err)
-	}
+	// Index tracked files by remote ID to spot files moved on the server
+	e.remoteIndex = make(map[string]string)
+	e.Store.EachRecord(func(relPath string, rec FileRecord) {
+		if rec.RemoteID != "" {
+			e.remoteIndex[rec.RemoteID] = relPath
+		}
+	})
 
-	for _, change := range changes.Changes {
-		switch change.Type {
-		case "directory":
-			e.handleDirectoryChange(change, result)
-		case "file":
-			e.handleFileChange(change, result)
+	applied := cursor
+	held := false // a deferred download keeps the cursor before its page
+	apply := func(page *api.ChangesResponse) error {
+		deferred := result.Deferred
+		for _, change := range page.Changes {
+			if err := e.stopped(ctx); err != nil {
+				return err
+			}
+			switch change.Type {
+			case "directory":
+				e.handleDirectoryChange(ctx, change, result)
+			case "file":
+				e.handleFileChange(ctx, change, result)
+			}
+		}
+		// A page cut short is fetched again next time
+		if err := e.stopped(ctx); err != nil {
+			return err
+		}
+		if held = held || result.Deferred > deferred; held {
+			return nil
 		}
+		applied = page.Cursor
+		if !e.DryRun {
+			e.Store.SetCursor(applied)
+		}
+		return nil
 	}
 
-	// If there are more changes, keep fetching
-	if changes.HasMore {
-		moreResult, newCursor, err := e.PullSync(changes.Cursor)
+	var pages []*api.ChangesResponse
+	var fetchErr error
+	next := cursor
+	for n := 0; ; n++ {
+		page, err := e.Client.GetChanges(ctx, next)
 		if err != nil {
-			return result, changes.Cursor, err
+			if n == 0 {
+				return nil, cursor, fmt.Errorf("could not fetch changes: %w", err)
+			}
+			// Keep what was applied; the cursor stays at the last good page
+			fetchErr = fmt.Errorf("could not fetch changes: %w", err)
+			break
+		}
+		if e.OnProgress != nil {
+			pages = append(pages, page)
+		} else if err := apply(page); err != nil {
+			return result, applied, err
+		}
+		next = page.Cursor
+		if !page.HasMore {
+			break
+		}
+	}
+
+	if e.OnProgress != nil {
+		var pending []api.Change
+		for _, page := range pages {
+			pending = append(pending, page.Changes...)
+		}
+		e.startProgress(e.planPull(pending))
+		for _, page := range pages {
+			if err := apply(page); err != nil {
+				return result, applied, err
+			}
 		}
-		result.Downloaded += moreResult.Downloaded
-		result.Deleted += moreResult.Deleted
-		result.Skipped += moreResult.Skipped
-		result.Errors = append(result.Errors, moreResult.Errors...)
-		return result, newCursor, nil
 	}
 
-	return result, changes.Cursor, nil
+	return result, applied, fetchErr
 }
 
 // PushSync scans the local sync directory and uploads new/changed files.
-func (e *Engine) PushSync() (*SyncResult, error) {
+func (e *Engine) PushSync(ctx context.Context) (*SyncResult, error) {
+	if err := e.CheckRoot(); err != nil {
+		return nil, err
+	}
 	result := &SyncResult{}
 
+	if e.OnProgress != nil {
+		e.startProgress(e.planPush())
+	}
+
 	// Get remote state — directories
-	rootID, remoteDirsByPath, err := e.initRootDir()
+	rootID, remoteDirsByPath, err := e.initRootDir(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not init sync directory: %w", err)
 	}
@@ -150,9 +610,9 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 	rootPrefix := "/" + e.RootDir
 	for path, dir := range remoteDirsByPath {
 		if path == rootPrefix || strings.HasPrefix(path, rootPrefix+"/") {
-			files, err := e.Client.ListFiles(dir.ID)
+			files, err := e.Client.ListFiles(ctx, dir.ID)
 			if err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("list files in %s: %v", path, err))
+				result.addError(fmt.Sprintf("list files in %s: %v", path, err))
 				continue
 			}
 			for _, f := range files {
@@ -161,10 +621,48 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 		}
 	}
 
+	// Refuse to push against a server that doesn't know our tracked files —
+	// re-uploading everything would duplicate the whole tree.
+	if !e.Force && len(result.Errors) == 0 {
+		if err := e.checkStaleState(remoteFilesByPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Index tracked files that have disappeared locally by content hash so a
+	// "new" file with the same hash can be treated as a rename/move.
+	missingByHash := make(map[string]string)
+	e.Store.EachRecord(func(relPath string, rec FileRecord) {
+		if rec.Hash == "" || rec.RemoteID == "" {
+			return
+		}
+		if _, isNote := e.State.Notes[relPath]; isNote {
+			return
+		}
+		if _, statErr := e.statLocal(filepath.Join(e.SyncDir, relPath)); os.IsNotExist(statErr) {
+			missingByHash[rec.Hash] = relPath
+		}
+	})
+
+	// Recently modified files about to be uploaded are held back until the
+	// walk is done, then probed for ongoing writes all at once
+	var growing []growingFile
+	settled := make(map[string]bool)
+	holdBack := func(path string, info os.FileInfo) bool {
+		if !e.SkipGrowing || settled[path] || !recentlyModified(info) {
+			return false
+		}
+		growing = append(growing, growingFile{path: path, info: info})
+		return true
+	}
+
 	// Walk local directory
-	err = filepath.Walk(e.SyncDir, func(path string, info os.FileInfo, walkErr error) error {
+	visit := func(path string, info os.FileInfo, walkErr error) error {
+		if e.stopped(ctx) != nil {
+			return filepath.SkipAll
+		}
 		if walkErr != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("walk error: %s: %v", path, walkErr))
+			result.addError(fmt.Sprintf("walk error: %s: %v", path, walkErr))
 			return nil
 		}
 
@@ -182,14 +680,15 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 		}
 
 		// Check ignore rules
-		if e.Ignore.IsIgnored(relPath, info.IsDir()) {
+		if e.isIgnored(relPath, info.IsDir()) {
 			if e.Verbose {
-				fmt.Printf("  ⏭ Ignored: %s\n", relPath)
+				fmt.Fprintf(e.out(), "  ⏭ Ignored: %s\n", relPath)
 			}
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
-			result.Skipped++
+			result.inc(&result.Skipped)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Reason: "ignored"})
 			return nil
 		}
 
@@ -209,25 +708,59 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 					parentID = rootDir.ID
 				}
 
-				if e.Verbose {
-					fmt.Printf("  📁 Creating: %s\n", remotePath)
+				if e.Verbose || e.DryRun {
+					fmt.Fprintf(e.out(), "  📁 Creating: %s\n", remotePath)
+				}
+				if e.DryRun {
+					e.emit(Event{Path: relPath, Action: ActionCreatedDir, Reason: "new local directory"})
+					return nil
 				}
-				dir, createErr := e.Client.CreateDirectory(info.Name(), parentID)
+				dir, createErr := e.Client.CreateDirectory(ctx, info.Name(), parentID)
 				if createErr != nil {
-					result.Errors = append(result.Errors, fmt.Sprintf("mkdir %s: %v", remotePath, createErr))
+					result.addError(fmt.Sprintf("mkdir %s: %v", remotePath, createErr))
 				} else {
 					remoteDirsByPath[remotePath] = *dir
+					e.emit(Event{Path: relPath, Action: ActionCreatedDir, Reason: "new local directory", RemoteID: dir.ID})
 				}
 			}
 			return nil
 		}
 
+		if isSymlink(info) {
+			e.pushSymlink(ctx, result, relPath, path, info, remoteFilesByPath, remoteDirsByPath)
+			return nil
+		}
+
+		// In add-only mode, anything the server already has is left as is
+		if e.OnlyNew {
+			_, isNote := e.State.Notes[relPath]
+			remoteFile, exists := remoteFilesByPath[remotePath]
+			if isNote || exists {
+				if e.Verbose {
+					fmt.Fprintf(e.out(), "  ⏭ Exists: %s\n", relPath)
+				}
+				result.inc(&result.Existing)
+				e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "already on server", RemoteID: remoteFile.ID})
+				return nil
+			}
+		}
+
+		// Don't keep retrying a file the server keeps rejecting
+		if f, stuck := e.stuckUpload(relPath, info); stuck {
+			if e.Verbose {
+				fmt.Fprintf(e.out(), "  ⛔ Stuck: %s (upload failed %d times: %s)\n", relPath, f.Count, f.LastError)
+			}
+			result.inc(&result.Stuck)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: fmt.Sprintf("upload failed %d times", f.Count)})
+			return nil
+		}
+
 		// Check if this is a tracked note file
 		if noteID, isNote := e.State.Notes[relPath]; isNote {
 			// This is a note — use text API to update
 			contents, readErr := os.ReadFile(path)
 			if readErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("read %s: %v", relPath, readErr))
+				e.fail(ctx, result, relPath, readErr, fmt.Sprintf("read %s: %v", relPath, readErr))
 				return nil
 			}
 
@@ -239,35 +772,52 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 
 			if remoteFile, exists := remoteFilesByPath[noteRemotePath]; exists {
 				if remoteFile.Size == info.Size() {
-					result.Skipped++
+					result.inc(&result.Skipped)
+					e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "note unchanged", RemoteID: noteID})
 					return nil
 				}
 			}
 
-			if e.Verbose {
-				fmt.Printf("  📝 Updating note: %s\n", relPath)
+			if holdBack(path, info) {
+				return nil
+			}
+			if e.Verbose || e.DryRun {
+				fmt.Fprintf(e.out(), "  📝 Updating note: %s\n", relPath)
+			}
+			var updateErr error
+			if !e.DryRun {
+				_, updateErr = e.Client.UpdateFile(ctx, noteID, map[string]string{
+					"contents": string(contents),
+				})
 			}
-			_, updateErr := e.Client.UpdateFile(noteID, map[string]string{
-				"contents": string(contents),
-			})
 			if updateErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("update note %s: %v", relPath, updateErr))
+				e.fail(ctx, result, relPath, updateErr, fmt.Sprintf("update note %s: %v", relPath, updateErr))
+				e.uploadFailed(ctx, relPath, info, updateErr)
 			} else {
-				noteHash, _ := HashFile(path)
-				e.State.Files[relPath] = FileRecord{
+				e.uploadSucceeded(relPath)
+				noteHash, _ := e.hashFile(path)
+				e.Store.SetRecord(relPath, FileRecord{
 					RemoteID: noteID,
 					Size:     info.Size(),
 					Hash:     noteHash,
 					LocalMod: info.ModTime().Unix(),
-				}
-				result.Uploaded++
+				})
+				result.inc(&result.Uploaded)
+				e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "note changed locally", RemoteID: noteID})
+				e.checkpoint(result)
+				e.advanceProgress(info.Size())
 			}
 			return nil
 		}
 
 		// Skip conflict files
 		if strings.Contains(info.Name(), ".conflict") {
-			result.Skipped++
+			result.inc(&result.Skipped)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "conflict copy"})
+			return nil
+		}
+
+		if e.tooLarge(result, relPath, info) {
 			return nil
 		}
 
@@ -275,45 +825,63 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 		remoteFile, exists := remoteFilesByPath[remotePath]
 		if exists {
 			// If server provides content_hash, compare directly
-			localHash, hashErr := HashFile(path)
+			localHash, hashErr := e.hashLocal(relPath, path, info)
 			if hashErr == nil && remoteFile.ContentHash != "" && localHash == remoteFile.ContentHash {
-				e.State.Files[relPath] = FileRecord{
+				e.Store.SetRecord(relPath, FileRecord{
 					RemoteID:   remoteFile.ID,
 					Size:       info.Size(),
 					Hash:       localHash,
 					RemoteTime: remoteFile.UpdatedAt,
 					LocalMod:   info.ModTime().Unix(),
-				}
-				result.Skipped++
+					Mode:       e.syncMode(ctx, result, relPath, path, remoteFile.ID, info, remoteFile.Mode),
+				})
+				result.inc(&result.Skipped)
+				e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "content matches server", RemoteID: remoteFile.ID})
 				return nil
 			}
 
 			// Fallback: use local state hash for comparison
 			if hashErr == nil {
-				if rec, tracked := e.State.Files[relPath]; tracked && rec.Hash != "" && rec.Hash == localHash && rec.RemoteTime == remoteFile.UpdatedAt {
+				if rec, tracked := e.Store.GetRecord(relPath); tracked && rec.Hash != "" && rec.Hash == localHash && rec.RemoteTime == remoteFile.UpdatedAt {
 					// Hash matches what we last synced AND remote hasn't changed — skip
-					result.Skipped++
+					if mode := e.syncMode(ctx, result, relPath, path, remoteFile.ID, info, remoteFile.Mode); mode != rec.Mode {
+						rec.Mode = mode
+						e.Store.SetRecord(relPath, rec)
+					}
+					result.inc(&result.Skipped)
+					e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "unchanged since last sync", RemoteID: remoteFile.ID})
 					return nil
 				}
 			}
 
 			if remoteFile.Size == info.Size() && localHash != "" {
-				if rec, tracked := e.State.Files[relPath]; tracked && rec.Hash == localHash {
+				if rec, tracked := e.Store.GetRecord(relPath); tracked && rec.Hash == localHash {
 					// Same hash as last sync, same size — remote metadata might differ but content is same
-					e.State.Files[relPath] = FileRecord{
+					e.Store.SetRecord(relPath, FileRecord{
 						RemoteID:   remoteFile.ID,
 						Size:       info.Size(),
 						Hash:       localHash,
 						RemoteTime: remoteFile.UpdatedAt,
 						LocalMod:   info.ModTime().Unix(),
-					}
-					result.Skipped++
+						Mode:       e.syncMode(ctx, result, relPath, path, remoteFile.ID, info, remoteFile.Mode),
+					})
+					result.inc(&result.Skipped)
+					e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "unchanged since last sync", RemoteID: remoteFile.ID})
 					return nil
 				}
 			}
 
-			// File exists on remote but content differs — check for conflict
-			if rec, tracked := e.State.Files[relPath]; tracked {
+			if holdBack(path, info) {
+				return nil
+			}
+
+			// File exists on remote but content differs — check for conflict.
+			// A record that couldn't be read mustn't pass for untracked.
+			rec, tracked := e.Store.GetRecord(relPath)
+			if e.stopped(ctx) != nil {
+				return nil
+			}
+			if tracked {
 				// Remote changed if updated_at differs from what we last saw
 				if rec.RemoteTime != "" && rec.RemoteTime != remoteFile.UpdatedAt {
 					// Remote changed — but did LOCAL actually change?
@@ -322,52 +890,45 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 					if !localChanged {
 						// Only remote changed — skip push, let next pull handle it
 						if e.Verbose {
-							fmt.Printf("  ⏭ Remote updated (local unchanged): %s\n", relPath)
+							fmt.Fprintf(e.out(), "  ⏭ Remote updated (local unchanged): %s\n", relPath)
 						}
-						result.Skipped++
+						result.inc(&result.Skipped)
+						e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "changed on server only", RemoteID: remoteFile.ID})
 						return nil
 					}
 
 					// Both sides changed — genuine conflict
-					ext := filepath.Ext(path)
-					base := strings.TrimSuffix(path, ext)
-					conflictPath := fmt.Sprintf("%s.conflict%s", base, ext)
-					if ext == "" {
-						conflictPath = path + ".conflict"
+					if e.DryRun {
+						fmt.Fprintf(e.out(), "  ⚠ Conflict: %s (local would be saved as %s%s)\n", relPath, e.conflictLabel(e.conflictPath(path)), conflictSource(remoteFile.ModifiedBy))
+						result.addConflict(ConflictDetail{Path: relPath, LocalHash: localHash, RemoteHash: remoteFile.ContentHash})
+						e.emit(Event{Path: relPath, Action: ActionConflict, Size: remoteFile.Size, Reason: "changed on both sides (server wins)", RemoteID: remoteFile.ID})
+						return nil
 					}
 
 					// Save local version as conflict, let remote win
-					if copyErr := copyFile(path, conflictPath); copyErr != nil {
-						result.Errors = append(result.Errors, fmt.Sprintf("conflict backup %s: %v", relPath, copyErr))
-					} else if e.Verbose {
-						fmt.Printf("  ⚠ Conflict: %s (local saved as %s)\n", relPath, filepath.Base(conflictPath))
+					conflict := ConflictDetail{Path: relPath, LocalHash: localHash, RemoteHash: remoteFile.ContentHash}
+					if conflictPath, copyErr := e.saveConflict(path); copyErr != nil {
+						e.fail(ctx, result, relPath, copyErr, fmt.Sprintf("conflict backup %s: %v", relPath, copyErr))
+					} else {
+						conflict.ConflictFile = e.conflictLabel(conflictPath)
+						e.recordConflict(conflictPath, remoteFile.ModifiedBy)
+						if e.Verbose {
+							fmt.Fprintf(e.out(), "  ⚠ Conflict: %s (local saved as %s%s)\n", relPath, e.conflictLabel(conflictPath), conflictSource(remoteFile.ModifiedBy))
+						}
 					}
 
 					// Download remote version as the winner
 					tmpPath := path + ".izerop-tmp"
-					f, dlErr := os.Create(tmpPath)
-					if dlErr == nil {
-						_, dlErr = e.Client.DownloadFile(remoteFile.ID, f)
-						f.Close()
-						if dlErr != nil {
-							os.Remove(tmpPath)
-							result.Errors = append(result.Errors, fmt.Sprintf("conflict download %s: %v", relPath, dlErr))
-						} else {
-							os.Rename(tmpPath, path)
-							if newInfo, err := os.Stat(path); err == nil {
-								h, _ := HashFile(path)
-								e.State.Files[relPath] = FileRecord{
-									RemoteID:   remoteFile.ID,
-									Size:       newInfo.Size(),
-									Hash:       h,
-									RemoteTime: remoteFile.UpdatedAt,
-									LocalMod:   newInfo.ModTime().Unix(),
-								}
-							}
-						}
+					if dlErr := e.downloadTemp(ctx, remoteFile.ID, remoteFile.Size, remoteFile.ContentHash, tmpPath); dlErr != nil {
+						e.fail(ctx, result, relPath, dlErr, fmt.Sprintf("conflict download %s: %v", relPath, dlErr))
+					} else if rec, err := e.installDownload(tmpPath, path, remoteFile.Mode); err == nil {
+						rec.RemoteID = remoteFile.ID
+						rec.RemoteTime = remoteFile.UpdatedAt
+						e.Store.SetRecord(relPath, rec)
 					}
 
-					result.Conflicts++
+					result.addConflict(conflict)
+					e.emit(Event{Path: relPath, Action: ActionConflict, Size: remoteFile.Size, Reason: "changed on both sides (server wins)", RemoteID: remoteFile.ID})
 					return nil
 				}
 			}
@@ -377,32 +938,45 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 				// Text file on server: read local contents and update via API
 				contents, readErr := os.ReadFile(path)
 				if readErr != nil {
-					result.Errors = append(result.Errors, fmt.Sprintf("read %s: %v", relPath, readErr))
+					e.fail(ctx, result, relPath, readErr, fmt.Sprintf("read %s: %v", relPath, readErr))
 					return nil
 				}
-				if e.Verbose {
-					fmt.Printf("  📝 Updating text: %s\n", relPath)
+				if e.Verbose || e.DryRun {
+					fmt.Fprintf(e.out(), "  📝 Updating text: %s\n", relPath)
+				}
+				var updateErr error
+				if !e.DryRun {
+					_, updateErr = e.Client.UpdateFile(ctx, remoteFile.ID, map[string]string{
+						"contents": string(contents),
+					})
 				}
-				_, updateErr := e.Client.UpdateFile(remoteFile.ID, map[string]string{
-					"contents": string(contents),
-				})
 				if updateErr != nil {
-					result.Errors = append(result.Errors, fmt.Sprintf("update %s: %v", relPath, updateErr))
+					e.fail(ctx, result, relPath, updateErr, fmt.Sprintf("update %s: %v", relPath, updateErr))
+					e.uploadFailed(ctx, relPath, info, updateErr)
 				} else {
-					h, _ := HashFile(path)
-					e.State.Files[relPath] = FileRecord{
+					e.uploadSucceeded(relPath)
+					h, _ := e.hashFile(path)
+					e.Store.SetRecord(relPath, FileRecord{
 						RemoteID:   remoteFile.ID,
 						Size:       info.Size(),
 						Hash:       h,
 						RemoteTime: remoteFile.UpdatedAt,
 						LocalMod:   info.ModTime().Unix(),
-					}
-					result.Uploaded++
+						Mode:       e.syncMode(ctx, result, relPath, path, remoteFile.ID, info, remoteFile.Mode),
+					})
+					result.inc(&result.Uploaded)
+					e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "changed locally", RemoteID: remoteFile.ID})
+					e.checkpoint(result)
+					e.advanceProgress(info.Size())
 				}
 				return nil
 			}
 		}
 
+		if !exists && holdBack(path, info) {
+			return nil
+		}
+
 		// Find the directory ID for this file
 		dirRemotePath := filepath.ToSlash(filepath.Dir(remotePath))
 		dirID := ""
@@ -410,297 +984,346 @@ func (e *Engine) PushSync() (*SyncResult, error) {
 			dirID = dir.ID
 		}
 
-		if dirID == "" {
-			result.Errors = append(result.Errors, fmt.Sprintf("no remote directory for %s (dir: %s)", remotePath, dirRemotePath))
+		// In a dry run, directories that would be created have no ID yet
+		if dirID == "" && !e.DryRun {
+			e.fail(ctx, result, relPath, nil, fmt.Sprintf("no remote directory for %s (dir: %s)", remotePath, dirRemotePath))
 			return nil
 		}
 
+		// New file whose content matches a tracked file that vanished — move it
+		if !exists && len(missingByHash) > 0 && !e.OnlyNew {
+			if localHash, hashErr := e.hashFile(path); hashErr == nil {
+				if oldRel, ok := missingByHash[localHash]; ok {
+					rec, _ := e.Store.GetRecord(oldRel)
+					if e.Verbose || e.DryRun {
+						fmt.Fprintf(e.out(), "  🔀 Moving: %s → %s\n", oldRel, relPath)
+					}
+					var moved *api.FileEntry
+					var moveErr error
+					if !e.DryRun {
+						moved, moveErr = e.Client.MoveFile(ctx, rec.RemoteID, info.Name(), dirID)
+					}
+					if moveErr != nil {
+						e.fail(ctx, result, relPath, moveErr, fmt.Sprintf("move %s → %s: %v", oldRel, relPath, moveErr))
+					} else {
+						remoteTime := ""
+						if moved != nil {
+							remoteTime = moved.UpdatedAt
+						}
+						delete(missingByHash, localHash)
+						e.Store.DeleteRecord(oldRel)
+						e.Store.SetRecord(relPath, FileRecord{
+							RemoteID:   rec.RemoteID,
+							Size:       info.Size(),
+							Hash:       localHash,
+							RemoteTime: remoteTime,
+							LocalMod:   info.ModTime().Unix(),
+						})
+						result.inc(&result.Moved)
+						e.emit(Event{Path: relPath, Action: ActionMoved, Size: info.Size(), Reason: "moved from " + oldRel, RemoteID: rec.RemoteID})
+						e.checkpoint(result)
+						e.advanceProgress(info.Size())
+						return nil
+					}
+				}
+			}
+		}
+
 		// Decide: text file or binary upload?
-		if isTextFile(path, info) {
+		isText := e.isText(path, relPath, info)
+		if !isText && e.DeferUpload != nil && e.DeferUpload(relPath, info.Size()) {
+			if e.Verbose {
+				fmt.Fprintf(e.out(), "  ⏸ Deferred: %s\n", relPath)
+			}
+			result.inc(&result.Deferred)
+			return nil
+		}
+		if isText {
 			contents, readErr := os.ReadFile(path)
 			if readErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("read %s: %v", relPath, readErr))
+				e.fail(ctx, result, relPath, readErr, fmt.Sprintf("read %s: %v", relPath, readErr))
 				return nil
 			}
-			if e.Verbose {
-				fmt.Printf("  📝 Creating text: %s\n", relPath)
+			if e.Verbose || e.DryRun {
+				fmt.Fprintf(e.out(), "  📝 Creating text: %s\n", relPath)
+			}
+			var created *api.FileEntry
+			var createErr error
+			if !e.DryRun {
+				created, createErr = e.Client.CreateTextFile(ctx, info.Name(), string(contents), dirID, "")
 			}
-			created, createErr := e.Client.CreateTextFile(info.Name(), string(contents), dirID, "")
 			if createErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("create text %s: %v", relPath, createErr))
+				e.fail(ctx, result, relPath, createErr, fmt.Sprintf("create text %s: %v", relPath, createErr))
+				e.uploadFailed(ctx, relPath, info, createErr)
 			} else {
-				h, _ := HashFile(path)
+				e.uploadSucceeded(relPath)
+				h, _ := e.hashFile(path)
 				rid := ""
+				var remoteMode uint32
 				if created != nil {
-					rid = created.ID
+					rid, remoteMode = created.ID, created.Mode
 				}
-				e.State.Files[relPath] = FileRecord{
+				e.Store.SetRecord(relPath, FileRecord{
 					RemoteID: rid,
 					Size:     info.Size(),
 					Hash:     h,
 					LocalMod: info.ModTime().Unix(),
-				}
-				result.Uploaded++
+					Mode:     e.syncMode(ctx, result, relPath, path, rid, info, remoteMode),
+				})
+				result.inc(&result.Uploaded)
+				e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "new local file", RemoteID: rid})
+				e.checkpoint(result)
+				e.advanceProgress(info.Size())
 			}
 		} else {
-			if e.Verbose {
-				fmt.Printf("  ⬆ Uploading: %s\n", relPath)
+			if e.Verbose || e.DryRun {
+				fmt.Fprintf(e.out(), "  ⬆ Uploading: %s\n", relPath)
+			}
+			var uploaded *api.FileEntry
+			var uploadErr error
+			if !e.DryRun {
+				uploaded, uploadErr = e.Client.UploadFile(ctx, path, dirID, info.Name())
 			}
-			uploaded, uploadErr := e.Client.UploadFile(path, dirID, info.Name())
 			if uploadErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("upload %s: %v", relPath, uploadErr))
+				e.fail(ctx, result, relPath, uploadErr, fmt.Sprintf("upload %s: %v", relPath, uploadErr))
+				e.uploadFailed(ctx, relPath, info, uploadErr)
 			} else {
-				h, _ := HashFile(path)
+				e.uploadSucceeded(relPath)
+				h, _ := e.hashFile(path)
 				rid := ""
+				var remoteMode uint32
 				if uploaded != nil {
-					rid = uploaded.ID
+					rid, remoteMode = uploaded.ID, uploaded.Mode
 				}
-				e.State.Files[relPath] = FileRecord{
+				e.Store.SetRecord(relPath, FileRecord{
 					RemoteID: rid,
 					Size:     info.Size(),
 					Hash:     h,
 					LocalMod: info.ModTime().Unix(),
-				}
-				result.Uploaded++
+					Mode:     e.syncMode(ctx, result, relPath, path, rid, info, remoteMode),
+				})
+				result.inc(&result.Uploaded)
+				e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "new local file", RemoteID: rid})
+				e.checkpoint(result)
+				e.advanceProgress(info.Size())
 			}
 		}
 
 		return nil
+	}
+	err = e.walk(visit, func(path, reason string) {
+		relPath, _ := filepath.Rel(e.SyncDir, path)
+		if strings.HasPrefix(filepath.Base(path), ".") || e.ignoredPath(relPath, false) {
+			return
+		}
+		if e.Verbose {
+			fmt.Fprintf(e.out(), "  ⏭ Skipping %s: %s\n", reason, relPath)
+		}
+		result.inc(&result.Skipped)
+		e.emit(Event{Path: relPath, Action: ActionSkipped, Reason: reason})
 	})
 
 	if err != nil {
 		return result, fmt.Errorf("walk failed: %w", err)
 	}
 
-	// Detect local deletions: tracked files that no longer exist on disk
-	// If a file is in State.Files but missing locally, the user deleted it — propagate to server
-	for relPath, rec := range e.State.Files {
-		localPath := filepath.Join(e.SyncDir, relPath)
-		if _, statErr := os.Stat(localPath); os.IsNotExist(statErr) {
-			if rec.RemoteID == "" {
-				// No remote ID tracked, just clean up state
-				delete(e.State.Files, relPath)
+	// Don't upload a snapshot of a file that is still being appended to
+	if len(growing) > 0 && e.stopped(ctx) == nil {
+		writing := stillWriting(growing)
+		for _, g := range growing {
+			if writing[g.path] {
+				if e.Verbose {
+					relPath, _ := filepath.Rel(e.SyncDir, g.path)
+					fmt.Fprintf(e.out(), "  ✏ Still writing: %s\n", relPath)
+				}
+				result.inc(&result.StillWriting)
 				continue
 			}
-			if e.Verbose {
-				fmt.Printf("  🗑 Deleting (local removed): %s\n", relPath)
-			}
-			if delErr := e.Client.DeleteFile(rec.RemoteID); delErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("delete %s: %v", relPath, delErr))
-			} else {
-				result.Deleted++
+			settled[g.path] = true
+			visit(g.path, g.info, nil)
+		}
+	}
+	if err := e.stopped(ctx); err != nil {
+		return result, err
+	}
+	e.pruneUploadFailures()
+
+	// Add-only pushes never delete anything on the server
+	if e.OnlyNew {
+		return result, nil
+	}
+
+	// Detect local deletions: tracked files that no longer exist on disk
+	// If a file is tracked in the Store but missing locally, the user deleted it — propagate to server.
+	// Ignored paths are outside the sync, so ignoring a file never deletes
+	// it from the server; its record is kept for if it's un-ignored.
+	removed := make(map[string]FileRecord)
+	e.Store.EachRecord(func(relPath string, rec FileRecord) {
+		if e.ignoredPath(relPath, false) {
+			return
+		}
+		if _, statErr := e.statLocal(filepath.Join(e.SyncDir, relPath)); os.IsNotExist(statErr) {
+			removed[relPath] = rec
+		}
+	})
+	for relPath, rec := range removed {
+		if rec.RemoteID == "" {
+			// No remote ID tracked, just clean up state
+			e.Store.DeleteRecord(relPath)
+			continue
+		}
+		if !e.PropagateDeletes {
+			// Keep the record so the skip is reported on every run until resolved
+			result.inc(&result.DeletesSkipped)
+			continue
+		}
+		if e.Verbose || e.DryRun {
+			fmt.Fprintf(e.out(), "  🗑 Deleting (local removed): %s\n", relPath)
+		}
+		var delErr error
+		if !e.DryRun {
+			delErr = e.Client.DeleteFile(ctx, rec.RemoteID)
+		}
+		if delErr != nil {
+			e.fail(ctx, result, relPath, delErr, fmt.Sprintf("delete %s: %v", relPath, delErr))
+			if err := e.stopped(ctx); err != nil {
+				return result, err
 			}
-			delete(e.State.Files, relPath)
+		} else {
+			result.inc(&result.Deleted)
+			e.emit(Event{Path: relPath, Action: ActionDeleted, Size: rec.Size, Reason: "removed locally", RemoteID: rec.RemoteID})
+			e.checkpoint(result)
 		}
+		e.Store.DeleteRecord(relPath)
 	}
 
 	// Same for tracked notes
 	for relPath, noteID := range e.State.Notes {
+		if e.ignoredPath(relPath, false) {
+			continue
+		}
 		localPath := filepath.Join(e.SyncDir, relPath)
-		if _, statErr := os.Stat(localPath); os.IsNotExist(statErr) {
-			if e.Verbose {
-				fmt.Printf("  🗑 Deleting note (local removed): %s\n", relPath)
+		if _, statErr := e.statLocal(localPath); os.IsNotExist(statErr) {
+			if !e.PropagateDeletes {
+				if _, tracked := e.Store.GetRecord(relPath); !tracked {
+					result.inc(&result.DeletesSkipped) // tracked files were counted above
+				}
+				continue
 			}
-			if delErr := e.Client.DeleteFile(noteID); delErr != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("delete note %s: %v", relPath, delErr))
+			if e.Verbose || e.DryRun {
+				fmt.Fprintf(e.out(), "  🗑 Deleting note (local removed): %s\n", relPath)
+			}
+			var delErr error
+			if !e.DryRun {
+				delErr = e.Client.DeleteFile(ctx, noteID)
+			}
+			if delErr != nil {
+				e.fail(ctx, result, relPath, delErr, fmt.Sprintf("delete note %s: %v", relPath, delErr))
+				if err := e.stopped(ctx); err != nil {
+					return result, err
+				}
 			} else {
-				result.Deleted++
+				result.inc(&result.Deleted)
+				e.emit(Event{Path: relPath, Action: ActionDeleted, Reason: "note removed locally", RemoteID: noteID})
+				e.checkpoint(result)
 			}
 			delete(e.State.Notes, relPath)
 			// Also clean from Files if tracked there
-			delete(e.State.Files, relPath)
+			e.Store.DeleteRecord(relPath)
 		}
 	}
 
 	return result, nil
 }
 
+// tooLarge reports whether info exceeds MaxFileSize, counting the file as
+// skipped if so.
+func (e *Engine) tooLarge(result *SyncResult, relPath string, info os.FileInfo) bool {
+	if e.MaxFileSize <= 0 || info.Size() <= e.MaxFileSize {
+		return false
+	}
+	if e.Verbose {
+		fmt.Fprintf(e.out(), "  ⏭ Too large: %s (%d bytes)\n", relPath, info.Size())
+	}
+	result.mu.Lock()
+	result.Skipped++
+	result.TooLarge = append(result.TooLarge, relPath)
+	result.mu.Unlock()
+	e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "larger than max file size"})
+	return true
+}
+
 // Reconcile performs a full reconciliation using the server manifest as source of truth.
 // It compares every remote file against local state and vice versa.
-func (e *Engine) Reconcile(dryRun bool) (*SyncResult, error) {
+func (e *Engine) Reconcile(ctx context.Context, dryRun bool) (*SyncResult, error) {
+	if err := e.CheckRoot(); err != nil {
+		return nil, err
+	}
 	result := &SyncResult{}
 
-	manifest, err := e.Client.GetManifest(e.RootDir)
+	manifest, err := e.Client.GetManifest(ctx, e.RootDir)
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch manifest: %w", err)
 	}
 
-	// Ensure root directory structure exists locally
-	_, _, err = e.initRootDir()
+	// Ensure root directory structure exists locally. Directories created
+	// for uploads below are added to the map, so this one listing serves
+	// the whole run.
+	rootID, remoteDirsByPath, err := e.initRootDir(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not init root dir: %w", err)
 	}
 
-	// Build remote file index by relative path
-	remoteByPath := make(map[string]api.ManifestEntry)
-	rootPrefix := "/" + e.RootDir
-	for _, f := range manifest.Files {
-		relPath := f.Path
-		if strings.HasPrefix(relPath, rootPrefix+"/") {
-			relPath = relPath[len(rootPrefix)+1:]
-		}
-		// Notes (no extension on server) get .txt locally
-		if filepath.Ext(relPath) == "" {
-			relPath = relPath + ".txt"
-		}
-		remoteByPath[relPath] = f
-	}
+	remoteByPath := e.manifestByPath(manifest)
 
 	// Ensure remote directories exist locally
 	for _, d := range manifest.Directories {
-		relPath := d.Path
-		if strings.HasPrefix(relPath, rootPrefix+"/") {
-			relPath = relPath[len(rootPrefix)+1:]
-		} else if relPath == rootPrefix {
+		relPath := e.remoteToLocal(d.Path)
+		if relPath == "" {
 			continue
 		}
-		if relPath == "" {
+		localDir, err := e.localPathFor(relPath)
+		if err != nil {
+			e.fail(ctx, result, relPath, err, fmt.Sprintf("skipping directory %s: %v", d.Path, err))
 			continue
 		}
-		localDir := filepath.Join(e.SyncDir, relPath)
 		if !dryRun {
 			os.MkdirAll(localDir, 0755)
 		}
 	}
 
 	// Phase 1: Check remote files against local
+	// Entries already done by an interrupted run are skipped when resuming,
+	// unless the server copy changed since. Dry runs leave the checkpoint alone.
+	if !dryRun && (!e.Resume || e.State.ReconcileDone == nil) {
+		e.State.ReconcileDone = make(map[string]string)
+	}
 	for relPath, remote := range remoteByPath {
-		if e.Ignore != nil && e.Ignore.IsIgnored(relPath, false) {
-			continue
-		}
-
-		localPath := filepath.Join(e.SyncDir, relPath)
-		_, statErr := os.Stat(localPath)
-
-		if os.IsNotExist(statErr) {
-			// Remote exists, local missing → download
-			if e.Verbose || dryRun {
-				fmt.Printf("  ⬇ Missing locally: %s\n", relPath)
-			}
-			if !dryRun {
-				os.MkdirAll(filepath.Dir(localPath), 0755)
-				tmpPath := localPath + ".izerop-tmp"
-				f, err := os.Create(tmpPath)
-				if err != nil {
-					result.Errors = append(result.Errors, fmt.Sprintf("create %s: %v", relPath, err))
-					continue
-				}
-				_, err = e.Client.DownloadFile(remote.ID, f)
-				f.Close()
-				if err != nil {
-					os.Remove(tmpPath)
-					result.Errors = append(result.Errors, fmt.Sprintf("download %s: %v", relPath, err))
-					continue
-				}
-				if err := os.Rename(tmpPath, localPath); err != nil {
-					os.Remove(tmpPath)
-					result.Errors = append(result.Errors, fmt.Sprintf("rename %s: %v", relPath, err))
-					continue
-				}
-
-				// Track in state
-				if newInfo, err := os.Stat(localPath); err == nil {
-					hash, _ := HashFile(localPath)
-					e.State.Files[relPath] = FileRecord{
-						RemoteID:   remote.ID,
-						Size:       newInfo.Size(),
-						Hash:       hash,
-						RemoteTime: remote.UpdatedAt,
-						LocalMod:   newInfo.ModTime().Unix(),
-					}
-				}
-				if filepath.Ext(remote.Path) == "" {
-					e.State.Notes[relPath] = remote.ID
-				}
-			}
-			result.Downloaded++
-			continue
+		if err := e.stopped(ctx); err != nil {
+			// ReconcileDone is kept so --resume continues from here
+			return result, err
 		}
-
-		if statErr != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("stat %s: %v", relPath, statErr))
-			continue
-		}
-
-		// Both exist — compare hashes
-		localHash, hashErr := HashFile(localPath)
-		if hashErr != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("hash %s: %v", relPath, hashErr))
+		if doneAt, ok := e.State.ReconcileDone[relPath]; ok && !dryRun && doneAt == remote.UpdatedAt {
+			result.inc(&result.Skipped)
+			e.emit(Event{Path: relPath, Action: ActionSkipped, Size: remote.Size, Reason: "already reconciled", RemoteID: remote.ID})
 			continue
 		}
 
-		if remote.ContentHash != "" && localHash == remote.ContentHash {
-			// Identical — update state and skip
-			info, _ := os.Stat(localPath)
-			e.State.Files[relPath] = FileRecord{
-				RemoteID:   remote.ID,
-				Size:       info.Size(),
-				Hash:       localHash,
-				RemoteTime: remote.UpdatedAt,
-				LocalMod:   info.ModTime().Unix(),
-			}
-			result.Skipped++
-			continue
+		errCount := len(result.Errors)
+		e.reconcileRemoteFile(ctx, relPath, remote, dryRun, result)
+		if dryRun || len(result.Errors) > errCount {
+			continue // retry failed entries on resume
 		}
 
-		// Hash differs — server wins, save local as conflict if modified since last sync
-		if rec, tracked := e.State.Files[relPath]; tracked && rec.Hash != "" && rec.Hash != localHash {
-			// Local was modified — save as conflict
-			ext := filepath.Ext(localPath)
-			base := strings.TrimSuffix(localPath, ext)
-			conflictPath := fmt.Sprintf("%s.conflict%s", base, ext)
-			if ext == "" {
-				conflictPath = localPath + ".conflict"
-			}
-
-			if e.Verbose || dryRun {
-				fmt.Printf("  ⚠ Conflict (server wins): %s\n", relPath)
-			}
-			if !dryRun {
-				copyFile(localPath, conflictPath)
-			}
-			result.Conflicts++
-		} else if e.Verbose || dryRun {
-			fmt.Printf("  ⬇ Stale locally: %s\n", relPath)
-		}
-
-		// Download server version
-		if !dryRun {
-			tmpPath := localPath + ".izerop-tmp"
-			f, err := os.Create(tmpPath)
-			if err != nil {
-				result.Errors = append(result.Errors, fmt.Sprintf("create %s: %v", relPath, err))
-				continue
-			}
-			_, err = e.Client.DownloadFile(remote.ID, f)
-			f.Close()
-			if err != nil {
-				os.Remove(tmpPath)
-				result.Errors = append(result.Errors, fmt.Sprintf("download %s: %v", relPath, err))
-				continue
-			}
-			if err := os.Rename(tmpPath, localPath); err != nil {
-				os.Remove(tmpPath)
-				result.Errors = append(result.Errors, fmt.Sprintf("rename %s: %v", relPath, err))
-				continue
-			}
-
-			if newInfo, err := os.Stat(localPath); err == nil {
-				hash, _ := HashFile(localPath)
-				e.State.Files[relPath] = FileRecord{
-					RemoteID:   remote.ID,
-					Size:       newInfo.Size(),
-					Hash:       hash,
-					RemoteTime: remote.UpdatedAt,
-					LocalMod:   newInfo.ModTime().Unix(),
-				}
-			}
-		}
-		result.Downloaded++
+		e.State.ReconcileDone[relPath] = remote.UpdatedAt
+		e.checkpoint(result)
 	}
 
 	// Phase 2: Check local files not on remote → upload
-	filepath.Walk(e.SyncDir, func(path string, info os.FileInfo, walkErr error) error {
+	e.walk(func(path string, info os.FileInfo, walkErr error) error {
+		if e.stopped(ctx) != nil {
+			return filepath.SkipAll
+		}
 		if walkErr != nil {
 			return nil
 		}
@@ -718,7 +1341,7 @@ func (e *Engine) Reconcile(dryRun bool) (*SyncResult, error) {
 		}
 
 		relPath, _ := filepath.Rel(e.SyncDir, path)
-		if e.Ignore != nil && e.Ignore.IsIgnored(relPath, false) {
+		if e.ignoredPath(relPath, false) {
 			return nil
 		}
 
@@ -727,89 +1350,260 @@ func (e *Engine) Reconcile(dryRun bool) (*SyncResult, error) {
 		}
 
 		// Local file not on remote
-		if rec, tracked := e.State.Files[relPath]; tracked && rec.RemoteID != "" {
+		rec, tracked := e.Store.GetRecord(relPath)
+		if e.stopped(ctx) != nil {
+			return filepath.SkipAll
+		}
+		if tracked && rec.RemoteID != "" {
 			// Was tracked — deleted on server → delete locally
+			if !e.PropagateDeletes {
+				if e.Verbose || dryRun {
+					fmt.Fprintf(e.out(), "  ⏭ Deleted on server (kept): %s\n", relPath)
+				}
+				result.inc(&result.DeletesSkipped)
+				return nil
+			}
 			if e.Verbose || dryRun {
-				fmt.Printf("  🗑 Deleted on server: %s\n", relPath)
+				fmt.Fprintf(e.out(), "  🗑 Deleted on server: %s\n", relPath)
 			}
 			if !dryRun {
 				os.Remove(path)
-				delete(e.State.Files, relPath)
+				e.Store.DeleteRecord(relPath)
 				delete(e.State.Notes, relPath)
 			}
-			result.Deleted++
-		} else {
+			result.inc(&result.Deleted)
+			e.emit(Event{Path: relPath, Action: ActionDeleted, Size: info.Size(), Reason: "deleted on server", RemoteID: rec.RemoteID})
+			e.checkpoint(result)
+		} else if !e.tooLarge(result, relPath, info) {
 			// New local file — upload to server
 			if e.Verbose || dryRun {
-				fmt.Printf("  ⬆ New local file: %s\n", relPath)
+				fmt.Fprintf(e.out(), "  ⬆ New local file: %s\n", relPath)
 			}
 			if !dryRun {
 				// Find or create parent directory
 				remoteDirPath := filepath.ToSlash(filepath.Dir(e.localToRemote(relPath)))
-				_, remoteDirsByPath, _ := e.initRootDir()
-				dirID := ""
-				if dir, ok := remoteDirsByPath[remoteDirPath]; ok {
-					dirID = dir.ID
-				}
+				dirID, dirErr := e.ensureRemoteDir(ctx, remoteDirPath, rootID, remoteDirsByPath)
 
-				if dirID != "" {
-					if isTextFile(path, info) {
+				if dirErr == nil {
+					if isSymlink(info) {
+						e.uploadSymlink(ctx, result, relPath, path, info, nil, dirID, "not on server")
+					} else if e.isText(path, relPath, info) {
 						contents, err := os.ReadFile(path)
 						if err == nil {
-							created, err := e.Client.CreateTextFile(info.Name(), string(contents), dirID, "")
+							created, err := e.Client.CreateTextFile(ctx, info.Name(), string(contents), dirID, "")
 							if err != nil {
-								result.Errors = append(result.Errors, fmt.Sprintf("upload text %s: %v", relPath, err))
+								e.fail(ctx, result, relPath, err, fmt.Sprintf("upload text %s: %v", relPath, err))
 							} else {
-								h, _ := HashFile(path)
+								h, _ := e.hashFile(path)
 								rid := ""
+								var remoteMode uint32
 								if created != nil {
-									rid = created.ID
+									rid, remoteMode = created.ID, created.Mode
 								}
-								e.State.Files[relPath] = FileRecord{
+								e.Store.SetRecord(relPath, FileRecord{
 									RemoteID: rid,
 									Size:     info.Size(),
 									Hash:     h,
 									LocalMod: info.ModTime().Unix(),
-								}
-								result.Uploaded++
+									Mode:     e.syncMode(ctx, result, relPath, path, rid, info, remoteMode),
+								})
+								result.inc(&result.Uploaded)
+								e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "not on server", RemoteID: rid})
+								e.checkpoint(result)
 							}
 						}
 					} else {
-						uploaded, err := e.Client.UploadFile(path, dirID, info.Name())
+						uploaded, err := e.Client.UploadFile(ctx, path, dirID, info.Name())
 						if err != nil {
-							result.Errors = append(result.Errors, fmt.Sprintf("upload %s: %v", relPath, err))
+							e.fail(ctx, result, relPath, err, fmt.Sprintf("upload %s: %v", relPath, err))
 						} else {
-							h, _ := HashFile(path)
+							h, _ := e.hashFile(path)
 							rid := ""
+							var remoteMode uint32
 							if uploaded != nil {
-								rid = uploaded.ID
+								rid, remoteMode = uploaded.ID, uploaded.Mode
 							}
-							e.State.Files[relPath] = FileRecord{
+							e.Store.SetRecord(relPath, FileRecord{
 								RemoteID: rid,
 								Size:     info.Size(),
 								Hash:     h,
 								LocalMod: info.ModTime().Unix(),
-							}
-							result.Uploaded++
+								Mode:     e.syncMode(ctx, result, relPath, path, rid, info, remoteMode),
+							})
+							result.inc(&result.Uploaded)
+							e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "not on server", RemoteID: rid})
+							e.checkpoint(result)
 						}
 					}
 				} else {
-					result.Errors = append(result.Errors, fmt.Sprintf("no remote dir for %s", relPath))
+					e.fail(ctx, result, relPath, dirErr, fmt.Sprintf("no remote dir for %s: %v", relPath, dirErr))
 				}
 			} else {
-				result.Uploaded++
+				result.inc(&result.Uploaded)
+				e.emit(Event{Path: relPath, Action: ActionUploaded, Size: info.Size(), Reason: "not on server"})
+				e.checkpoint(result)
 			}
 		}
 
 		return nil
-	})
+	}, nil)
+	if err := e.stopped(ctx); err != nil {
+		return result, err
+	}
+
+	if !dryRun {
+		e.State.ReconcileDone = nil // finished — nothing to resume
+	}
 
 	return result, nil
 }
 
-// isTextFile determines if a file should be treated as a text file.
+// manifestByPath indexes manifest files by local relative path.
+func (e *Engine) manifestByPath(manifest *api.ManifestResponse) map[string]api.ManifestEntry {
+	remoteByPath := make(map[string]api.ManifestEntry)
+	for _, f := range manifest.Files {
+		relPath := e.remoteToLocal(f.Path)
+		if relPath == "" {
+			continue
+		}
+		// Notes (no extension on server) get .txt locally
+		if filepath.Ext(relPath) == "" {
+			relPath = relPath + ".txt"
+		}
+		remoteByPath[relPath] = f
+	}
+	return remoteByPath
+}
+
+// reconcileRemoteFile brings one remote manifest entry in line with the local copy.
+func (e *Engine) reconcileRemoteFile(ctx context.Context, relPath string, remote api.ManifestEntry, dryRun bool, result *SyncResult) {
+	if e.ignoredPath(relPath, false) {
+		return
+	}
+
+	localPath, err := e.localPathFor(relPath)
+	if err != nil {
+		e.fail(ctx, result, relPath, err, fmt.Sprintf("skipping %s: %v", remote.Path, err))
+		return
+	}
+	if e.skipsLink(localPath) {
+		return
+	}
+	localInfo, statErr := e.statLocal(localPath)
+
+	if os.IsNotExist(statErr) {
+		// Remote exists, local missing → download
+		if e.Verbose || dryRun {
+			fmt.Fprintf(e.out(), "  ⬇ Missing locally: %s\n", relPath)
+		}
+		if !dryRun {
+			os.MkdirAll(filepath.Dir(localPath), 0755)
+			tmpPath := localPath + ".izerop-tmp"
+			if err := e.downloadTemp(ctx, remote.ID, remote.Size, remote.ContentHash, tmpPath); err != nil {
+				e.fail(ctx, result, relPath, err, fmt.Sprintf("download %s: %v", relPath, err))
+				return
+			}
+			rec, err := e.installDownload(tmpPath, localPath, remote.Mode)
+			if err != nil {
+				e.fail(ctx, result, relPath, err, fmt.Sprintf("rename %s: %v", relPath, err))
+				return
+			}
+
+			// Track in state
+			rec.RemoteID = remote.ID
+			rec.RemoteTime = remote.UpdatedAt
+			e.Store.SetRecord(relPath, rec)
+			if filepath.Ext(remote.Path) == "" {
+				e.State.Notes[relPath] = remote.ID
+			}
+		}
+		result.inc(&result.Downloaded)
+		e.emit(Event{Path: relPath, Action: ActionDownloaded, Size: remote.Size, Reason: "missing locally", RemoteID: remote.ID})
+		return
+	}
+
+	if statErr != nil {
+		e.fail(ctx, result, relPath, statErr, fmt.Sprintf("stat %s: %v", relPath, statErr))
+		return
+	}
+
+	// Both exist — compare hashes
+	localHash, hashErr := e.hashLocal(relPath, localPath, localInfo)
+	if hashErr != nil {
+		e.fail(ctx, result, relPath, hashErr, fmt.Sprintf("hash %s: %v", relPath, hashErr))
+		return
+	}
+
+	if remote.ContentHash != "" && localHash == remote.ContentHash {
+		// Identical — update state and skip
+		info, _ := e.statLocal(localPath)
+		e.Store.SetRecord(relPath, FileRecord{
+			RemoteID:   remote.ID,
+			Size:       info.Size(),
+			Hash:       localHash,
+			RemoteTime: remote.UpdatedAt,
+			LocalMod:   info.ModTime().Unix(),
+		})
+		result.inc(&result.Skipped)
+		e.emit(Event{Path: relPath, Action: ActionSkipped, Size: info.Size(), Reason: "content matches server", RemoteID: remote.ID})
+		return
+	}
+
+	// Hash differs — server wins, save local as conflict if modified since last sync
+	rec, tracked := e.Store.GetRecord(relPath)
+	if e.stopped(ctx) != nil {
+		return
+	}
+	if tracked && rec.Hash != "" && rec.Hash != localHash {
+		// Local was modified — save as conflict
+		if e.Verbose || dryRun {
+			fmt.Fprintf(e.out(), "  ⚠ Conflict (server wins): %s%s\n", relPath, conflictSource(remote.ModifiedBy))
+		}
+		conflict := ConflictDetail{Path: relPath, LocalHash: localHash, RemoteHash: remote.ContentHash}
+		if !dryRun {
+			if conflictPath, err := e.saveConflict(localPath); err == nil {
+				conflict.ConflictFile = e.conflictLabel(conflictPath)
+				e.recordConflict(conflictPath, remote.ModifiedBy)
+			}
+		}
+		result.addConflict(conflict)
+		e.emit(Event{Path: relPath, Action: ActionConflict, Size: remote.Size, Reason: "changed on both sides (server wins)", RemoteID: remote.ID})
+	} else if e.Verbose || dryRun {
+		fmt.Fprintf(e.out(), "  ⬇ Stale locally: %s\n", relPath)
+	}
+
+	// Download server version
+	if !dryRun {
+		tmpPath := localPath + ".izerop-tmp"
+		if err := e.downloadTemp(ctx, remote.ID, remote.Size, remote.ContentHash, tmpPath); err != nil {
+			e.fail(ctx, result, relPath, err, fmt.Sprintf("download %s: %v", relPath, err))
+			return
+		}
+		rec, err := e.installDownload(tmpPath, localPath, remote.Mode)
+		if err != nil {
+			e.fail(ctx, result, relPath, err, fmt.Sprintf("rename %s: %v", relPath, err))
+			return
+		}
+		rec.RemoteID = remote.ID
+		rec.RemoteTime = remote.UpdatedAt
+		e.Store.SetRecord(relPath, rec)
+	}
+	result.inc(&result.Downloaded)
+	e.emit(Event{Path: relPath, Action: ActionDownloaded, Size: remote.Size, Reason: "differs from server", RemoteID: remote.ID})
+}
+
+// isText reports whether a new file should be created through the text API.
+func (e *Engine) isText(path, relPath string, info os.FileInfo) bool {
+	if e.NoTextAPI || (e.MaxTextSize > 0 && info.Size() > e.MaxTextSize) {
+		return false
+	}
+	return e.Types.IsText(path, relPath, info)
+}
+
+// IsTextFile determines if a file should be treated as a text file.
 // Files without extensions or with known text extensions are text files.
-func isTextFile(path string, info os.FileInfo) bool {
+// These are the built-in defaults; FileTypes layers config overrides on top.
+func IsTextFile(path string, info os.FileInfo) bool {
 	ext := strings.ToLower(filepath.Ext(info.Name()))
 
 	// No extension = text file
@@ -831,49 +1625,69 @@ func isTextFile(path string, info os.FileInfo) bool {
 		return true
 	}
 
-	// Small files without binary content are likely text
-	if info.Size() < 1024*100 { // < 100KB
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return false
-		}
-		// Check for null bytes (binary indicator)
-		for _, b := range data {
-			if b == 0 {
-				return false
-			}
-		}
-		return true
-	}
+	// Anything else is text if its head has no null bytes
+	return sniffText(path)
+}
+
+// sniffSize is how much of a file sniffText reads.
+const sniffSize = 8 * 1024
 
-	return false
+// sniffText reports whether the first sniffSize bytes of path contain no
+// null bytes, the usual sign of a binary file. Only the head is read, so
+// large text files are classified without loading them.
+func sniffText(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false
+	}
+	return bytes.IndexByte(buf[:n], 0) < 0
 }
 
-func (e *Engine) handleDirectoryChange(change api.Change, result *SyncResult) {
+func (e *Engine) handleDirectoryChange(ctx context.Context, change api.Change, result *SyncResult) {
 	localRel := e.remoteToLocal(change.Path)
 	if localRel == "" {
 		return // root dir itself, skip
 	}
-	if e.Ignore.IsIgnored(localRel, true) {
+	if e.ignoredPath(localRel, true) {
+		return
+	}
+	localPath, err := e.localPathFor(localRel)
+	if err != nil {
+		e.fail(ctx, result, localRel, err, fmt.Sprintf("skipping directory %s: %v", change.Path, err))
 		return
 	}
-	localPath := filepath.Join(e.SyncDir, localRel)
 
 	switch change.Action {
 	case "created", "modified":
+		if e.DryRun {
+			return
+		}
 		if err := os.MkdirAll(localPath, 0755); err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("mkdir %s: %v", localPath, err))
+			result.addError(fmt.Sprintf("mkdir %s: %v", localPath, err))
 		}
 	case "deleted":
+		if !e.PropagateDeletes {
+			result.inc(&result.DeletesSkipped)
+			return
+		}
 		entries, _ := os.ReadDir(localPath)
 		if len(entries) == 0 {
-			os.Remove(localPath)
-			result.Deleted++
+			if !e.DryRun {
+				os.Remove(localPath)
+			}
+			result.inc(&result.Deleted)
+			e.emit(Event{Path: localRel, Action: ActionDeleted, Reason: "directory deleted on server", RemoteID: change.ID})
 		}
 	}
 }
 
-func (e *Engine) handleFileChange(change api.Change, result *SyncResult) {
+func (e *Engine) handleFileChange(ctx context.Context, change api.Change, result *SyncResult) {
 	localRel := e.remoteToLocal(change.Path)
 	if localRel == "" {
 		return
@@ -886,105 +1700,137 @@ func (e *Engine) handleFileChange(change api.Change, result *SyncResult) {
 	}
 
 	// Check ignore rules
-	if e.Ignore.IsIgnored(localRel, false) {
-		result.Skipped++
+	if e.ignoredPath(localRel, false) {
+		result.inc(&result.Skipped)
+		e.emit(Event{Path: localRel, Action: ActionSkipped, Size: change.Size, Reason: "ignored", RemoteID: change.ID})
+		return
+	}
+
+	localPath, err := e.localPathFor(localRel)
+	if err != nil {
+		e.fail(ctx, result, localRel, err, fmt.Sprintf("skipping %s: %v", change.Path, err))
 		return
 	}
 
-	localPath := filepath.Join(e.SyncDir, localRel)
+	if e.skipsLink(localPath) {
+		result.inc(&result.Skipped)
+		e.emit(Event{Path: localRel, Action: ActionSkipped, Size: change.Size, Reason: "symlink", RemoteID: change.ID})
+		return
+	}
 
 	switch change.Action {
 	case "created", "modified":
 		// Ensure parent directory exists
-		os.MkdirAll(filepath.Dir(localPath), 0755)
+		if !e.DryRun {
+			os.MkdirAll(filepath.Dir(localPath), 0755)
+		}
+
+		// The file (or a directory above it) was moved on the server
+		if e.moveTracked(ctx, change, localRel, result) {
+			return
+		}
 
 		// Skip files actively being edited (modified in last 30 seconds)
-		if info, statErr := os.Stat(localPath); statErr == nil {
+		if info, statErr := e.statLocal(localPath); statErr == nil {
 			secsSinceMod := time.Now().Unix() - info.ModTime().Unix()
 			if secsSinceMod < 30 {
 				if e.Verbose {
-					fmt.Printf("  ⏳ Skipping (actively edited): %s\n", localRel)
+					fmt.Fprintf(e.out(), "  ⏳ Skipping (actively edited): %s\n", localRel)
 				}
-				result.Skipped++
+				result.inc(&result.Skipped)
+				e.emit(Event{Path: localRel, Action: ActionSkipped, Size: change.Size, Reason: "edited locally in the last 30s", RemoteID: change.ID})
 				return
 			}
 		}
 
 		// If server provides content_hash, skip download when local matches
 		if change.ContentHash != "" {
-			if _, statErr := os.Stat(localPath); statErr == nil {
-				localHash, hashErr := HashFile(localPath)
+			if info, statErr := e.statLocal(localPath); statErr == nil {
+				localHash, hashErr := e.hashLocal(localRel, localPath, info)
 				if hashErr == nil && localHash == change.ContentHash {
 					// Content identical — update state and skip
-					if newInfo, infoErr := os.Stat(localPath); infoErr == nil {
-						e.State.Files[localRel] = FileRecord{
+					if newInfo, infoErr := e.statLocal(localPath); infoErr == nil {
+						e.Store.SetRecord(localRel, FileRecord{
 							RemoteID:   change.ID,
 							Size:       newInfo.Size(),
 							Hash:       localHash,
 							RemoteTime: change.UpdatedAt,
 							LocalMod:   newInfo.ModTime().Unix(),
-						}
+						})
 					}
-					result.Skipped++
+					result.inc(&result.Skipped)
+					e.emit(Event{Path: localRel, Action: ActionSkipped, Size: change.Size, Reason: "content matches server", RemoteID: change.ID})
 					return
 				}
 			}
 		}
 
+		if !isNote && e.DeferDownload != nil && e.DeferDownload(localRel, change.Size) {
+			if e.Verbose {
+				fmt.Fprintf(e.out(), "  ⏸ Deferred: %s\n", localRel)
+			}
+			result.inc(&result.Deferred)
+			return
+		}
+
 		// Conflict detection: if local file exists and has changed since last sync
-		if info, statErr := os.Stat(localPath); statErr == nil {
-			if rec, tracked := e.State.Files[localRel]; tracked {
+		if info, statErr := e.statLocal(localPath); statErr == nil {
+			rec, tracked := e.Store.GetRecord(localRel)
+			if e.stopped(ctx) != nil {
+				return
+			}
+			if tracked {
 				// File was previously synced — check if local modified it
 				localModTime := info.ModTime().Unix()
 				if localModTime != rec.LocalMod || info.Size() != rec.Size {
 					// Local changed — but check if remote content actually differs
 					// If content_hash matches local hash, it's not a real conflict
-					localHash, hashErr := HashFile(localPath)
+					localHash, hashErr := e.hashPath(localPath)
 					if hashErr == nil && change.ContentHash != "" && localHash == change.ContentHash {
 						// Content is identical — no real conflict, just timestamp drift
 						if e.Verbose {
-							fmt.Printf("  ✓ Hash match (no conflict): %s\n", localRel)
+							fmt.Fprintf(e.out(), "  ✓ Hash match (no conflict): %s\n", localRel)
 						}
 					} else {
-						// Genuine conflict — local and remote have different content
-						ext := filepath.Ext(localPath)
-						base := strings.TrimSuffix(localPath, ext)
-						conflictPath := fmt.Sprintf("%s.conflict%s", base, ext)
-						if ext == "" {
-							conflictPath = localPath + ".conflict"
-						}
-
+						// Genuine conflict — local and remote have different content.
 						// Copy current local to conflict file
-						if copyErr := copyFile(localPath, conflictPath); copyErr != nil {
-							result.Errors = append(result.Errors, fmt.Sprintf("conflict backup %s: %v", localRel, copyErr))
-						} else if e.Verbose {
-							fmt.Printf("  ⚠ Conflict: %s (local saved as %s)\n", localRel, filepath.Base(conflictPath))
+						conflict := ConflictDetail{Path: localRel, LocalHash: localHash, RemoteHash: change.ContentHash}
+						if e.DryRun {
+							fmt.Fprintf(e.out(), "  ⚠ Conflict: %s (local would be saved as %s%s)\n", localRel, e.conflictLabel(e.conflictPath(localPath)), conflictSource(change.ModifiedBy))
+						} else if conflictPath, copyErr := e.saveConflict(localPath); copyErr != nil {
+							e.fail(ctx, result, localRel, copyErr, fmt.Sprintf("conflict backup %s: %v", localRel, copyErr))
+						} else {
+							conflict.ConflictFile = e.conflictLabel(conflictPath)
+							e.recordConflict(conflictPath, change.ModifiedBy)
+							if e.Verbose {
+								fmt.Fprintf(e.out(), "  ⚠ Conflict: %s (local saved as %s%s)\n", localRel, e.conflictLabel(conflictPath), conflictSource(change.ModifiedBy))
+							}
 						}
-						result.Conflicts++
+						result.addConflict(conflict)
+						e.emit(Event{Path: localRel, Action: ActionConflict, Size: change.Size, Reason: "changed on both sides (server wins)", RemoteID: change.ID})
 					}
 				}
 			}
 		}
 
-		// Atomic write: download to temp file, then rename to avoid partial reads
-		tmpPath := localPath + ".izerop-tmp"
-		f, err := os.Create(tmpPath)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("create %s: %v", localPath, err))
+		if e.DryRun {
+			fmt.Fprintf(e.out(), "  ⬇ %s\n", localRel)
+			result.inc(&result.Downloaded)
+			e.emit(Event{Path: localRel, Action: ActionDownloaded, Size: change.Size, Reason: change.Action + " on server", RemoteID: change.ID})
+			e.advanceProgress(change.Size)
 			return
 		}
 
-		_, err = e.Client.DownloadFile(change.ID, f)
-		f.Close()
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("download %s: %v", change.Path, err))
-			os.Remove(tmpPath)
+		// Atomic write: download to temp file, then rename to avoid partial reads
+		tmpPath := localPath + ".izerop-tmp"
+		if err := e.downloadTemp(ctx, change.ID, change.Size, change.ContentHash, tmpPath); err != nil {
+			e.fail(ctx, result, localRel, err, fmt.Sprintf("download %s: %v", change.Path, err))
 			return
 		}
 
-		if err := os.Rename(tmpPath, localPath); err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("rename %s: %v", localPath, err))
-			os.Remove(tmpPath)
+		rec, err := e.installDownload(tmpPath, localPath, change.Mode)
+		if err != nil {
+			e.fail(ctx, result, localRel, err, fmt.Sprintf("rename %s: %v", localPath, err))
 			return
 		}
 
@@ -994,38 +1840,195 @@ func (e *Engine) handleFileChange(change api.Change, result *SyncResult) {
 		}
 
 		// Update file record with content hash
-		if newInfo, statErr := os.Stat(localPath); statErr == nil {
-			hash, _ := HashFile(localPath)
-			e.State.Files[localRel] = FileRecord{
-				RemoteID:   change.ID,
-				Size:       newInfo.Size(),
-				Hash:       hash,
-				RemoteTime: change.UpdatedAt,
-				LocalMod:   newInfo.ModTime().Unix(),
-			}
-		}
+		rec.RemoteID = change.ID
+		rec.RemoteTime = change.UpdatedAt
+		e.Store.SetRecord(localRel, rec)
 
 		if e.Verbose {
 			label := "⬇"
 			if isNote {
 				label = "📝"
 			}
-			fmt.Printf("  %s %s\n", label, localRel)
+			fmt.Fprintf(e.out(), "  %s %s\n", label, localRel)
 		}
-		result.Downloaded++
+		result.inc(&result.Downloaded)
+		e.emit(Event{Path: localRel, Action: ActionDownloaded, Size: change.Size, Reason: change.Action + " on server", RemoteID: change.ID})
+		e.checkpoint(result)
+		e.advanceProgress(change.Size)
 
 	case "deleted":
-		if _, err := os.Stat(localPath); err == nil {
-			os.Remove(localPath)
-			delete(e.State.Notes, localRel)
-			if e.Verbose {
-				fmt.Printf("  🗑 %s\n", localRel)
+		if _, err := e.statLocal(localPath); err == nil {
+			if !e.PropagateDeletes {
+				result.inc(&result.DeletesSkipped)
+				return
 			}
-			result.Deleted++
+			if !e.DryRun {
+				os.Remove(localPath)
+				delete(e.State.Notes, localRel)
+			}
+			if e.Verbose || e.DryRun {
+				fmt.Fprintf(e.out(), "  🗑 %s\n", localRel)
+			}
+			result.inc(&result.Deleted)
+			e.emit(Event{Path: localRel, Action: ActionDeleted, Reason: "deleted on server", RemoteID: change.ID})
+			e.checkpoint(result)
 		}
 	}
 }
 
+// moveTracked handles a pulled change for a file tracked under a different
+// local path, which happens when the file or a directory above it is moved
+// or renamed on the server. If the old local copy is unchanged since the
+// last sync and the content is the same, it is moved to the new path and
+// moveTracked reports true. If only the content differs, the old copy is
+// removed and false is returned so the new version is downloaded; a locally
+// edited copy is left alone.
+func (e *Engine) moveTracked(ctx context.Context, change api.Change, localRel string, result *SyncResult) bool {
+	oldRel, ok := e.remoteIndex[change.ID]
+	if !ok || oldRel == localRel {
+		return false
+	}
+	rec, tracked := e.Store.GetRecord(oldRel)
+	if !tracked || rec.RemoteID != change.ID {
+		return false
+	}
+	oldPath := filepath.Join(e.SyncDir, oldRel)
+	newPath := filepath.Join(e.SyncDir, localRel)
+	info, err := os.Stat(oldPath)
+	if err != nil || info.Size() != rec.Size || info.ModTime().Unix() != rec.LocalMod {
+		return false
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		return false
+	}
+
+	sameContent := change.ContentHash != "" && change.ContentHash == rec.Hash
+	if e.Verbose || e.DryRun {
+		fmt.Fprintf(e.out(), "  🔀 Moved on server: %s → %s\n", oldRel, localRel)
+	}
+	if !e.DryRun {
+		if sameContent {
+			if err := os.Rename(oldPath, newPath); err != nil {
+				e.fail(ctx, result, localRel, err, fmt.Sprintf("move %s → %s: %v", oldRel, localRel, err))
+				return true
+			}
+		} else {
+			os.Remove(oldPath)
+		}
+		e.pruneEmptyDirs(filepath.Dir(oldPath))
+	}
+
+	e.Store.DeleteRecord(oldRel)
+	e.remoteIndex[change.ID] = localRel
+	noteID, isNote := e.State.Notes[oldRel]
+	delete(e.State.Notes, oldRel)
+	if !sameContent {
+		return false
+	}
+
+	if isNote {
+		e.State.Notes[localRel] = noteID
+	}
+	rec.RemoteTime = change.UpdatedAt
+	if newInfo, err := os.Stat(newPath); err == nil {
+		rec.LocalMod = newInfo.ModTime().Unix()
+	}
+	e.Store.SetRecord(localRel, rec)
+	result.inc(&result.Moved)
+	e.emit(Event{Path: localRel, Action: ActionMoved, Size: rec.Size, Reason: "moved on server from " + oldRel, RemoteID: change.ID})
+	e.checkpoint(result)
+	return true
+}
+
+// pruneEmptyDirs removes dir and its parents, up to but not including the
+// sync dir, while they are empty, so a directory renamed on the server
+// doesn't leave its old local copy behind to be pushed again.
+func (e *Engine) pruneEmptyDirs(dir string) {
+	for dir != e.SyncDir && strings.HasPrefix(dir, e.SyncDir+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// ErrHashMismatch means a downloaded file's SHA-256 differs from the
+// server's content_hash, e.g. because the transfer was cut short.
+var ErrHashMismatch = errors.New("downloaded content does not match the server's hash")
+
+// VerifyHash checks that the file at path has the SHA-256 want. An empty
+// want (the server sent no hash) always passes.
+func VerifyHash(path, want string) error {
+	if want == "" {
+		return nil
+	}
+	got, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%w (got %.12s…, want %.12s…)", ErrHashMismatch, got, want)
+	}
+	return nil
+}
+
+// downloadTemp downloads a remote file to a fresh temp path. Large files use
+// a resumable download so a dropped connection doesn't restart from zero.
+// If wantHash is set the result must match it. The temp file is removed on
+// failure.
+func (e *Engine) downloadTemp(ctx context.Context, fileID string, size int64, wantHash, tmpPath string) error {
+	os.Remove(tmpPath) // never resume a stale temp from an earlier run
+
+	if size >= api.ResumableThreshold {
+		if _, err := e.Client.DownloadFileResumable(ctx, fileID, tmpPath, size); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	} else {
+		f, err := os.Create(tmpPath)
+		if err != nil {
+			return err
+		}
+		_, err = e.Client.DownloadFile(ctx, fileID, f)
+		f.Close()
+		if err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+
+	// A short or corrupted transfer must not replace the local copy
+	if err := VerifyHash(tmpPath, wantHash); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// recordConflict remembers which client last modified the remote side of a
+// conflict so `izerop conflicts` can show where the other version came from.
+func (e *Engine) recordConflict(conflictPath, modifiedBy string) {
+	if modifiedBy == "" {
+		return
+	}
+	relPath, err := filepath.Rel(e.SyncDir, conflictPath)
+	if err != nil {
+		return
+	}
+	if e.State.Conflicts == nil {
+		e.State.Conflicts = make(map[string]string)
+	}
+	e.State.Conflicts[relPath] = modifiedBy
+}
+
+// conflictSource formats the remote modifier for conflict messages.
+func conflictSource(modifiedBy string) string {
+	if modifiedBy == "" {
+		return ""
+	}
+	return ", remote from " + modifiedBy
+}
+
 // copyFile copies src to dst.
 func copyFile(src, dst string) error {
 	s, err := os.Open(src)
@@ -1044,6 +2047,18 @@ func copyFile(src, dst string) error {
 	return err
 }
 
+// hashLocal returns the SHA256 of the synced file at path. If its size and
+// mtime still match the record from the last sync, the file is taken as
+// unchanged and the recorded hash is returned without reading it; only new
+// or touched files are hashed in full.
+func (e *Engine) hashLocal(relPath, path string, info os.FileInfo) (string, error) {
+	if rec, tracked := e.Store.GetRecord(relPath); tracked && rec.Hash != "" &&
+		rec.Size == info.Size() && rec.LocalMod == info.ModTime().Unix() {
+		return rec.Hash, nil
+	}
+	return e.hashPath(path)
+}
+
 // HashFile computes SHA256 of a local file.
 func HashFile(path string) (string, error) {
 	f, err := os.Open(path)
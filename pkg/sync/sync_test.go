@@ -0,0 +1,389 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+)
+
+// TestSyncResultConcurrent updates one SyncResult from many goroutines,
+// as parallel transfers do; run with -race to catch unguarded fields.
+func TestSyncResultConcurrent(t *testing.T) {
+	const workers, each = 8, 100
+	r := &SyncResult{}
+	ForEach(workers, workers*each, func(i int) {
+		r.inc(&r.Uploaded)
+		r.inc(&r.Skipped)
+		r.addError(fmt.Sprintf("error %d", i))
+		r.addConflict(ConflictDetail{Path: fmt.Sprintf("file%d", i)})
+	})
+
+	n := workers * each
+	if r.Uploaded != n || r.Skipped != n || r.Conflicts != n {
+		t.Errorf("counts: uploaded %d, skipped %d, conflicts %d, want %d each", r.Uploaded, r.Skipped, r.Conflicts, n)
+	}
+	if len(r.Errors) != n || len(r.ConflictDetails) != n {
+		t.Errorf("%d errors and %d conflict details, want %d each", len(r.Errors), len(r.ConflictDetails), n)
+	}
+}
+
+func TestVerifyHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	mustWrite(t, path, "hello")
+	sum := sha256.Sum256([]byte("hello"))
+	good := hex.EncodeToString(sum[:])
+
+	if err := VerifyHash(path, good); err != nil {
+		t.Errorf("matching hash: %v", err)
+	}
+	if err := VerifyHash(path, ""); err != nil {
+		t.Errorf("no hash from the server: %v", err)
+	}
+	if err := VerifyHash(path, strings.Repeat("0", 64)); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("wrong hash: got %v, want ErrHashMismatch", err)
+	}
+}
+
+// TestPullTruncatedDownload serves a download cut short of the content the
+// server's hash describes: the local file must be left as it was.
+func TestPullTruncatedDownload(t *testing.T) {
+	for _, size := range []int64{32, api.ResumableThreshold} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			content := strings.Repeat("x", int(size))
+			sum := sha256.Sum256([]byte(content))
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v1/sync/changes":
+					json.NewEncoder(w).Encode(api.ChangesResponse{Cursor: "c2", Changes: []api.Change{{
+						Type: "file", Action: "modified", ID: "f1", Path: "/root/notes.md",
+						Size: size, ContentHash: hex.EncodeToString(sum[:]), UpdatedAt: "t2",
+					}}})
+				case "/api/v1/files/f1/download":
+					io.WriteString(w, content[:size/2])
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			dir := t.TempDir()
+			local := filepath.Join(dir, "notes.md")
+			mustWrite(t, local, "old")
+			old := time.Now().Add(-time.Hour)
+			os.Chtimes(local, old, old)
+
+			e := NewEngine(api.NewClient(srv.URL, "token"), dir, &State{})
+			e.Out = io.Discard
+			e.Store.SetRecord("notes.md", FileRecord{RemoteID: "f1", Size: 3, RemoteTime: "t1", LocalMod: old.Unix()})
+
+			result, _, err := e.PullSync(context.Background(), "c1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Errors) == 0 || result.Downloaded != 0 {
+				t.Errorf("truncated download counted as downloaded (%d) with errors %v", result.Downloaded, result.Errors)
+			}
+			if got, _ := os.ReadFile(local); string(got) != "old" {
+				t.Errorf("local file replaced by %d bytes", len(got))
+			}
+			if _, err := os.Stat(local + ".izerop-tmp"); !os.IsNotExist(err) {
+				t.Errorf("temp file left behind: %v", err)
+			}
+			if rec, _ := e.Store.GetRecord("notes.md"); rec.RemoteTime != "t1" {
+				t.Errorf("record updated to %+v", rec)
+			}
+		})
+	}
+}
+
+// TestUnchangedFilesNotRehashed checks that PushSync and Reconcile take the
+// recorded hash for a file whose size and mtime match its record, and only
+// read files that were touched since the last sync.
+func TestUnchangedFilesNotRehashed(t *testing.T) {
+	contents := map[string]string{"same.txt": "unchanged", "touched.txt": "touched"}
+	hashes := make(map[string]string)
+	var files []api.FileEntry
+	var manifest []api.ManifestEntry
+	for name, content := range contents {
+		sum := sha256.Sum256([]byte(content))
+		hashes[name] = hex.EncodeToString(sum[:])
+		files = append(files, api.FileEntry{ID: name, Name: name, Path: "/root/" + name, DirectoryID: "d1",
+			Size: int64(len(content)), ContentHash: hashes[name], UpdatedAt: "t1"})
+		manifest = append(manifest, api.ManifestEntry{ID: name, Name: name, Path: "/root/" + name, DirectoryID: "d1",
+			Size: int64(len(content)), ContentHash: hashes[name], UpdatedAt: "t1"})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/directories":
+			json.NewEncoder(w).Encode(map[string]any{"directories": []api.Directory{{ID: "d1", Name: "root", Path: "/root"}}})
+		case "/api/v1/files":
+			json.NewEncoder(w).Encode(map[string]any{"files": files})
+		case "/api/v1/sync/manifest":
+			json.NewEncoder(w).Encode(api.ManifestResponse{Files: manifest})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for _, run := range []struct {
+		name string
+		sync func(*Engine) (*SyncResult, error)
+	}{
+		{"push", func(e *Engine) (*SyncResult, error) { return e.PushSync(context.Background()) }},
+		{"reconcile", func(e *Engine) (*SyncResult, error) { return e.Reconcile(context.Background(), false) }},
+	} {
+		t.Run(run.name, func(t *testing.T) {
+			dir := t.TempDir()
+			old := time.Now().Add(-time.Hour)
+			for name, content := range contents {
+				mustWrite(t, filepath.Join(dir, name), content)
+				os.Chtimes(filepath.Join(dir, name), old, old)
+			}
+
+			e := NewEngine(api.NewClient(srv.URL, "token"), dir, &State{})
+			e.Out = io.Discard
+			var hashed []string
+			e.hashFile = func(path string) (string, error) {
+				hashed = append(hashed, filepath.Base(path))
+				return HashFile(path)
+			}
+			e.Store.SetRecord("same.txt", FileRecord{RemoteID: "same.txt", Size: int64(len(contents["same.txt"])),
+				Hash: hashes["same.txt"], RemoteTime: "t1", LocalMod: old.Unix()})
+			e.Store.SetRecord("touched.txt", FileRecord{RemoteID: "touched.txt", Size: int64(len(contents["touched.txt"])),
+				Hash: hashes["touched.txt"], RemoteTime: "t1", LocalMod: old.Unix() - 60})
+
+			result, err := run.sync(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Errors) > 0 || result.Uploaded+result.Downloaded > 0 {
+				t.Errorf("uploaded %d, downloaded %d, errors %v", result.Uploaded, result.Downloaded, result.Errors)
+			}
+			if len(hashed) != 1 || hashed[0] != "touched.txt" {
+				t.Errorf("hashed %v, want only touched.txt", hashed)
+			}
+		})
+	}
+}
+
+// TestReconcileListsDirectoriesOnce uploads new local files spread over
+// existing and missing directories: Reconcile must list the server's
+// directories once and create each missing one once, however many files
+// there are.
+func TestReconcileListsDirectoriesOnce(t *testing.T) {
+	for _, n := range []int{6, 60} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			var mu gosync.Mutex
+			var lists, creates, uploads int
+			dirs := map[string]string{"d0": "/root", "d1": "/root/a"}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case r.URL.Path == "/api/v1/sync/manifest":
+					json.NewEncoder(w).Encode(api.ManifestResponse{})
+				case r.URL.Path == "/api/v1/directories" && r.Method == http.MethodGet:
+					lists++
+					var list []api.Directory
+					for id, path := range dirs {
+						list = append(list, api.Directory{ID: id, Name: filepath.Base(path), Path: path})
+					}
+					json.NewEncoder(w).Encode(map[string]any{"directories": list})
+				case r.URL.Path == "/api/v1/directories":
+					creates++
+					var req map[string]string
+					json.NewDecoder(r.Body).Decode(&req)
+					id := fmt.Sprintf("d%d", len(dirs))
+					dirs[id] = dirs[req["user_directory_id"]] + "/" + req["name"]
+					json.NewEncoder(w).Encode(map[string]any{"directory": api.Directory{ID: id, Name: req["name"], Path: dirs[id]}})
+				case r.URL.Path == "/api/v1/files" && r.Method == http.MethodPost:
+					uploads++
+					json.NewEncoder(w).Encode(map[string]any{"file": api.FileEntry{ID: fmt.Sprintf("f%d", uploads)}})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			dir := t.TempDir()
+			subdirs := []string{"", "a", "b", "c/d"}
+			for i := 0; i < n; i++ {
+				mustWrite(t, filepath.Join(dir, subdirs[i%len(subdirs)], fmt.Sprintf("f%d.bin", i)), "\x00bin")
+			}
+
+			e := NewEngine(api.NewClient(srv.URL, "token"), dir, &State{})
+			e.Out = io.Discard
+			result, err := e.Reconcile(context.Background(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Uploaded != n || len(result.Errors) > 0 {
+				t.Fatalf("uploaded %d of %d, errors %v", result.Uploaded, n, result.Errors)
+			}
+			if lists != 1 {
+				t.Errorf("listed directories %d times, want 1", lists)
+			}
+			if creates != 3 { // b, c, c/d
+				t.Errorf("created %d directories, want 3", creates)
+			}
+		})
+	}
+}
+
+// TestPullAppliesPages cancels a pull partway through its second page of
+// changes: the first page must have been applied before the second was
+// fetched, and the cursor must have moved past it and no further.
+func TestPullAppliesPages(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var appliedFirst bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/sync/changes" && r.URL.Query().Get("since") == "c0":
+			json.NewEncoder(w).Encode(api.ChangesResponse{Cursor: "c1", HasMore: true, Changes: []api.Change{{
+				Type: "directory", Action: "created", ID: "d1", Path: "/root/docs",
+			}}})
+		case r.URL.Path == "/api/v1/sync/changes" && r.URL.Query().Get("since") == "c1":
+			_, err := os.Stat(filepath.Join(dir, "docs"))
+			appliedFirst = err == nil
+			json.NewEncoder(w).Encode(api.ChangesResponse{Cursor: "c2", Changes: []api.Change{{
+				Type: "file", Action: "created", ID: "f1", Path: "/root/docs/a.txt", Size: 5, UpdatedAt: "t1",
+			}}})
+		case r.URL.Path == "/api/v1/files/f1/download":
+			cancel()
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	e := NewEngine(api.NewClient(srv.URL, "token"), dir, &State{})
+	e.Out = io.Discard
+	e.Store.SetCursor("c0")
+
+	_, cursor, err := e.PullSync(ctx, e.Store.Cursor())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if !appliedFirst {
+		t.Error("second page fetched before the first was applied")
+	}
+	if cursor != "c1" || e.Store.Cursor() != "c1" {
+		t.Errorf("cursor %q, stored %q; want c1", cursor, e.Store.Cursor())
+	}
+}
+
+// brokenStore fails every record read, like a database that stays busy.
+type brokenStore struct {
+	*JSONStore
+	err error
+}
+
+func (s *brokenStore) GetRecord(string) (FileRecord, bool) {
+	s.err = errors.New("database is locked")
+	return FileRecord{}, false
+}
+
+func (s *brokenStore) Err() error { return s.err }
+
+// TestReconcileStopsOnStoreError checks that a record the store failed to
+// read isn't taken for an untracked file: Reconcile must stop rather than
+// upload a file that was deleted on the server.
+func TestReconcileStopsOnStoreError(t *testing.T) {
+	uploads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/sync/manifest":
+			json.NewEncoder(w).Encode(api.ManifestResponse{})
+		case r.URL.Path == "/api/v1/directories":
+			json.NewEncoder(w).Encode(map[string]any{"directories": []api.Directory{{ID: "d1", Name: "root", Path: "/root"}}})
+		case r.URL.Path == "/api/v1/files" && r.Method == http.MethodPost:
+			uploads++
+			json.NewEncoder(w).Encode(map[string]any{"file": api.FileEntry{ID: "f2"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	mustWrite(t, filepath.Join(dir, "gone.bin"), "\x00bin")
+	e := NewEngine(api.NewClient(srv.URL, "token"), dir, &State{})
+	e.Out = io.Discard
+	e.Store = &brokenStore{JSONStore: NewJSONStore("", e.State)}
+
+	if _, err := e.Reconcile(context.Background(), false); err == nil {
+		t.Error("Reconcile succeeded with a broken store")
+	}
+	if uploads != 0 {
+		t.Errorf("uploaded %d files", uploads)
+	}
+}
+
+// TestPullDeferDownload holds back a large download: the rest of the pull
+// goes ahead, but the cursor stays before the deferred file's page so the
+// next pull fetches it again.
+func TestPullDeferDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/sync/changes" && r.URL.Query().Get("since") == "c0":
+			json.NewEncoder(w).Encode(api.ChangesResponse{Cursor: "c1", HasMore: true, Changes: []api.Change{{
+				Type: "file", Action: "created", ID: "big", Path: "/root/big.bin", Size: 3, UpdatedAt: "t1",
+			}}})
+		case r.URL.Path == "/api/v1/sync/changes" && r.URL.Query().Get("since") == "c1":
+			json.NewEncoder(w).Encode(api.ChangesResponse{Cursor: "c2", Changes: []api.Change{{
+				Type: "file", Action: "created", ID: "small", Path: "/root/small.bin", Size: 3, UpdatedAt: "t1",
+			}}})
+		case strings.HasPrefix(r.URL.Path, "/api/v1/files/"):
+			io.WriteString(w, "abc")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	e := NewEngine(api.NewClient(srv.URL, "token"), dir, &State{})
+	e.Out = io.Discard
+	e.DeferDownload = func(relPath string, size int64) bool { return relPath == "big.bin" }
+
+	result, cursor, err := e.PullSync(context.Background(), "c0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Deferred != 1 || result.Downloaded != 1 {
+		t.Errorf("deferred %d, downloaded %d; want 1, 1", result.Deferred, result.Downloaded)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "big.bin")); !os.IsNotExist(err) {
+		t.Errorf("deferred file downloaded: %v", err)
+	}
+	if cursor != "c0" || e.Store.Cursor() != "" {
+		t.Errorf("cursor %q, stored %q; want c0 and unchanged", cursor, e.Store.Cursor())
+	}
+
+	e.DeferDownload = nil
+	result, cursor, err = e.PullSync(context.Background(), cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "big.bin")); err != nil || result.Deferred != 0 || cursor != "c2" {
+		t.Errorf("second pull: big.bin %v, deferred %d, cursor %q", err, result.Deferred, cursor)
+	}
+}
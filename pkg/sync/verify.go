@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+)
+
+// VerifyStatus is the outcome of verifying one tracked file.
+type VerifyStatus string
+
+const (
+	VerifyOK            VerifyStatus = "ok"
+	VerifyMismatch      VerifyStatus = "mismatch"       // local content differs from the server's
+	VerifyMissingRemote VerifyStatus = "missing_remote" // tracked, but gone from the server
+	VerifyMissingLocal  VerifyStatus = "missing_local"  // tracked, but gone from the sync dir
+	VerifyUnverified    VerifyStatus = "unverified"     // the server has no hash to compare
+	VerifyError         VerifyStatus = "error"          // the local file or download couldn't be read
+)
+
+// VerifyEntry is a tracked file that didn't verify cleanly.
+type VerifyEntry struct {
+	Path       string       `json:"path"`
+	Status     VerifyStatus `json:"status"`
+	LocalHash  string       `json:"local_hash,omitempty"`
+	RemoteHash string       `json:"remote_hash,omitempty"`
+	RemoteID   string       `json:"remote_id,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// VerifyReport is the result of Verify.
+type VerifyReport struct {
+	Checked int `json:"checked"`
+	OK      int `json:"ok"`
+	// Discrepancies counts Files entries other than VerifyUnverified.
+	Discrepancies int `json:"discrepancies"`
+	// Files lists every file that isn't VerifyOK, sorted by path.
+	Files []VerifyEntry `json:"files"`
+}
+
+// Clean reports whether every tracked file that could be checked matched.
+func (r *VerifyReport) Clean() bool {
+	return r.Discrepancies == 0
+}
+
+// Verify hashes every tracked file (records and notes) in the sync dir and
+// compares it with the content hash in the server manifest. Unlike
+// CheckDrift it reads every local file rather than trusting the recorded
+// hash. With download, files the manifest has no hash for are downloaded
+// and hashed in memory. It changes nothing on either side.
+func (e *Engine) Verify(ctx context.Context, download bool) (*VerifyReport, error) {
+	manifest, err := e.Client.GetManifest(ctx, e.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch manifest: %w", err)
+	}
+	remoteByPath := e.manifestByPath(manifest)
+
+	report := &VerifyReport{Files: []VerifyEntry{}}
+	check := func(relPath string) {
+		if ctx.Err() != nil || e.ignoredPath(relPath, false) {
+			return
+		}
+		report.Checked++
+		entry := e.verifyFile(ctx, relPath, remoteByPath, download)
+		if entry.Status == VerifyOK {
+			report.OK++
+			return
+		}
+		if entry.Status != VerifyUnverified {
+			report.Discrepancies++
+		}
+		report.Files = append(report.Files, entry)
+	}
+	e.Store.EachRecord(func(relPath string, _ FileRecord) {
+		check(relPath)
+	})
+	for relPath := range e.State.Notes {
+		if _, tracked := e.Store.GetRecord(relPath); !tracked {
+			check(relPath)
+		}
+	}
+	if err := e.Store.Err(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report, nil
+}
+
+// verifyFile checks one tracked file against its manifest entry.
+func (e *Engine) verifyFile(ctx context.Context, relPath string, remoteByPath map[string]api.ManifestEntry, download bool) VerifyEntry {
+	entry := VerifyEntry{Path: relPath}
+	localPath, err := e.localPathFor(relPath)
+	if err != nil {
+		entry.Status, entry.Error = VerifyError, err.Error()
+		return entry
+	}
+
+	remote, onRemote := remoteByPath[relPath]
+	if onRemote {
+		entry.RemoteID, entry.RemoteHash = remote.ID, remote.ContentHash
+	}
+	if _, statErr := e.statLocal(localPath); os.IsNotExist(statErr) {
+		if !onRemote {
+			entry.Status = VerifyMissingRemote // gone from both sides
+		} else {
+			entry.Status = VerifyMissingLocal
+		}
+		return entry
+	}
+	if !onRemote {
+		entry.Status = VerifyMissingRemote
+		return entry
+	}
+
+	if entry.LocalHash, err = e.hashPath(localPath); err != nil {
+		entry.Status, entry.Error = VerifyError, err.Error()
+		return entry
+	}
+	if entry.RemoteHash == "" && download {
+		h := sha256.New()
+		if _, err := e.Client.DownloadFile(ctx, remote.ID, h); err != nil {
+			entry.Status, entry.Error = VerifyError, fmt.Sprintf("download: %v", err)
+			return entry
+		}
+		entry.RemoteHash = hex.EncodeToString(h.Sum(nil))
+	}
+
+	switch {
+	case entry.RemoteHash == "":
+		entry.Status = VerifyUnverified
+	case entry.LocalHash != entry.RemoteHash:
+		entry.Status = VerifyMismatch
+	default:
+		entry.Status = VerifyOK
+	}
+	return entry
+}
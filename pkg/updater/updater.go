@@ -1,7 +1,11 @@
 package updater
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,8 +19,13 @@ const (
 	repoOwner = "patricksimpson"
 	repoName  = "izerop-cli"
 	releaseURL = "https://api.github.com/repos/" + repoOwner + "/" + repoName + "/releases/latest"
+	// checksumsName is the release asset listing SHA256 sums of every binary.
+	checksumsName = "checksums.txt"
 )
 
+// ErrChecksumMismatch is returned when a downloaded binary doesn't match its published checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
 // Release represents a GitHub release.
 type Release struct {
 	TagName string  `json:"tag_name"`
@@ -89,8 +98,52 @@ func FindAsset(release *Release) *Asset {
 	return nil
 }
 
-// DownloadAndReplace downloads the new binary and replaces the current executable.
-func DownloadAndReplace(asset *Asset) error {
+// FindChecksums finds the checksums file published with a release.
+func FindChecksums(release *Release) *Asset {
+	for _, a := range release.Assets {
+		if a.Name == checksumsName {
+			return &a
+		}
+	}
+	return nil
+}
+
+// ExpectedChecksum fetches the release checksums file and returns the SHA256
+// listed for the given asset.
+func ExpectedChecksum(release *Release, asset *Asset) (string, error) {
+	sums := FindChecksums(release)
+	if sums == nil {
+		return "", fmt.Errorf("release %s has no %s", release.TagName, checksumsName)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(sums.BrowserDownloadURL)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch checksums: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("checksums download returned status %d", resp.StatusCode)
+	}
+
+	// Lines look like "<sha256>  <filename>" (sha256sum format)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset.Name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("could not read checksums: %w", err)
+	}
+	return "", fmt.Errorf("no checksum listed for %s", asset.Name)
+}
+
+// DownloadAndReplace downloads the new binary, verifies it against the
+// expected SHA256, and replaces the current executable.
+func DownloadAndReplace(asset *Asset, expectedSHA256 string) error {
 	client := &http.Client{Timeout: 120 * time.Second}
 	resp, err := client.Get(asset.BrowserDownloadURL)
 	if err != nil {
@@ -115,13 +168,20 @@ func DownloadAndReplace(asset *Asset) error {
 		return fmt.Errorf("could not create temp file: %w", err)
 	}
 
-	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmpFile, h), resp.Body); err != nil {
 		tmpFile.Close()
 		os.Remove(tmpPath)
 		return fmt.Errorf("download write failed: %w", err)
 	}
 	tmpFile.Close()
 
+	// Refuse to swap in a truncated or tampered binary
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(expectedSHA256) {
+		os.Remove(tmpPath)
+		return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, asset.Name, expectedSHA256, got)
+	}
+
 	// Make executable
 	if err := os.Chmod(tmpPath, 0755); err != nil {
 		os.Remove(tmpPath)
@@ -0,0 +1,99 @@
+package watcher
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+)
+
+// LogLine is one line of JSON log output. Event, Path, and Counts are set
+// on structured lines: per-file sync events and run summaries.
+type LogLine struct {
+	TS      string         `json:"ts"`
+	Level   string         `json:"level"`
+	Msg     string         `json:"msg"`
+	Event   string         `json:"event,omitempty"`
+	Path    string         `json:"path,omitempty"`
+	Counts  map[string]int `json:"counts,omitempty"`
+	Profile string         `json:"profile,omitempty"`
+}
+
+// JSONLogWriter turns log.Logger output into JSON lines for log aggregators.
+// Use it with a logger that has no prefix or flags: log.New(w, "", 0).
+type JSONLogWriter struct {
+	mu      gosync.Mutex
+	out     io.Writer
+	profile string
+}
+
+// NewJSONLogWriter creates a JSONLogWriter tagging each line with profile.
+func NewJSONLogWriter(out io.Writer, profile string) *JSONLogWriter {
+	return &JSONLogWriter{out: out, profile: profile}
+}
+
+// Write emits one JSON object per log call.
+func (j *JSONLogWriter) Write(p []byte) (int, error) {
+	if err := j.WriteLine(LogLine{Msg: strings.TrimRight(string(p), "\n")}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteLine emits a structured line, filling in the timestamp, profile, and
+// (if unset) a level guessed from Msg.
+func (j *JSONLogWriter) WriteLine(line LogLine) error {
+	line.TS = time.Now().UTC().Format(time.RFC3339)
+	line.Profile = j.profile
+	if line.Level == "" {
+		line.Level = LogLevel(line.Msg)
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err = j.out.Write(append(data, '\n'))
+	return err
+}
+
+// eventLine describes a per-file sync event as a structured log line.
+// Skips and failures return false: skips are too noisy to log and failures
+// are already logged with their error.
+func eventLine(ev sync.Event) (LogLine, bool) {
+	line := LogLine{Event: string(ev.Action), Path: ev.Path, Level: "info"}
+	switch ev.Action {
+	case sync.ActionUploaded:
+		line.Msg, line.Level = "⬆ "+ev.Path, "success"
+	case sync.ActionDownloaded:
+		line.Msg, line.Level = "⬇ "+ev.Path, "success"
+	case sync.ActionDeleted:
+		line.Msg = "🗑 " + ev.Path
+	case sync.ActionMoved:
+		line.Msg = "🔀 " + ev.Path
+	case sync.ActionConflict:
+		line.Msg, line.Level = "⚠ Conflict: "+ev.Path, "warn"
+	default:
+		return line, false
+	}
+	return line, true
+}
+
+// LogLevel classifies a watcher log message as "error", "warn", "success", or "info".
+func LogLevel(msg string) string {
+	switch {
+	case strings.Contains(msg, "error") || strings.Contains(msg, "ERROR") || strings.Contains(msg, "failed"):
+		return "error"
+	case strings.Contains(msg, "⬆") || strings.Contains(msg, "⬇") || strings.Contains(msg, "uploaded") || strings.Contains(msg, "downloaded"):
+		return "success"
+	case strings.Contains(msg, "⚠") || strings.Contains(msg, "conflict"):
+		return "warn"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package watcher
+
+import (
+	"os"
+	"syscall"
+)
+
+// PauseSignal and ResumeSignal pause and resume a running watcher process.
+var (
+	PauseSignal  os.Signal = syscall.SIGUSR1
+	ResumeSignal os.Signal = syscall.SIGUSR2
+)
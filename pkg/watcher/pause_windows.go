@@ -0,0 +1,12 @@
+//go:build windows
+
+package watcher
+
+import "os"
+
+// PauseSignal and ResumeSignal are unavailable on Windows; pausing a
+// separate watcher process is not supported there.
+var (
+	PauseSignal  os.Signal
+	ResumeSignal os.Signal
+)
@@ -0,0 +1,52 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// quietLargeFileSize is the size at which binary uploads are deferred during quiet hours.
+const quietLargeFileSize = 1 * 1024 * 1024
+
+// QuietHours is a daily window (local time) during which large uploads are deferred.
+// The window may wrap past midnight, e.g. "22:00-06:00".
+type QuietHours struct {
+	start, end int // minutes since midnight
+}
+
+// ParseQuietHours parses a window like "09:00-17:00".
+func ParseQuietHours(s string) (*QuietHours, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid quiet hours %q (expected HH:MM-HH:MM)", s)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours start %q: %w", from, err)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours end %q: %w", to, err)
+	}
+	return &QuietHours{
+		start: start.Hour()*60 + start.Minute(),
+		end:   end.Hour()*60 + end.Minute(),
+	}, nil
+}
+
+// Active reports whether t falls inside the window. A nil window is never active.
+func (q *QuietHours) Active(t time.Time) bool {
+	if q == nil || q.start == q.end {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	if q.start < q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end // wraps past midnight
+}
+
+func (q *QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.start/60, q.start%60, q.end/60, q.end%60)
+}
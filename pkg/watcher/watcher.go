@@ -1,6 +1,8 @@
 package watcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -25,18 +27,98 @@ type Config struct {
 	SettleTime   time.Duration // debounce delay before pushing local changes (default 12s)
 	Verbose      bool
 	Logger       *log.Logger
+
+	// ReconcileInterval is how often to run a full manifest reconcile to catch
+	// changes the incremental cursor missed (0 disables it).
+	ReconcileInterval time.Duration
+
+	// SyncRoot is the remote directory the sync dir maps to ("" is the
+	// default, or a project config's root_dir).
+	SyncRoot string
+	// TextExtensions and BinaryExtensions override text vs binary detection.
+	TextExtensions   []string
+	BinaryExtensions []string
+	// NoTextAPI uploads every new file as a binary, never as a text note.
+	NoTextAPI bool
+	// MaxTextSize uploads text files larger than this many bytes as
+	// binaries (0 is no limit; the CLI passes config's TextSizeLimit).
+	MaxTextSize int64
+	// NoDelete stops deletions from syncing in either direction.
+	NoDelete bool
+	// QuietHours defers large binary uploads and scheduled reconciles
+	// during a daily window (nil disables it).
+	QuietHours *QuietHours
+	// SkipGrowing leaves files that are still being written for a later push.
+	SkipGrowing bool
+	// PreserveMode syncs Unix permission bits if the server stores them.
+	PreserveMode bool
+	// MaxUploadAttempts stops retrying a file after that many failed
+	// uploads in a row, until it changes (0 retries forever).
+	MaxUploadAttempts int
+	// MaxFileSize skips uploading files larger than this many bytes (0 is
+	// no limit).
+	MaxFileSize int64
+	// ExcludeVCS adds the built-in VCS/dependency ignore set (see sync.VCSIgnorePatterns).
+	ExcludeVCS bool
+	// DefaultIgnore is the profile's default_ignore pattern list.
+	DefaultIgnore []string
+	// KeepOSJunk syncs .DS_Store, Thumbs.db, and the like instead of
+	// filtering them out.
+	KeepOSJunk bool
+	// ConflictStrategy decides where conflict copies are saved.
+	ConflictStrategy sync.ConflictStrategy
+	// Symlinks decides what happens to symbolic links in the sync dir.
+	Symlinks sync.SymlinkPolicy
+	// StateBackend is where the profile's sync state is kept.
+	StateBackend sync.StateBackend
+	// OnEvent, if set, receives per-file sync events from every run.
+	OnEvent func(sync.Event)
+	// JSONLog, if set, receives per-file events and run summaries as
+	// structured lines (event, path, counts) in place of their plain log
+	// lines. Logger should write through the same JSONLogWriter.
+	JSONLog *JSONLogWriter
+	// Once makes Run a bounded run for scripts: it pulls, waits until local
+	// changes have settled for SettleTime (catching in-flight saves), pushes,
+	// and returns. There is no polling or reconcile. Run returns an error if
+	// the pull or push failed.
+	Once bool
 }
 
 // Watcher monitors a directory and syncs changes.
 type Watcher struct {
-	cfg      Config
-	state    *sync.State
-	fsw      *fsnotify.Watcher
-	pushCh   chan struct{} // signal to trigger a push
-	stopCh   chan struct{}
-	pulling  bool // true while pull is in progress — suppresses fsnotify events
+	cfg     Config
+	store   sync.Store
+	fsw     *fsnotify.Watcher
+	pushCh  chan struct{} // signal to trigger a push
+	pulling bool          // true while pull is in progress — suppresses fsnotify events
+
+	// ctx is canceled by Stop or a signal, aborting the run in progress
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	deferred     map[string]int64 // large uploads held back during quiet hours (path → size)
+	pollFailures int              // consecutive failed polls, for backoff
+	pauseCh      chan bool        // true pauses, false resumes
+	paused       bool
+	dirty        bool      // local changes seen while paused
+	stateMod     time.Time // state file mtime as last loaded or saved
+	stuck        int       // stuck uploads at the last push, to log changes only
+	tooLarge     int       // oversized files at the last push, to log changes only
+	deferredPull int       // large downloads held back at the last pull, to log changes only
+	polls        int       // server polls so far, to schedule watch rescans
 }
 
+// maxPollBackoff caps the poll interval while the server is unreachable.
+const maxPollBackoff = 10 * time.Minute
+
+// lockWait is how long a run waits for another sync of the same profile
+// (such as 'izerop sync' in a terminal) before skipping this round.
+const lockWait = 2 * time.Second
+
+// rescanEvery is how many server polls pass between safety rescans of the
+// directory watches, which catch subtrees fsnotify never reported.
+const rescanEvery = 5
+
 // New creates a new Watcher.
 func New(cfg Config) (*Watcher, error) {
 	fsw, err := fsnotify.NewWatcher()
@@ -45,14 +127,24 @@ func New(cfg Config) (*Watcher, error) {
 	}
 
 	sync.MigrateState(cfg.Profile, cfg.SyncDir)
-	state, _ := sync.LoadState(cfg.Profile)
+	store, err := sync.OpenStore(cfg.Profile, cfg.StateBackend)
+	if err != nil {
+		fsw.Close()
+		return nil, err
+	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Watcher{
 		cfg:    cfg,
-		state:  state,
+		store:  store,
 		fsw:    fsw,
 		pushCh: make(chan struct{}, 1), // buffered so we don't block
-		stopCh: make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+
+		deferred: make(map[string]int64),
+		pauseCh:  make(chan bool, 1),
+		stateMod: stateModTime(cfg.Profile, cfg.StateBackend),
 	}, nil
 }
 
@@ -64,26 +156,93 @@ func (w *Watcher) Run() error {
 	}
 
 	w.cfg.Logger.Printf("Watching: %s ↔ %s", w.cfg.SyncDir, w.cfg.ServerURL)
-	w.cfg.Logger.Printf("Poll interval: %s, settle time: %s, fsnotify: enabled", w.cfg.PollInterval, w.cfg.SettleTime)
+	if w.cfg.Once {
+		w.cfg.Logger.Printf("One-shot run, settle time: %s, fsnotify: enabled", w.cfg.SettleTime)
+	} else {
+		w.cfg.Logger.Printf("Poll interval: %s, settle time: %s, fsnotify: enabled", w.cfg.PollInterval, w.cfg.SettleTime)
+	}
+	if w.cfg.ReconcileInterval > 0 {
+		w.cfg.Logger.Printf("Reconcile interval: %s", w.cfg.ReconcileInterval)
+	}
+	if w.cfg.QuietHours != nil {
+		w.cfg.Logger.Printf("Quiet hours: %s (large uploads deferred)", w.cfg.QuietHours)
+	}
+	if w.cfg.PreserveMode && w.cfg.Client.Require(w.ctx, api.FeatureFileModes) != nil {
+		w.cfg.Logger.Println("⚠ preserve_mode is set, but this server doesn't store file modes; ignoring it")
+		w.cfg.PreserveMode = false
+	}
 
 	// Add the sync dir and all subdirs to fsnotify
 	if err := w.addWatchRecursive(w.cfg.SyncDir); err != nil {
 		return fmt.Errorf("could not watch directory: %w", err)
 	}
 
-	// Handle signals
+	// Handle signals. They cancel the context rather than wait for the main
+	// loop, so a sync in progress stops without finishing its transfers.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigCh:
+			w.cfg.Logger.Println("Shutting down...")
+			w.cancel()
+		case <-done:
+		}
+	}()
+	if PauseSignal != nil {
+		ctlCh := make(chan os.Signal, 1)
+		signal.Notify(ctlCh, PauseSignal, ResumeSignal)
+		defer signal.Stop(ctlCh)
+		go func() {
+			for sig := range ctlCh {
+				if sig == PauseSignal {
+					w.Pause()
+				} else {
+					w.Resume()
+				}
+			}
+		}()
+	}
+
+	// Run initial sync; a one-shot run holds the push until changes settle
+	var pullErr error
+	if w.cfg.Once {
+		w.cfg.Logger.Println("Pull (startup)...")
+		pullErr = w.runPull()
+	} else {
+		w.runSync("startup")
+	}
 
-	// Run initial sync
-	w.runSync("startup")
+	// Server poll timer — rescheduled after each poll so failures can back off
+	pollTimer := time.NewTimer(w.cfg.PollInterval)
+	defer pollTimer.Stop()
+	if w.cfg.Once {
+		pollTimer.Stop()
+	}
 
-	// Server poll ticker
-	pollTicker := time.NewTicker(w.cfg.PollInterval)
-	defer pollTicker.Stop()
+	// Full reconcile ticker — a nil channel never fires when disabled
+	var reconcileCh <-chan time.Time
+	if w.cfg.ReconcileInterval > 0 && !w.cfg.Once {
+		reconcileTicker := time.NewTicker(w.cfg.ReconcileInterval)
+		defer reconcileTicker.Stop()
+		reconcileCh = reconcileTicker.C
+	}
 
 	// Debounce timer for local changes — wait 2s after last change before pushing
 	var debounce *time.Timer
+	if w.cfg.Once {
+		// Start the settle window now, so a quiet directory pushes after one
+		w.cfg.Logger.Printf("Waiting for %s without local changes before pushing", w.cfg.SettleTime)
+		debounce = time.AfterFunc(w.cfg.SettleTime, func() {
+			select {
+			case w.pushCh <- struct{}{}:
+			default:
+			}
+		})
+	}
 
 	for {
 		select {
@@ -91,7 +250,10 @@ func (w *Watcher) Run() error {
 			if !ok {
 				return nil
 			}
-			if w.pulling || w.shouldIgnore(event.Name) {
+			if event.Name == filepath.Join(w.cfg.SyncDir, ".izeropignore") {
+				// Not synced itself, but a push applies the new rules
+				w.reloadIgnore()
+			} else if w.pulling || w.shouldIgnore(event.Name) {
 				continue
 			}
 			if w.cfg.Verbose {
@@ -105,6 +267,20 @@ func (w *Watcher) Run() error {
 				}
 			}
 
+			// A renamed or removed directory leaves stale watches behind, and
+			// its subdirectories under the new name were never added
+			if event.Has(fsnotify.Rename) || event.Has(fsnotify.Remove) {
+				if w.watching(event.Name) {
+					w.rescanWatches("directory moved")
+				}
+			}
+
+			// While paused, just note that something changed
+			if w.paused {
+				w.dirty = true
+				continue
+			}
+
 			// Debounce: reset timer on each event, push after settle time of quiet
 			// This gives the user time to finish renaming files/folders before sync fires
 			if debounce != nil {
@@ -124,47 +300,223 @@ func (w *Watcher) Run() error {
 			w.cfg.Logger.Printf("fsnotify error: %v", err)
 
 		case <-w.pushCh:
-			w.runPush()
+			if w.paused {
+				w.dirty = true
+			} else if w.cfg.Once {
+				// Skipped while another sync held the lock: a retry is queued.
+				// A canceled push falls through to the shutdown below.
+				if ran, err := w.runPush(); ran && w.ctx.Err() == nil {
+					w.fsw.Close()
+					w.store.Close()
+					if err == nil {
+						err = pullErr
+					}
+					return err
+				}
+			} else {
+				w.runPush()
+			}
+
+		case <-pollTimer.C:
+			if !w.paused {
+				w.runPull()
+				w.flushDeferred()
+			}
+			w.polls++
+			if w.polls%rescanEvery == 0 && w.rescanWatches("periodic rescan") > 0 {
+				// Changes inside a subtree we weren't watching went unseen
+				if w.paused {
+					w.dirty = true
+				} else {
+					select {
+					case w.pushCh <- struct{}{}:
+					default:
+					}
+				}
+			}
+			pollTimer.Reset(w.pollInterval())
 
-		case <-pollTicker.C:
-			w.runPull()
+		case <-reconcileCh:
+			if !w.paused {
+				w.runReconcile()
+			}
 
-		case <-sigCh:
-			w.cfg.Logger.Println("Shutting down...")
-			w.saveState()
-			w.fsw.Close()
-			w.cfg.Logger.Println("State saved. Goodbye!")
-			return nil
+		case pause := <-w.pauseCh:
+			if pause && !w.paused {
+				w.paused = true
+				if debounce != nil && debounce.Stop() {
+					w.dirty = true // a push was pending
+				}
+				w.cfg.Logger.Println("⏸ Paused — local changes will sync on resume")
+			} else if !pause && w.paused {
+				w.paused = false
+				w.cfg.Logger.Printf("▶ Resumed (local changes while paused: %t)", w.dirty)
+				w.dirty = false
+				w.runSync("resume")
+			}
 
-		case <-w.stopCh:
-			w.saveState()
+		case <-w.ctx.Done():
+			saved := w.saveOnExit()
 			w.fsw.Close()
+			w.store.Close()
+			if saved {
+				w.cfg.Logger.Println("State saved. Goodbye!")
+			} else {
+				w.cfg.Logger.Println("State left to the other running sync. Goodbye!")
+			}
+			if w.cfg.Once {
+				// The bounded run didn't finish
+				return w.ctx.Err()
+			}
 			return nil
 		}
 	}
 }
 
-// Stop signals the watcher to stop.
+// Pause suspends syncing without stopping the watcher. fsnotify watches and
+// the cursor are kept; local changes are noted and synced on Resume.
+func (w *Watcher) Pause() {
+	w.setPaused(true)
+}
+
+// Resume ends a pause and runs one full sync.
+func (w *Watcher) Resume() {
+	w.setPaused(false)
+}
+
+func (w *Watcher) setPaused(pause bool) {
+	// Only the latest request matters; drop a pending one that wasn't handled yet
+	select {
+	case <-w.pauseCh:
+	default:
+	}
+	w.pauseCh <- pause
+}
+
+// Stop signals the watcher to stop. A sync in progress is canceled,
+// aborting its transfers; what finished before that is kept in state.
 func (w *Watcher) Stop() {
-	close(w.stopCh)
+	w.cancel()
+}
+
+// newEngine creates a sync engine from the watcher config. Engines are
+// recreated per run so ignore and attribute file edits are picked up.
+func (w *Watcher) newEngine() *sync.Engine {
+	engine := sync.NewEngine(w.cfg.Client, w.cfg.SyncDir, w.store.State())
+	engine.SetRoot(w.cfg.SyncRoot)
+	engine.Store = w.store
+	engine.Verbose = w.cfg.Verbose
+	engine.Ignore = sync.LoadIgnoreRules(w.cfg.SyncDir, w.cfg.DefaultIgnore...)
+	engine.Types = sync.LoadFileTypes(w.cfg.SyncDir, w.cfg.TextExtensions, w.cfg.BinaryExtensions)
+	engine.NoTextAPI = w.cfg.NoTextAPI
+	engine.MaxTextSize = w.cfg.MaxTextSize
+	engine.PropagateDeletes = !w.cfg.NoDelete
+	engine.DeferUpload = w.deferUpload
+	engine.DeferDownload = w.deferDownload
+	engine.SkipGrowing = w.cfg.SkipGrowing
+	engine.PreserveMode = w.cfg.PreserveMode
+	engine.MaxUploadAttempts = w.cfg.MaxUploadAttempts
+	engine.MaxFileSize = w.cfg.MaxFileSize
+	engine.FilterOSJunk = !w.cfg.KeepOSJunk
+	engine.ConflictStrategy = w.cfg.ConflictStrategy
+	engine.Symlinks = w.cfg.Symlinks
+	engine.OnEvent = w.onEvent
+	if w.cfg.ExcludeVCS {
+		engine.ExcludeVCS()
+	}
+	return engine
+}
+
+// onEvent writes per-file events to the JSON log and passes them on to
+// Config.OnEvent.
+func (w *Watcher) onEvent(ev sync.Event) {
+	if w.cfg.JSONLog != nil {
+		if line, ok := eventLine(ev); ok {
+			w.cfg.JSONLog.WriteLine(line)
+		}
+	}
+	if w.cfg.OnEvent != nil {
+		w.cfg.OnEvent(ev)
+	}
+}
+
+// logCounts logs a run summary. In JSON logs the counts are also fields,
+// so aggregators don't have to parse the message.
+func (w *Watcher) logCounts(event string, counts map[string]int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if w.cfg.JSONLog != nil {
+		w.cfg.JSONLog.WriteLine(LogLine{Level: "success", Event: event, Msg: msg, Counts: counts})
+		return
+	}
+	w.cfg.Logger.Println(msg)
+}
+
+// deferUpload holds back large binary uploads during quiet hours, queueing
+// them until the window ends.
+func (w *Watcher) deferUpload(relPath string, size int64) bool {
+	if size < quietLargeFileSize || !w.cfg.QuietHours.Active(time.Now()) {
+		return false
+	}
+	if _, queued := w.deferred[relPath]; !queued {
+		w.cfg.Logger.Printf("⏸ Deferring %s (%d bytes) until quiet hours end", relPath, size)
+		w.deferred[relPath] = size
+	}
+	return true
+}
+
+// deferDownload holds back large downloads during quiet hours. The pull
+// cursor stays before them, so the first poll after the window gets them.
+func (w *Watcher) deferDownload(relPath string, size int64) bool {
+	return size >= quietLargeFileSize && w.cfg.QuietHours.Active(time.Now())
+}
+
+// logDeferredPull notes downloads held back by quiet hours. Every poll in
+// the window defers them again, so it only logs when their number changes.
+func (w *Watcher) logDeferredPull(n int) {
+	if n > 0 && n != w.deferredPull {
+		w.cfg.Logger.Printf("⏸ %d large download(s) deferred until quiet hours end", n)
+	}
+	w.deferredPull = n
+}
+
+// flushDeferred pushes queued uploads once quiet hours are over.
+func (w *Watcher) flushDeferred() {
+	if len(w.deferred) == 0 || w.cfg.QuietHours.Active(time.Now()) {
+		return
+	}
+	w.cfg.Logger.Printf("Quiet hours over — uploading %d deferred file(s)", len(w.deferred))
+	w.deferred = make(map[string]int64)
+	w.runPush()
 }
 
 func (w *Watcher) runSync(reason string) {
+	lock := w.acquireLock("Sync (" + reason + ")")
+	if lock == nil {
+		w.retryPush()
+		return
+	}
+	defer lock.Release()
+
 	w.cfg.Logger.Printf("Sync (%s)...", reason)
 	w.pulling = true
-	engine := sync.NewEngine(w.cfg.Client, w.cfg.SyncDir, w.state)
-	engine.Verbose = w.cfg.Verbose
+	engine := w.newEngine()
 
 	// Pull
-	pullResult, newCursor, err := engine.PullSync(w.state.Cursor)
+	pullResult, newCursor, err := engine.PullSync(w.ctx, w.store.Cursor())
+	if w.ctx.Err() != nil {
+		return // stopping; state is saved on the way out
+	}
 	if err != nil {
 		w.cfg.Logger.Printf("Pull error: %v", err)
+		w.logAuthExpired(err)
 	} else {
-		w.state.Cursor = newCursor
-		if pullResult.Downloaded > 0 || pullResult.Deleted > 0 || pullResult.Conflicts > 0 {
-			w.cfg.Logger.Printf("⬇ %d downloaded, %d deleted, %d conflicts",
-				pullResult.Downloaded, pullResult.Deleted, pullResult.Conflicts)
+		w.store.SetCursor(newCursor)
+		if pullResult.Downloaded > 0 || pullResult.Deleted > 0 || pullResult.Moved > 0 || pullResult.Conflicts > 0 {
+			w.logCounts("pull", map[string]int{"downloaded": pullResult.Downloaded, "deleted": pullResult.Deleted, "moved": pullResult.Moved, "conflicts": pullResult.Conflicts},
+				"⬇ %d downloaded, %d deleted, %d moved, %d conflicts", pullResult.Downloaded, pullResult.Deleted, pullResult.Moved, pullResult.Conflicts)
 		}
+		w.logDeletesSkipped(pullResult.DeletesSkipped)
+		w.logDeferredPull(pullResult.Deferred)
 		for _, e := range pullResult.Errors {
 			w.cfg.Logger.Printf("⚠ pull: %s", e)
 		}
@@ -174,14 +526,21 @@ func (w *Watcher) runSync(reason string) {
 	w.pulling = false
 
 	// Push
-	pushResult, err := engine.PushSync()
+	pushResult, err := engine.PushSync(w.ctx)
+	if w.ctx.Err() != nil {
+		return
+	}
 	if err != nil {
-		w.cfg.Logger.Printf("Push error: %v", err)
+		w.logPushError(err)
 	} else {
-		if pushResult.Uploaded > 0 || pushResult.Deleted > 0 || pushResult.Conflicts > 0 {
-			w.cfg.Logger.Printf("⬆ %d uploaded, %d deleted, %d conflicts",
-				pushResult.Uploaded, pushResult.Deleted, pushResult.Conflicts)
+		if pushResult.Uploaded > 0 || pushResult.Deleted > 0 || pushResult.Moved > 0 || pushResult.Conflicts > 0 {
+			w.logCounts("push", map[string]int{"uploaded": pushResult.Uploaded, "deleted": pushResult.Deleted, "moved": pushResult.Moved, "conflicts": pushResult.Conflicts},
+				"⬆ %d uploaded, %d deleted, %d moved, %d conflicts", pushResult.Uploaded, pushResult.Deleted, pushResult.Moved, pushResult.Conflicts)
 		}
+		w.logDeletesSkipped(pushResult.DeletesSkipped)
+		w.logStillWriting(pushResult.StillWriting)
+		w.logStuck(pushResult.Stuck)
+		w.logTooLarge(pushResult.TooLarge)
 		for _, e := range pushResult.Errors {
 			w.cfg.Logger.Printf("⚠ push: %s", e)
 		}
@@ -190,52 +549,258 @@ func (w *Watcher) runSync(reason string) {
 	w.saveState()
 }
 
-func (w *Watcher) runPull() {
+// pollInterval returns the delay before the next server poll: the configured
+// interval, doubled for each consecutive failure up to maxPollBackoff.
+func (w *Watcher) pollInterval() time.Duration {
+	interval := w.cfg.PollInterval
+	for i := 0; i < w.pollFailures && interval < maxPollBackoff; i++ {
+		interval *= 2
+	}
+	if interval > maxPollBackoff {
+		interval = maxPollBackoff
+	}
+	return interval
+}
+
+// runPull pulls remote changes and returns the pull error, if any. A pull
+// skipped because another sync held the lock is not an error.
+func (w *Watcher) runPull() error {
+	lock := w.acquireLock("Pull")
+	if lock == nil {
+		return nil
+	}
+	defer lock.Release()
+
 	w.pulling = true
 	defer func() { w.pulling = false }()
 
-	engine := sync.NewEngine(w.cfg.Client, w.cfg.SyncDir, w.state)
-	engine.Verbose = w.cfg.Verbose
+	engine := w.newEngine()
 
-	pullResult, newCursor, err := engine.PullSync(w.state.Cursor)
+	pullResult, newCursor, err := engine.PullSync(w.ctx, w.store.Cursor())
+	if err != nil && w.ctx.Err() != nil {
+		return err // stopping, not a failed poll
+	}
 	if err != nil {
-		w.cfg.Logger.Printf("Pull error: %v", err)
-		return
+		w.pollFailures++
+		w.cfg.Logger.Printf("Pull error: %v (next poll in %s)", err, w.pollInterval())
+		if w.logAuthExpired(err) {
+			w.saveState() // keep whatever downloaded before the token was rejected
+		}
+		return fmt.Errorf("pull failed: %w", err)
 	}
-	w.state.Cursor = newCursor
-	if pullResult.Downloaded > 0 || pullResult.Deleted > 0 || pullResult.Conflicts > 0 {
-		w.cfg.Logger.Printf("⬇ %d downloaded, %d deleted, %d conflicts",
-			pullResult.Downloaded, pullResult.Deleted, pullResult.Conflicts)
+	if w.pollFailures > 0 {
+		w.cfg.Logger.Printf("Server reachable again after %d failed poll(s); polling every %s", w.pollFailures, w.cfg.PollInterval)
+		w.pollFailures = 0
 	}
+	w.store.SetCursor(newCursor)
+	if pullResult.Downloaded > 0 || pullResult.Deleted > 0 || pullResult.Moved > 0 || pullResult.Conflicts > 0 {
+		w.logCounts("pull", map[string]int{"downloaded": pullResult.Downloaded, "deleted": pullResult.Deleted, "moved": pullResult.Moved, "conflicts": pullResult.Conflicts},
+			"⬇ %d downloaded, %d deleted, %d moved, %d conflicts", pullResult.Downloaded, pullResult.Deleted, pullResult.Moved, pullResult.Conflicts)
+	}
+	w.logDeletesSkipped(pullResult.DeletesSkipped)
+	w.logDeferredPull(pullResult.Deferred)
 	for _, e := range pullResult.Errors {
 		w.cfg.Logger.Printf("⚠ pull: %s", e)
 	}
 	w.saveState()
+	return nil
 }
 
-func (w *Watcher) runPush() {
-	engine := sync.NewEngine(w.cfg.Client, w.cfg.SyncDir, w.state)
-	engine.Verbose = w.cfg.Verbose
+// runPush pushes local changes. It reports false if another sync held the
+// lock, in which case a retry is queued, and returns the push error, if any.
+func (w *Watcher) runPush() (bool, error) {
+	lock := w.acquireLock("Push")
+	if lock == nil {
+		w.retryPush()
+		return false, nil
+	}
+	defer lock.Release()
 
-	pushResult, err := engine.PushSync()
+	engine := w.newEngine()
+
+	pushResult, err := engine.PushSync(w.ctx)
+	if err != nil && w.ctx.Err() != nil {
+		return true, err
+	}
 	if err != nil {
-		w.cfg.Logger.Printf("Push error: %v", err)
-		return
+		w.logPushError(err)
+		return true, fmt.Errorf("push failed: %w", err)
 	}
-	if pushResult.Uploaded > 0 || pushResult.Deleted > 0 || pushResult.Conflicts > 0 {
-		w.cfg.Logger.Printf("⬆ %d uploaded, %d deleted, %d conflicts",
-			pushResult.Uploaded, pushResult.Deleted, pushResult.Conflicts)
+	if pushResult.Uploaded > 0 || pushResult.Deleted > 0 || pushResult.Moved > 0 || pushResult.Conflicts > 0 {
+		w.logCounts("push", map[string]int{"uploaded": pushResult.Uploaded, "deleted": pushResult.Deleted, "moved": pushResult.Moved, "conflicts": pushResult.Conflicts},
+			"⬆ %d uploaded, %d deleted, %d moved, %d conflicts", pushResult.Uploaded, pushResult.Deleted, pushResult.Moved, pushResult.Conflicts)
 	}
+	w.logDeletesSkipped(pushResult.DeletesSkipped)
+	w.logStillWriting(pushResult.StillWriting)
+	w.logStuck(pushResult.Stuck)
+	w.logTooLarge(pushResult.TooLarge)
 	for _, e := range pushResult.Errors {
 		w.cfg.Logger.Printf("⚠ push: %s", e)
 	}
 	w.saveState()
+	return true, nil
+}
+
+// logPushError logs a failed push, with a hint when the state looks stale.
+func (w *Watcher) logPushError(err error) {
+	w.cfg.Logger.Printf("Push error: %v", err)
+	if errors.Is(err, sync.ErrStaleState) {
+		w.cfg.Logger.Println("Sync state looks stale for this server; stop the watcher and run 'izerop state reset'")
+	}
+	if w.logAuthExpired(err) {
+		w.saveState()
+	}
+}
+
+// logAuthExpired adds a login hint when err means the token was rejected,
+// and reports whether it did.
+func (w *Watcher) logAuthExpired(err error) bool {
+	if !errors.Is(err, api.ErrUnauthorized) {
+		return false
+	}
+	w.cfg.Logger.Println("🔒 Authentication expired mid-sync; run 'izerop login' and restart the watcher to resume")
+	return true
+}
+
+// logDeletesSkipped notes deletions held back by NoDelete.
+func (w *Watcher) logDeletesSkipped(n int) {
+	if n > 0 {
+		w.cfg.Logger.Printf("⏭ %d deletion(s) skipped (no-delete)", n)
+	}
+}
+
+// logStillWriting notes files left for later because they were still growing.
+func (w *Watcher) logStillWriting(n int) {
+	if n > 0 {
+		w.cfg.Logger.Printf("✏ %d file(s) still being written — will retry", n)
+	}
+}
+
+// logStuck notes files skipped after repeated upload failures. Every push
+// skips them again, so it only logs when their number changes.
+func (w *Watcher) logStuck(n int) {
+	if n > 0 && n != w.stuck {
+		w.cfg.Logger.Printf("⛔ %d file(s) skipped after repeated upload failures — see 'izerop status'", n)
+	}
+	w.stuck = n
+}
+
+// runReconcile does a full manifest-based reconcile as a safety net for
+// changes the incremental cursor missed.
+// logTooLarge warns about files over MaxFileSize when their number
+// changes, rather than on every push.
+func (w *Watcher) logTooLarge(paths []string) {
+	if len(paths) > 0 && len(paths) != w.tooLarge {
+		w.cfg.Logger.Printf("⚠ %d file(s) over max_file_size — not uploaded: %s", len(paths), strings.Join(paths, ", "))
+	}
+	w.tooLarge = len(paths)
+}
+
+func (w *Watcher) runReconcile() {
+	if w.cfg.QuietHours.Active(time.Now()) {
+		w.cfg.Logger.Println("Reconcile skipped (quiet hours)")
+		return
+	}
+
+	lock := w.acquireLock("Reconcile")
+	if lock == nil {
+		return
+	}
+	defer lock.Release()
+
+	w.pulling = true
+	defer func() { w.pulling = false }()
+
+	engine := w.newEngine()
+
+	w.cfg.Logger.Println("Reconcile (scheduled)...")
+	result, err := engine.Reconcile(w.ctx, false)
+	if w.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		w.cfg.Logger.Printf("Reconcile error: %v", err)
+		return
+	}
+	if result.Downloaded > 0 || result.Uploaded > 0 || result.Deleted > 0 || result.Conflicts > 0 {
+		w.logCounts("reconcile", map[string]int{"downloaded": result.Downloaded, "uploaded": result.Uploaded, "deleted": result.Deleted, "conflicts": result.Conflicts},
+			"🔄 %d downloaded, %d uploaded, %d deleted, %d conflicts", result.Downloaded, result.Uploaded, result.Deleted, result.Conflicts)
+	}
+	w.logDeletesSkipped(result.DeletesSkipped)
+	w.logTooLarge(result.TooLarge)
+	for _, e := range result.Errors {
+		w.cfg.Logger.Printf("⚠ reconcile: %s", e)
+	}
+	w.saveState()
 }
 
 func (w *Watcher) saveState() {
-	if err := sync.SaveState(w.cfg.Profile, w.state); err != nil {
+	if err := w.store.Flush(); err != nil {
 		w.cfg.Logger.Printf("Warning: could not save state: %v", err)
 	}
+	w.stateMod = stateModTime(w.cfg.Profile, w.cfg.StateBackend)
+}
+
+// acquireLock takes the profile's sync lock for one run, then reloads state
+// if another process saved it since the watcher last did, so the run
+// doesn't overwrite that work. It logs and returns nil when another sync
+// still holds the lock.
+func (w *Watcher) acquireLock(run string) *sync.Lock {
+	lock, err := sync.AcquireLock(w.cfg.Profile, lockWait)
+	if err != nil {
+		w.cfg.Logger.Printf("⏳ %s skipped: %v", run, err)
+		return nil
+	}
+	if mod := stateModTime(w.cfg.Profile, w.cfg.StateBackend); !mod.Equal(w.stateMod) {
+		if store, err := sync.OpenStore(w.cfg.Profile, w.cfg.StateBackend); err == nil {
+			w.store.Close()
+			w.store = store
+			w.stateMod = mod
+			w.cfg.Logger.Println("Sync state was updated by another izerop process; reloaded")
+		}
+	}
+	return lock
+}
+
+// retryPush queues another push after the settle time, for a push that was
+// skipped while another sync held the lock.
+func (w *Watcher) retryPush() {
+	time.AfterFunc(w.cfg.SettleTime, func() {
+		select {
+		case w.pushCh <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// saveOnExit saves state at shutdown, unless another sync holds the lock or
+// has saved newer state that the watcher's copy would overwrite. It reports
+// whether state was saved.
+func (w *Watcher) saveOnExit() bool {
+	lock, err := sync.AcquireLock(w.cfg.Profile, 0)
+	if err != nil {
+		return false
+	}
+	defer lock.Release()
+	if !stateModTime(w.cfg.Profile, w.cfg.StateBackend).Equal(w.stateMod) {
+		return false
+	}
+	w.saveState()
+	return true
+}
+
+// stateModTime returns the modification time of the profile's state file,
+// or the zero time if it doesn't exist yet.
+func stateModTime(profile string, backend sync.StateBackend) time.Time {
+	path, err := sync.StoreFile(profile, backend)
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
 }
 
 func (w *Watcher) addWatchRecursive(dir string) error {
@@ -253,12 +818,74 @@ func (w *Watcher) addWatchRecursive(dir string) error {
 	})
 }
 
+// reloadIgnore reports a change to the sync dir's .izeropignore. Every run
+// loads the ignore rules afresh, so this only parses them to confirm the
+// edit in the log; the caller schedules a push to apply them.
+func (w *Watcher) reloadIgnore() {
+	rules := sync.LoadIgnoreRules(w.cfg.SyncDir, w.cfg.DefaultIgnore...)
+	mode := ""
+	if rules.IncludeMode() {
+		mode = ", include mode"
+	}
+	w.cfg.Logger.Printf("🙈 Ignore rules reloaded (%d patterns%s)", rules.Len(), mode)
+}
+
+// watching reports whether path has an fsnotify watch, i.e. it is (or was)
+// a directory in the sync tree.
+func (w *Watcher) watching(path string) bool {
+	for _, p := range w.fsw.WatchList() {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// rescanWatches brings the fsnotify watches in line with the directories on
+// disk: it drops watches on paths that no longer exist and adds any directory
+// that isn't watched yet. It returns how many directories were added.
+func (w *Watcher) rescanWatches(reason string) int {
+	watched := make(map[string]bool)
+	removed := 0
+	for _, p := range w.fsw.WatchList() {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			watched[p] = true
+			continue
+		}
+		if w.fsw.Remove(p) == nil {
+			removed++
+		}
+	}
+
+	added := 0
+	filepath.Walk(w.cfg.SyncDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != w.cfg.SyncDir {
+			return filepath.SkipDir
+		}
+		if !watched[path] && w.fsw.Add(path) == nil {
+			added++
+		}
+		return nil
+	})
+
+	if added > 0 || removed > 0 {
+		w.cfg.Logger.Printf("🔭 Watches rescanned (%s): %d added, %d removed", reason, added, removed)
+	}
+	return added
+}
+
 func (w *Watcher) shouldIgnore(path string) bool {
 	name := filepath.Base(path)
 	// Ignore hidden files, sync state, conflict files, temp files
 	if strings.HasPrefix(name, ".") {
 		return true
 	}
+	if !w.cfg.KeepOSJunk && sync.IsOSJunk(name) {
+		return true
+	}
 	if name == ".izerop-sync.json" {
 		return true
 	}
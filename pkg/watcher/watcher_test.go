@@ -0,0 +1,162 @@
+package watcher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/patricksimpson/izerop-cli/pkg/api"
+	"github.com/patricksimpson/izerop-cli/pkg/sync"
+)
+
+// syncBuffer is a log destination safe to read while the watcher writes.
+type syncBuffer struct {
+	mu  gosync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testServer serves a root directory holding secret.txt and records the
+// IDs of files deleted through it.
+func testServer(t *testing.T, secret string) (*httptest.Server, func() []string) {
+	sum := sha256.Sum256([]byte(secret))
+	var mu gosync.Mutex
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/sync/changes":
+			json.NewEncoder(w).Encode(api.ChangesResponse{Cursor: "c1"})
+		case r.URL.Path == "/api/v1/directories":
+			json.NewEncoder(w).Encode(map[string]any{"directories": []api.Directory{{ID: "d1", Name: "root", Path: "/root"}}})
+		case r.URL.Path == "/api/v1/files":
+			json.NewEncoder(w).Encode(map[string]any{"files": []api.FileEntry{{ID: "f1", Name: "secret.txt", Path: "/root/secret.txt",
+				DirectoryID: "d1", Size: int64(len(secret)), ContentHash: hex.EncodeToString(sum[:]), UpdatedAt: "t1"}}})
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/v1/files/"):
+			mu.Lock()
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/api/v1/files/"))
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), deleted...)
+	}
+}
+
+func newTestWatcher(t *testing.T, srv *httptest.Server, dir string, logs *syncBuffer) *Watcher {
+	t.Setenv("HOME", t.TempDir())
+	w, err := New(Config{
+		Profile:      "test",
+		SyncDir:      dir,
+		ServerURL:    srv.URL,
+		Client:       api.NewClient(srv.URL, "token"),
+		PollInterval: time.Hour,
+		SettleTime:   20 * time.Millisecond,
+		Logger:       log.New(logs, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// TestIgnoreReload edits .izeropignore under a running watcher: the edit is
+// reported, and the next run's engine applies the new rules.
+func TestIgnoreReload(t *testing.T) {
+	srv, _ := testServer(t, "s")
+	dir := t.TempDir()
+	logs := &syncBuffer{}
+	w := newTestWatcher(t, srv, dir, logs)
+
+	done := make(chan error, 1)
+	go func() { done <- w.Run() }()
+
+	// Events before the watch is added are missed, so keep saving the file
+	// until one is seen
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "Ignore rules reloaded (2 patterns)") {
+		if time.Now().After(deadline) {
+			w.Stop()
+			t.Fatalf("reload never logged:\n%s", logs.String())
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".izeropignore"), []byte("*.log\nbuild/\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	w.Stop()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	ignore := w.newEngine().Ignore
+	if !ignore.IsIgnored("debug.log", false) || !ignore.IsIgnored("build", true) {
+		t.Error("new engine doesn't apply the reloaded rules")
+	}
+	if ignore.IsIgnored("notes.md", false) {
+		t.Error("notes.md ignored")
+	}
+}
+
+// TestIgnoredPathNotDeleted removes a tracked file locally: once it is
+// ignored, the push must leave the server copy and its record alone.
+func TestIgnoredPathNotDeleted(t *testing.T) {
+	for _, ignored := range []bool{false, true} {
+		name := "tracked"
+		if ignored {
+			name = "ignored"
+		}
+		t.Run(name, func(t *testing.T) {
+			srv, deleted := testServer(t, "s")
+			dir := t.TempDir()
+			w := newTestWatcher(t, srv, dir, &syncBuffer{})
+			defer w.store.Close()
+			sum := sha256.Sum256([]byte("s"))
+			w.store.SetRecord("secret.txt", sync.FileRecord{RemoteID: "f1", Size: 1, Hash: hex.EncodeToString(sum[:]), RemoteTime: "t1"})
+
+			if ignored {
+				if err := os.WriteFile(filepath.Join(dir, ".izeropignore"), []byte("secret.txt\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				w.reloadIgnore()
+			}
+			if ran, err := w.runPush(); !ran || err != nil {
+				t.Fatalf("push ran %t: %v", ran, err)
+			}
+
+			_, kept := w.store.GetRecord("secret.txt")
+			if ignored && (len(deleted()) > 0 || !kept) {
+				t.Errorf("ignored file deleted on the server (%v), record kept %t", deleted(), kept)
+			}
+			if !ignored && len(deleted()) != 1 {
+				t.Errorf("deleted %v, want [f1]", deleted())
+			}
+		})
+	}
+}